@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"ride-sharing/shared/auth"
+	"ride-sharing/shared/contracts"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	authModeJWT = "jwt"
+	// authModeInsecure trusts the userID sent by the clients, for local development only
+	authModeInsecure = "insecure"
+
+	environmentDevelopment = "development"
+
+	// jwksRefreshInterval bounds how often tokens signed with unknown keys reload the JWKS file
+	jwksRefreshInterval = time.Minute
+)
+
+type AuthConfig struct {
+	Mode        string
+	Environment string // the insecure mode is refused outside development
+	HMACSecret  string // verifies HS256/384/512 tokens
+	JWKSFile    string // verifies RS*, PS* and ES* tokens with the keys of the file
+	Issuer      string
+	Audience    string
+}
+
+var (
+	errUnauthenticated  = errors.New("authentication required")
+	errIdentityMismatch = errors.New("the request is not made by the authenticated user")
+	errMissingUserID    = errors.New("userID is required")
+	errMissingRole      = errors.New("role must be rider or driver")
+	errNoAuthMiddleware = errors.New("the route is not behind the auth middleware")
+)
+
+// tokenClaims are the claims of the access tokens, the subject being the user ID
+type tokenClaims struct {
+	Role auth.Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type authenticator struct {
+	insecure    bool
+	hmacSecret  []byte
+	jwksFile    string
+	keys        map[string]crypto.PublicKey // JWKS keys by key ID
+	refreshedAt time.Time                   // when the JWKS file was last read
+	keysMu      sync.Mutex
+	parser      *jwt.Parser
+	now         func() time.Time
+}
+
+func NewAuthenticator(cfg AuthConfig) (*authenticator, error) {
+	switch cfg.Mode {
+	case authModeInsecure:
+		if cfg.Environment != environmentDevelopment {
+			return nil, fmt.Errorf("insecure auth mode is only allowed in development, not in %q", cfg.Environment)
+		}
+		log.Printf("WARNING: authentication is disabled, the gateway trusts the userID sent by the clients")
+		return &authenticator{insecure: true}, nil
+	case authModeJWT:
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", cfg.Mode)
+	}
+
+	a := &authenticator{now: time.Now}
+	var methods []string
+
+	if cfg.HMACSecret != "" {
+		a.hmacSecret = []byte(cfg.HMACSecret)
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwksFile = cfg.JWKSFile
+		a.keys = keys
+		a.refreshedAt = a.now()
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512")
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("jwt auth mode needs an HMAC secret or a JWKS file")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(options...)
+
+	return a, nil
+}
+
+// Require only lets through the requests authenticated as one of the roles,
+// putting the identity of the user in the request context
+func (a *authenticator) Require(next http.HandlerFunc, roles ...auth.Role) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.insecure {
+			next(w, r.WithContext(context.WithValue(r.Context(), insecureKey{}, true)))
+			return
+		}
+
+		identity, err := a.authenticate(r)
+		if err != nil {
+			log.Printf("Rejected request to %s: %v", r.URL.Path, err)
+			writeError(w, http.StatusUnauthorized, contracts.ErrCodeUnauthenticated, "invalid or missing access token")
+			return
+		}
+
+		if !slices.Contains(roles, identity.Role) {
+			writeError(w, http.StatusForbidden, contracts.ErrCodePermissionDenied, fmt.Sprintf("%s users can't access this resource", identity.Role))
+			return
+		}
+
+		next(w, r.WithContext(auth.NewContext(r.Context(), identity)))
+	}
+}
+
+func (a *authenticator) authenticate(r *http.Request) (auth.Identity, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	// browsers can't set headers on websockets, the token comes in the query string instead
+	if !ok && websocket.IsWebSocketUpgrade(r) {
+		token, ok = r.URL.Query().Get("token"), true
+	}
+	if !ok || token == "" {
+		return auth.Identity{}, errUnauthenticated
+	}
+
+	var claims tokenClaims
+	if _, err := a.parser.ParseWithClaims(token, &claims, a.key); err != nil {
+		return auth.Identity{}, err
+	}
+
+	if claims.Subject == "" {
+		return auth.Identity{}, fmt.Errorf("token has no subject")
+	}
+	if !claims.Role.IsValid() {
+		return auth.Identity{}, fmt.Errorf("token has an invalid role %q", claims.Role)
+	}
+
+	return auth.Identity{UserID: claims.Subject, Role: claims.Role}, nil
+}
+
+// key returns the key verifying the signature of the token
+func (a *authenticator) key(token *jwt.Token) (any, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if a.hmacSecret == nil {
+			return nil, fmt.Errorf("no HMAC secret configured")
+		}
+		return a.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := a.jwk(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	// a token can't pick how the key is used, an RSA key doesn't verify ES256 tokens
+	switch token.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := key.(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("key %q can't verify %s tokens", kid, token.Method.Alg())
+		}
+	case *jwt.SigningMethodECDSA:
+		if _, ok := key.(*ecdsa.PublicKey); !ok {
+			return nil, fmt.Errorf("key %q can't verify %s tokens", kid, token.Method.Alg())
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signing method %s", token.Method.Alg())
+	}
+
+	return key, nil
+}
+
+// jwk returns the JWKS key with the ID. Tokens signed with an unknown key reload the
+// JWKS file, at most once per jwksRefreshInterval, so rotated keys are picked up.
+func (a *authenticator) jwk(kid string) (crypto.PublicKey, bool) {
+	a.keysMu.Lock()
+	defer a.keysMu.Unlock()
+
+	if key, ok := a.lookupKey(kid); ok {
+		return key, true
+	}
+
+	if a.jwksFile == "" || a.now().Sub(a.refreshedAt) < jwksRefreshInterval {
+		return nil, false
+	}
+	a.refreshedAt = a.now()
+
+	keys, err := loadJWKS(a.jwksFile)
+	if err != nil {
+		// keep verifying with the keys already loaded
+		log.Printf("Failed to refresh the JWKS keys: %v", err)
+		return nil, false
+	}
+	a.keys = keys
+
+	return a.lookupKey(kid)
+}
+
+// lookupKey must be called with a.keysMu held
+func (a *authenticator) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if key, ok := a.keys[kid]; ok {
+		return key, true
+	}
+
+	// a single key doesn't need to be named
+	if kid == "" && len(a.keys) == 1 {
+		for _, key := range a.keys {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+type insecureKey struct{}
+
+// identify resolves who makes the request, from the access token or, when authentication
+// is disabled, from the userID and role the client claims. The routes open to both roles
+// take the claimed role from the role query parameter. The returned context carries
+// the identity to the services called with it.
+func identify(r *http.Request, claimedUserID string, claimedRole auth.Role) (context.Context, auth.Identity, error) {
+	ctx := r.Context()
+
+	if identity, ok := auth.FromContext(ctx); ok {
+		if (claimedUserID != "" && claimedUserID != identity.UserID) || (claimedRole != "" && claimedRole != identity.Role) {
+			return ctx, identity, errIdentityMismatch
+		}
+		return ctx, identity, nil
+	}
+
+	if insecure, _ := ctx.Value(insecureKey{}).(bool); !insecure {
+		return ctx, auth.Identity{}, errNoAuthMiddleware
+	}
+
+	if claimedUserID == "" {
+		return ctx, auth.Identity{}, errMissingUserID
+	}
+
+	// the services reject the calls made without a role
+	if claimedRole == "" {
+		claimedRole = auth.Role(r.URL.Query().Get("role"))
+	}
+	if !claimedRole.IsValid() {
+		return ctx, auth.Identity{}, errMissingRole
+	}
+
+	identity := auth.Identity{UserID: claimedUserID, Role: claimedRole}
+	return auth.NewContext(ctx, identity), identity, nil
+}
+
+// writeIdentityError answers a request identify failed on
+func writeIdentityError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errMissingUserID):
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidArgument, "invalid request", contracts.APIErrorDetail{
+			Field:       "userID",
+			Description: "userID is required",
+		})
+	case errors.Is(err, errMissingRole):
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidArgument, "invalid request", contracts.APIErrorDetail{
+			Field:       "role",
+			Description: err.Error(),
+		})
+	case errors.Is(err, errIdentityMismatch):
+		writeError(w, http.StatusForbidden, contracts.ErrCodePermissionDenied, err.Error())
+	default:
+		log.Printf("Failed to identify the request: %v", err)
+		writeError(w, http.StatusUnauthorized, contracts.ErrCodeUnauthenticated, errUnauthenticated.Error())
+	}
+}
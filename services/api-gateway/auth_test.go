@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ride-sharing/shared/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testHMACSecret = "test-secret"
+
+var (
+	testRSAKey = mustRSAKey()
+	testECKey  = mustECKey()
+)
+
+func mustRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func mustECKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// writeJWKS writes the public keys to the JWKS file at path, by key ID
+func writeJWKS(t *testing.T, path string, keys map[string]crypto.PublicKey) {
+	t.Helper()
+
+	encode := func(n *big.Int, size int) string {
+		return base64.RawURLEncoding.EncodeToString(n.FillBytes(make([]byte, size)))
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	for kid, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, jsonWebKey{
+				Kty: "RSA", Kid: kid, Use: "sig",
+				N: encode(key.N, key.Size()),
+				E: encode(big.NewInt(int64(key.E)), 3),
+			})
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, jsonWebKey{
+				Kty: "EC", Kid: kid, Use: "sig", Crv: "P-256",
+				X: encode(key.X, 32),
+				Y: encode(key.Y, 32),
+			})
+		}
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal JWKS: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write JWKS: %v", err)
+	}
+}
+
+func newTestAuthenticator(t *testing.T) *authenticator {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]crypto.PublicKey{"rsa-1": &testRSAKey.PublicKey, "ec-1": &testECKey.PublicKey})
+
+	a, err := NewAuthenticator(AuthConfig{
+		Mode:       authModeJWT,
+		HMACSecret: testHMACSecret,
+		JWKSFile:   path,
+		Issuer:     "ride-sharing",
+		Audience:   "api-gateway",
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	return a
+}
+
+func testClaims(userID string, role auth.Role) tokenClaims {
+	return tokenClaims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			Issuer:    "ride-sharing",
+			Audience:  jwt.ClaimStrings{"api-gateway"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.Claims, key any) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign %s token: %v", method.Alg(), err)
+	}
+
+	return signed
+}
+
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/trip/preview", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestAuthenticateTokens(t *testing.T) {
+	a := newTestAuthenticator(t)
+	rider := testClaims("rider-1", auth.RoleRider)
+
+	expired := testClaims("rider-1", auth.RoleRider)
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	withinLeeway := testClaims("rider-1", auth.RoleRider)
+	withinLeeway.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+
+	noExpiry := testClaims("rider-1", auth.RoleRider)
+	noExpiry.ExpiresAt = nil
+
+	otherIssuer := testClaims("rider-1", auth.RoleRider)
+	otherIssuer.Issuer = "someone-else"
+
+	otherAudience := testClaims("rider-1", auth.RoleRider)
+	otherAudience.Audience = jwt.ClaimStrings{"payment-service"}
+
+	noSubject := testClaims("", auth.RoleRider)
+
+	rsaPublicKey, err := json.Marshal(testRSAKey.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal RSA public key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  auth.Identity
+		ok    bool
+	}{
+		{"HS256", signToken(t, jwt.SigningMethodHS256, "", rider, []byte(testHMACSecret)), auth.Identity{UserID: "rider-1", Role: auth.RoleRider}, true},
+		{"RS256", signToken(t, jwt.SigningMethodRS256, "rsa-1", rider, testRSAKey), auth.Identity{UserID: "rider-1", Role: auth.RoleRider}, true},
+		{"PS256", signToken(t, jwt.SigningMethodPS256, "rsa-1", rider, testRSAKey), auth.Identity{UserID: "rider-1", Role: auth.RoleRider}, true},
+		{"ES256", signToken(t, jwt.SigningMethodES256, "ec-1", rider, testECKey), auth.Identity{UserID: "rider-1", Role: auth.RoleRider}, true},
+		{"driver role", signToken(t, jwt.SigningMethodHS256, "", testClaims("driver-1", auth.RoleDriver), []byte(testHMACSecret)), auth.Identity{UserID: "driver-1", Role: auth.RoleDriver}, true},
+		{"expired within the leeway", signToken(t, jwt.SigningMethodHS256, "", withinLeeway, []byte(testHMACSecret)), auth.Identity{UserID: "rider-1", Role: auth.RoleRider}, true},
+
+		{"no token", "", auth.Identity{}, false},
+		{"alg none", signToken(t, jwt.SigningMethodNone, "", rider, jwt.UnsafeAllowNoneSignatureType), auth.Identity{}, false},
+		// the RSA public key used as an HMAC secret
+		{"HS256 with the RSA public key", signToken(t, jwt.SigningMethodHS256, "rsa-1", rider, rsaPublicKey), auth.Identity{}, false},
+		{"RS256 naming the EC key", signToken(t, jwt.SigningMethodRS256, "ec-1", rider, testRSAKey), auth.Identity{}, false},
+		{"ES256 naming the RSA key", signToken(t, jwt.SigningMethodES256, "rsa-1", rider, testECKey), auth.Identity{}, false},
+		{"unknown key", signToken(t, jwt.SigningMethodRS256, "rsa-2", rider, testRSAKey), auth.Identity{}, false},
+		{"bad signature", signToken(t, jwt.SigningMethodHS256, "", rider, []byte("another-secret")), auth.Identity{}, false},
+		{"signed with another RSA key", signToken(t, jwt.SigningMethodRS256, "rsa-1", rider, mustRSAKey()), auth.Identity{}, false},
+		{"expired", signToken(t, jwt.SigningMethodHS256, "", expired, []byte(testHMACSecret)), auth.Identity{}, false},
+		{"no expiry", signToken(t, jwt.SigningMethodHS256, "", noExpiry, []byte(testHMACSecret)), auth.Identity{}, false},
+		{"other issuer", signToken(t, jwt.SigningMethodHS256, "", otherIssuer, []byte(testHMACSecret)), auth.Identity{}, false},
+		{"other audience", signToken(t, jwt.SigningMethodHS256, "", otherAudience, []byte(testHMACSecret)), auth.Identity{}, false},
+		{"no subject", signToken(t, jwt.SigningMethodHS256, "", noSubject, []byte(testHMACSecret)), auth.Identity{}, false},
+		{"no role", signToken(t, jwt.SigningMethodHS256, "", testClaims("rider-1", ""), []byte(testHMACSecret)), auth.Identity{}, false},
+		// the services' role is never handed out to users
+		{"service role", signToken(t, jwt.SigningMethodHS256, "", testClaims("trip-service", auth.RoleService), []byte(testHMACSecret)), auth.Identity{}, false},
+		{"unknown role", signToken(t, jwt.SigningMethodHS256, "", testClaims("rider-1", "admin"), []byte(testHMACSecret)), auth.Identity{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.authenticate(bearerRequest(tt.token))
+			if (err == nil) != tt.ok || got != tt.want {
+				t.Errorf("got %+v, %v; want %+v, ok %v", got, err, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestAuthenticateTamperedToken(t *testing.T) {
+	a := newTestAuthenticator(t)
+	token := signToken(t, jwt.SigningMethodHS256, "", testClaims("rider-1", auth.RoleRider), []byte(testHMACSecret))
+
+	// swap the payload for one claiming to be a driver, keeping the signature
+	payload, err := json.Marshal(testClaims("driver-1", auth.RoleDriver))
+	if err != nil {
+		t.Fatalf("marshal claims: %v", err)
+	}
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+
+	if _, err := a.authenticate(bearerRequest(tampered)); err == nil {
+		t.Errorf("tampered token accepted")
+	}
+}
+
+func TestAuthenticateWebsocketToken(t *testing.T) {
+	a := newTestAuthenticator(t)
+	token := signToken(t, jwt.SigningMethodHS256, "", testClaims("driver-1", auth.RoleDriver), []byte(testHMACSecret))
+
+	ws := httptest.NewRequest(http.MethodGet, "/drivers?token="+token, nil)
+	ws.Header.Set("Connection", "Upgrade")
+	ws.Header.Set("Upgrade", "websocket")
+
+	got, err := a.authenticate(ws)
+	if err != nil || got != (auth.Identity{UserID: "driver-1", Role: auth.RoleDriver}) {
+		t.Errorf("websocket: got %+v, %v; want driver-1", got, err)
+	}
+
+	// plain requests must send the token in the Authorization header, not in urls that get logged
+	plain := httptest.NewRequest(http.MethodGet, "/trip/preview?token="+token, nil)
+	if _, err := a.authenticate(plain); !errors.Is(err, errUnauthenticated) {
+		t.Errorf("plain request: got %v, want %v", err, errUnauthenticated)
+	}
+
+	// the header wins over the query parameter
+	ws.Header.Set("Authorization", "Bearer "+signToken(t, jwt.SigningMethodHS256, "", testClaims("rider-1", auth.RoleRider), []byte(testHMACSecret)))
+	if got, err := a.authenticate(ws); err != nil || got.UserID != "rider-1" {
+		t.Errorf("websocket with a header: got %+v, %v; want rider-1", got, err)
+	}
+}
+
+func TestJWKSRefreshesUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]crypto.PublicKey{"rsa-1": &testRSAKey.PublicKey})
+
+	a, err := NewAuthenticator(AuthConfig{Mode: authModeJWT, JWKSFile: path})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	rotatedKey := mustRSAKey()
+	token := signToken(t, jwt.SigningMethodRS256, "rsa-2", testClaims("rider-1", auth.RoleRider), rotatedKey)
+
+	if _, err := a.authenticate(bearerRequest(token)); err == nil {
+		t.Fatalf("token signed with a key not in the JWKS file accepted")
+	}
+
+	// the key is rotated in, but the file was just read
+	writeJWKS(t, path, map[string]crypto.PublicKey{"rsa-1": &testRSAKey.PublicKey, "rsa-2": &rotatedKey.PublicKey})
+	if _, err := a.authenticate(bearerRequest(token)); err == nil {
+		t.Errorf("the JWKS file was reloaded before the refresh interval")
+	}
+
+	now = now.Add(jwksRefreshInterval)
+	if _, err := a.authenticate(bearerRequest(token)); err != nil {
+		t.Errorf("token signed with the rotated key: %v", err)
+	}
+
+	// a broken file keeps the keys already loaded
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatalf("write JWKS: %v", err)
+	}
+	now = now.Add(jwksRefreshInterval)
+	unknown := signToken(t, jwt.SigningMethodRS256, "rsa-3", testClaims("rider-1", auth.RoleRider), testRSAKey)
+	if _, err := a.authenticate(bearerRequest(unknown)); err == nil {
+		t.Errorf("token signed with an unknown key accepted")
+	}
+	if _, err := a.authenticate(bearerRequest(token)); err != nil {
+		t.Errorf("the rotated key was dropped by a failed refresh: %v", err)
+	}
+}
+
+func TestRequireRoles(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	var got auth.Identity
+	handler := a.Require(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = auth.FromContext(r.Context())
+	}, auth.RoleDriver)
+
+	tests := []struct {
+		name     string
+		token    string
+		want     int
+		identity auth.Identity
+	}{
+		{"driver", signToken(t, jwt.SigningMethodHS256, "", testClaims("driver-1", auth.RoleDriver), []byte(testHMACSecret)), http.StatusOK, auth.Identity{UserID: "driver-1", Role: auth.RoleDriver}},
+		{"rider", signToken(t, jwt.SigningMethodHS256, "", testClaims("rider-1", auth.RoleRider), []byte(testHMACSecret)), http.StatusForbidden, auth.Identity{}},
+		{"no token", "", http.StatusUnauthorized, auth.Identity{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = auth.Identity{}
+			w := httptest.NewRecorder()
+			handler(w, bearerRequest(tt.token))
+
+			if w.Code != tt.want || got != tt.identity {
+				t.Errorf("got %d as %+v, want %d as %+v", w.Code, got, tt.want, tt.identity)
+			}
+		})
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	driver := auth.Identity{UserID: "driver-1", Role: auth.RoleDriver}
+	authenticated := func(url string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, url, nil)
+		return r.WithContext(auth.NewContext(r.Context(), driver))
+	}
+	insecure := func(url string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, url, nil)
+		return r.WithContext(context.WithValue(r.Context(), insecureKey{}, true))
+	}
+
+	tests := []struct {
+		name          string
+		r             *http.Request
+		claimedUserID string
+		claimedRole   auth.Role
+		want          auth.Identity
+		wantErr       error
+	}{
+		{"authenticated", authenticated("/trip/1"), "", "", driver, nil},
+		{"authenticated claiming themselves", authenticated("/trip/1"), "driver-1", auth.RoleDriver, driver, nil},
+		{"authenticated claiming another user", authenticated("/trip/1"), "driver-2", "", driver, errIdentityMismatch},
+		{"authenticated claiming another role", authenticated("/trip/1"), "driver-1", auth.RoleRider, driver, errIdentityMismatch},
+		// the role query parameter is only read in insecure mode
+		{"authenticated with a role parameter", authenticated("/trip/1?role=rider"), "", "", driver, nil},
+
+		{"not behind the middleware", httptest.NewRequest(http.MethodGet, "/trip/1", nil), "rider-1", auth.RoleRider, auth.Identity{}, errNoAuthMiddleware},
+
+		{"insecure", insecure("/trip/1"), "rider-1", auth.RoleRider, auth.Identity{UserID: "rider-1", Role: auth.RoleRider}, nil},
+		{"insecure with a role parameter", insecure("/trip/1?role=driver"), "driver-1", "", driver, nil},
+		{"insecure without user", insecure("/trip/1"), "", auth.RoleRider, auth.Identity{}, errMissingUserID},
+		{"insecure without role", insecure("/trip/1"), "rider-1", "", auth.Identity{}, errMissingRole},
+		{"insecure as a service", insecure("/trip/1?role=service"), "trip-service", "", auth.Identity{}, errMissingRole},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, got, err := identify(tt.r, tt.claimedUserID, tt.claimedRole)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Fatalf("got %+v, %v; want %+v, %v", got, err, tt.want, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			// the services are called with the identity
+			if carried, _ := auth.FromContext(ctx); carried != tt.want {
+				t.Errorf("the context carries %+v, want %+v", carried, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAuthenticatorInsecureOnlyInDevelopment(t *testing.T) {
+	tests := []struct {
+		environment string
+		ok          bool
+	}{
+		{"development", true},
+		{"production", false},
+		{"staging", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		a, err := NewAuthenticator(AuthConfig{Mode: authModeInsecure, Environment: tt.environment})
+		if (err == nil) != tt.ok {
+			t.Errorf("insecure mode in %q: got %v, want ok %v", tt.environment, err, tt.ok)
+		}
+		if err == nil && !a.insecure {
+			t.Errorf("insecure mode in %q: the authenticator checks tokens", tt.environment)
+		}
+	}
+
+	if _, err := NewAuthenticator(AuthConfig{Mode: authModeJWT, Environment: "production"}); err == nil {
+		t.Errorf("jwt mode without an HMAC secret or JWKS file: got nil error")
+	}
+	if _, err := NewAuthenticator(AuthConfig{Mode: "none", Environment: "development"}); err == nil {
+		t.Errorf("unknown mode: got nil error")
+	}
+}
@@ -2,6 +2,7 @@ package grpc_clients
 
 import (
 	"os"
+	"ride-sharing/shared/auth"
 	pb "ride-sharing/shared/proto/driver"
 
 	"google.golang.org/grpc"
@@ -19,7 +20,17 @@ func NewDriverServiceClient() (*driverServiceClient, error) {
 		driverServiceURL = "driver-service:9092"
 	}
 
-	conn, err := grpc.NewClient(driverServiceURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	serviceKey, err := auth.LoadServiceKey()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(driverServiceURL,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// forward the identity of the user to the service
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor(serviceKey)),
+		grpc.WithStreamInterceptor(auth.StreamClientInterceptor(serviceKey)),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package grpc_clients
 
 import (
 	"os"
+	"ride-sharing/shared/auth"
 	pb "ride-sharing/shared/proto/trip"
 
 	"google.golang.org/grpc"
@@ -20,7 +21,17 @@ func NewTripServiceClient() (*tripServiceClient, error) {
 		tripServiceURL = "trip-service:9093"
 	}
 
-	conn, err := grpc.NewClient(tripServiceURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	serviceKey, err := auth.LoadServiceKey()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(tripServiceURL,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// forward the identity of the user to the service
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor(serviceKey)),
+		grpc.WithStreamInterceptor(auth.StreamClientInterceptor(serviceKey)),
+	)
 	if err != nil {
 		return nil, err
 	}
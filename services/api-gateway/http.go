@@ -5,26 +5,35 @@ import (
 	"log"
 	"net/http"
 	"ride-sharing/services/api-gateway/grpc_clients"
+	"ride-sharing/shared/auth"
 	"ride-sharing/shared/contracts"
+	pb "ride-sharing/shared/proto/trip"
 )
 
 func handleTripStart(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
 	var reqBody startTripRequest
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-		http.Error(w, "failed to parse JSON data", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidArgument, "failed to parse JSON data")
 		return
 	}
 
 	defer r.Body.Close()
 
+	ctx, identity, err := identify(r, reqBody.UserID, auth.RoleRider)
+	if err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+	reqBody.UserID = identity.UserID
+
 	// Why we need to create a new client for each connection:
 	// because if a service is down, we don't want to block the whole application
 	// so we create a new client for each connection
 	tripService, err := grpc_clients.NewTripServiceClient()
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("Failed to create trip service client: %v", err)
+		writeError(w, http.StatusServiceUnavailable, contracts.ErrCodeUnavailable, "trip service is unavailable")
+		return
 	}
 
 	// Don't forget to close the client to avoid resource leaks!
@@ -33,7 +42,7 @@ func handleTripStart(w http.ResponseWriter, r *http.Request) {
 	trip, err := tripService.Client.CreateTrip(ctx, reqBody.toProto())
 	if err != nil {
 		log.Printf("Failed to start a trip: %v", err)
-		http.Error(w, "Failed to start trip", http.StatusInternalServerError)
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -47,33 +56,155 @@ func handleTripPreview(w http.ResponseWriter, r *http.Request) {
 	var requestBody previewTripRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		http.Error(w, "failed to parse JSON data", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidArgument, "failed to parse JSON data")
 		return
 	}
 	defer r.Body.Close()
 
-	// validation
-	if requestBody.UserID == "" {
-		http.Error(w, "userID is required", http.StatusBadRequest)
+	ctx, identity, err := identify(r, requestBody.UserID, auth.RoleRider)
+	if err != nil {
+		writeIdentityError(w, err)
 		return
 	}
+	requestBody.UserID = identity.UserID
 
 	// Use backoff here
 	tripService, err := grpc_clients.NewTripServiceClient()
 
 	if err != nil {
-		http.Error(w, "failed to create trip service client", http.StatusInternalServerError)
+		log.Printf("Failed to create trip service client: %v", err)
+		writeError(w, http.StatusServiceUnavailable, contracts.ErrCodeUnavailable, "trip service is unavailable")
 		return
 	}
 	defer tripService.Close()
 
-	tripPreview, err := tripService.Client.PreviewTrip(r.Context(), requestBody.toProto())
+	tripPreview, err := tripService.Client.PreviewTrip(ctx, requestBody.toProto())
 
 	if err != nil {
 		log.Printf("Failed to preview a trip: %v", err)
-		http.Error(w, "Failed to preview trip", http.StatusInternalServerError)
+		writeGRPCError(w, err)
+		return
 	}
 
 	response := contracts.APIResponse{Data: tripPreview}
 	writeJSON(w, http.StatusCreated, response)
 }
+
+func handleTripCancel(w http.ResponseWriter, r *http.Request) {
+	tripID := r.PathValue("id")
+
+	var reqBody cancelTripRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidArgument, "failed to parse JSON data")
+		return
+	}
+	defer r.Body.Close()
+
+	// riders and drivers can both cancel
+	ctx, identity, err := identify(r, reqBody.UserID, "")
+	if err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+	reqBody.UserID = identity.UserID
+
+	tripService, err := grpc_clients.NewTripServiceClient()
+	if err != nil {
+		log.Printf("Failed to create trip service client: %v", err)
+		writeError(w, http.StatusServiceUnavailable, contracts.ErrCodeUnavailable, "trip service is unavailable")
+		return
+	}
+	defer tripService.Close()
+
+	cancelled, err := tripService.Client.CancelTrip(ctx, reqBody.toProto(tripID))
+	if err != nil {
+		log.Printf("Failed to cancel trip %s: %v", tripID, err)
+		writeGRPCError(w, err)
+		return
+	}
+
+	response := contracts.APIResponse{Data: cancelled}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handleGetTrip(w http.ResponseWriter, r *http.Request) {
+	tripID := r.PathValue("id")
+
+	// only the rider and the driver of the trip can see it
+	ctx, identity, err := identify(r, r.URL.Query().Get("userID"), "")
+	if err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+
+	tripService, err := grpc_clients.NewTripServiceClient()
+	if err != nil {
+		log.Printf("Failed to create trip service client: %v", err)
+		writeError(w, http.StatusServiceUnavailable, contracts.ErrCodeUnavailable, "trip service is unavailable")
+		return
+	}
+	defer tripService.Close()
+
+	trip, err := tripService.Client.GetTrip(ctx, &pb.GetTripRequest{
+		TripID: tripID,
+		UserID: identity.UserID,
+	})
+	if err != nil {
+		log.Printf("Failed to get trip %s: %v", tripID, err)
+		writeGRPCError(w, err)
+		return
+	}
+
+	response := contracts.APIResponse{Data: trip}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handleListTrips(w http.ResponseWriter, r *http.Request) {
+	reqParams, details := parseListTripsRequest(r.URL.Query())
+	if len(details) > 0 {
+		writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidArgument, "invalid request", details...)
+		return
+	}
+
+	ctx, identity, err := identify(r, reqParams.UserID, reqParams.Role)
+	if err != nil {
+		writeIdentityError(w, err)
+		return
+	}
+	reqParams.UserID = identity.UserID
+	reqParams.Role = identity.Role
+
+	tripService, err := grpc_clients.NewTripServiceClient()
+	if err != nil {
+		log.Printf("Failed to create trip service client: %v", err)
+		writeError(w, http.StatusServiceUnavailable, contracts.ErrCodeUnavailable, "trip service is unavailable")
+		return
+	}
+	defer tripService.Close()
+
+	// users only ever list their own trips, as a rider or as a driver
+	var trips *pb.ListTripsResponse
+	if reqParams.Role == auth.RoleDriver {
+		trips, err = tripService.Client.ListTripsByDriver(ctx, &pb.ListTripsByDriverRequest{
+			DriverID:  reqParams.UserID,
+			Filter:    reqParams.filter(),
+			PageSize:  reqParams.PageSize,
+			PageToken: reqParams.PageToken,
+		})
+	} else {
+		trips, err = tripService.Client.ListTripsByUser(ctx, &pb.ListTripsByUserRequest{
+			UserID:    reqParams.UserID,
+			Filter:    reqParams.filter(),
+			PageSize:  reqParams.PageSize,
+			PageToken: reqParams.PageToken,
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to list trips of %s %s: %v", reqParams.Role, reqParams.UserID, err)
+		writeGRPCError(w, err)
+		return
+	}
+
+	response := contracts.APIResponse{Data: trips}
+	writeJSON(w, http.StatusOK, response)
+}
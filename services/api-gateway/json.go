@@ -2,7 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/grpcerr"
+
+	"google.golang.org/grpc/codes"
 )
 
 func writeJSON(w http.ResponseWriter, status int, data any) error {
@@ -10,3 +15,64 @@ func writeJSON(w http.ResponseWriter, status int, data any) error {
 	w.WriteHeader(status)
 	return json.NewEncoder(w).Encode(data)
 }
+
+func writeError(w http.ResponseWriter, status int, code, message string, details ...contracts.APIErrorDetail) {
+	writeJSON(w, status, contracts.APIResponse{
+		Error: &contracts.APIError{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	})
+}
+
+// writeGRPCError translates an error returned by a service into an API error
+func writeGRPCError(w http.ResponseWriter, err error) {
+	parsed := grpcerr.Parse(err)
+
+	status, code := httpStatus(parsed.Code)
+	if parsed.Reason != "" {
+		code = parsed.Reason
+	}
+
+	message := parsed.Message
+	if status == http.StatusInternalServerError {
+		// don't leak the internals of the services to the clients
+		log.Printf("Service error: %v", err)
+		message = "internal server error"
+	}
+
+	var details []contracts.APIErrorDetail
+	for _, v := range parsed.Violations {
+		details = append(details, contracts.APIErrorDetail{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+
+	writeError(w, status, code, message, details...)
+}
+
+// httpStatus maps a gRPC code to its HTTP status and the API error code used when the service gave none
+func httpStatus(code codes.Code) (int, string) {
+	switch code {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest, contracts.ErrCodeInvalidArgument
+	case codes.NotFound:
+		return http.StatusNotFound, contracts.ErrCodeNotFound
+	case codes.PermissionDenied:
+		return http.StatusForbidden, contracts.ErrCodePermissionDenied
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized, contracts.ErrCodeUnauthenticated
+	case codes.FailedPrecondition, codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict, contracts.ErrCodeConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests, contracts.ErrCodeUnavailable
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable, contracts.ErrCodeUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout, contracts.ErrCodeUnavailable
+	}
+
+	return http.StatusInternalServerError, contracts.ErrCodeInternal
+}
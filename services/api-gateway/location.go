@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"ride-sharing/shared/types"
+	"ride-sharing/shared/util"
+	"time"
+)
+
+const (
+	// Drivers can't report their location more often than this
+	minLocationUpdateInterval = time.Second
+	// Anything faster than this is a GPS glitch (or a spoofed location), not a car
+	maxDriverSpeedKmh = 250
+)
+
+var (
+	ErrLocationOutOfRange     = errors.New("location is out of range")
+	ErrLocationTooFrequent    = errors.New("location updates are sent too frequently")
+	ErrLocationImpossibleJump = errors.New("location moved further than a car possibly could")
+)
+
+type driverLocationMessage struct {
+	Location types.Coordinate `json:"location"`
+	Geohash  string           `json:"geohash"`
+}
+
+// locationTracker validates the location updates of a single driver connection.
+// It is not safe for concurrent use, every connection reads its messages from a single goroutine.
+type locationTracker struct {
+	last   *types.Coordinate
+	lastAt time.Time
+}
+
+// Accept checks the location against the previous accepted one and remembers it when valid
+func (t *locationTracker) Accept(location types.Coordinate, at time.Time) error {
+	if location.Latitude < -90 || location.Latitude > 90 || location.Longitude < -180 || location.Longitude > 180 {
+		return ErrLocationOutOfRange
+	}
+
+	if t.last != nil {
+		elapsed := at.Sub(t.lastAt)
+		if elapsed < minLocationUpdateInterval {
+			return ErrLocationTooFrequent
+		}
+
+		distanceKm := util.HaversineDistanceKm(t.last.Latitude, t.last.Longitude, location.Latitude, location.Longitude)
+		if distanceKm/elapsed.Hours() > maxDriverSpeedKmh {
+			return ErrLocationImpossibleJump
+		}
+	}
+
+	t.last = &location
+	t.lastAt = at
+
+	return nil
+}
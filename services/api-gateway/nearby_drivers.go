@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"ride-sharing/shared/contracts"
+	pb "ride-sharing/shared/proto/driver"
+	"ride-sharing/shared/types"
+
+	"github.com/mmcloughlin/geohash"
+)
+
+// Riders see the drivers in their geohash cell of this precision (about 5km wide) and the cells around it
+const nearbyDriversPrecision = 5
+
+type riderLocationMessage struct {
+	Location types.Coordinate `json:"location"`
+}
+
+// watchNearbyDrivers streams the drivers around the location from the driver service and sends
+// the rider the up to date list of drivers on every change, until ctx is cancelled
+func watchNearbyDrivers(ctx context.Context, client pb.DriverServiceClient, riderID string, location types.Coordinate) error {
+	cell := geohash.EncodeWithPrecision(location.Latitude, location.Longitude, nearbyDriversPrecision)
+	cells := append([]string{cell}, geohash.Neighbors(cell)...)
+
+	stream, err := client.StreamDriverLocations(ctx, &pb.StreamDriverLocationsRequest{
+		Geohashes: cells,
+	})
+	if err != nil {
+		return err
+	}
+
+	drivers := make(map[string]*pb.Driver)
+	for {
+		update, err := stream.Recv()
+		if errors.Is(err, io.EOF) || ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if update.GetRemoved() {
+			delete(drivers, update.GetDriver().GetId())
+		} else {
+			drivers[update.GetDriver().GetId()] = update.GetDriver()
+		}
+
+		nearby := make([]*pb.Driver, 0, len(drivers))
+		for _, driver := range drivers {
+			nearby = append(nearby, driver)
+		}
+
+		if err := connManager.SendMessage(riderID, contracts.WSMessage{
+			Type: contracts.DriverCmdLocation,
+			Data: nearby,
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+// nearbyDriversWatcher keeps a single watch per rider connection, restarting it when the rider moves.
+// It is not safe for concurrent use, every connection reads its messages from a single goroutine.
+type nearbyDriversWatcher struct {
+	riderID string
+	client  pb.DriverServiceClient
+	cancel  context.CancelFunc
+}
+
+func (w *nearbyDriversWatcher) Watch(ctx context.Context, location types.Coordinate) {
+	w.Stop()
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	go func() {
+		if err := watchNearbyDrivers(ctx, w.client, w.riderID, location); err != nil {
+			log.Printf("Stopped watching drivers near rider %s: %v", w.riderID, err)
+		}
+	}()
+}
+
+func (w *nearbyDriversWatcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+		w.cancel = nil
+	}
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"fmt"
+	"net/url"
+	"ride-sharing/shared/auth"
+	"ride-sharing/shared/contracts"
 	pb "ride-sharing/shared/proto/trip"
 	"ride-sharing/shared/types"
+	"strconv"
+	"strings"
 )
 
 type previewTripRequest struct {
@@ -37,3 +43,85 @@ func (c *startTripRequest) toProto() *pb.CreateTripRequest {
 		UserID:     c.UserID,
 	}
 }
+
+type cancelTripRequest struct {
+	UserID string `json:"userID"`
+	Reason string `json:"reason"`
+}
+
+func (c *cancelTripRequest) toProto(tripID string) *pb.CancelTripRequest {
+	return &pb.CancelTripRequest{
+		TripID: tripID,
+		UserID: c.UserID,
+		Reason: c.Reason,
+	}
+}
+
+type listTripsRequest struct {
+	UserID        string
+	Role          auth.Role // rider or driver, the trips the user took or drove
+	Statuses      []string
+	CreatedAfter  string
+	CreatedBefore string
+	PageSize      int32
+	PageToken     string
+}
+
+// parseListTripsRequest reads the listing from the query string, returning the invalid parameters
+func parseListTripsRequest(query url.Values) (*listTripsRequest, []contracts.APIErrorDetail) {
+	req := &listTripsRequest{
+		UserID:        query.Get("userID"),
+		Role:          auth.Role(query.Get("role")),
+		CreatedAfter:  query.Get("createdAfter"),
+		CreatedBefore: query.Get("createdBefore"),
+		PageToken:     query.Get("pageToken"),
+	}
+
+	var details []contracts.APIErrorDetail
+
+	if req.Role != "" && !req.Role.IsValid() {
+		details = append(details, contracts.APIErrorDetail{Field: "role", Description: "role must be rider or driver"})
+	}
+
+	// statuses can be repeated or comma separated
+	for _, statuses := range query["status"] {
+		for _, status := range strings.Split(statuses, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				req.Statuses = append(req.Statuses, status)
+			}
+		}
+	}
+
+	if pageSize := query.Get("pageSize"); pageSize != "" {
+		size, err := strconv.ParseInt(pageSize, 10, 32)
+		if err != nil || size < 0 {
+			details = append(details, contracts.APIErrorDetail{Field: "pageSize", Description: "pageSize must be a positive number"})
+		}
+		req.PageSize = int32(size)
+	}
+
+	return req, details
+}
+
+func (l *listTripsRequest) filter() *pb.TripFilter {
+	return &pb.TripFilter{
+		Statuses:      l.Statuses,
+		CreatedAfter:  l.CreatedAfter,
+		CreatedBefore: l.CreatedBefore,
+	}
+}
+
+// parseLastEventID reads the ID of the last websocket message the client saw before reconnecting, if any
+func parseLastEventID(query url.Values) (*uint64, error) {
+	value := query.Get("lastEventID")
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("lastEventID must be a positive number")
+	}
+
+	return &id, nil
+}
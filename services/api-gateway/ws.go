@@ -1,68 +1,193 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"ride-sharing/services/api-gateway/grpc_clients"
+	"ride-sharing/shared/auth"
 	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/grpcerr"
 	"ride-sharing/shared/messaging"
 	"ride-sharing/shared/proto/driver"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 var (
-	connManager = messaging.NewConnectionManager()
+	connManager = messaging.NewConnectionManager(messaging.DefaultHeartbeatConfig(), messaging.DefaultConnectionPolicy())
+
+	unregisterTimeout = 5 * time.Second
 )
 
-func handlerDriversWebSocket(rb *messaging.RabbitMQ) http.HandlerFunc {
+func writeAlreadyConnected(w http.ResponseWriter) {
+	writeError(w, http.StatusConflict, contracts.ErrCodeConflict, "already connected from another device")
+}
+
+// alreadyConnected tells whether the connection of the user is rejected, the policy refusing
+// a new connection while they are connected to any gateway instance
+func alreadyConnected(ctx context.Context, router *messaging.WSRouter, userID string) bool {
+	if connManager.Policy() != messaging.RejectDuplicateConnection {
+		return false
+	}
+	if connManager.Connected(userID) {
+		return true
+	}
+
+	present, err := router.Present(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to tell whether user %s is connected to another gateway instance: %v", userID, err)
+		return false
+	}
+
+	return present
+}
+
+// closeRejected tells the client why its connection is refused before closing it
+func closeRejected(conn *websocket.Conn, userID string, err error) {
+	log.Printf("Rejected connection of user %s: %v", userID, err)
+
+	message := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error())
+	if err := conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second)); err != nil {
+		log.Printf("Failed to close the connection of user %s: %v", userID, err)
+	}
+}
+
+// isTimeout tells whether the read failed because the client stopped answering the pings
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// connectedDriver returns the driver already registered by another of their connections,
+// the first update of the stream of their locations being the driver as it is
+func connectedDriver(ctx context.Context, client driver.DriverServiceClient, driverID string) (*driver.Driver, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.StreamDriverLocations(ctx, &driver.StreamDriverLocationsRequest{
+		DriverID: driverID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	update, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	return update.GetDriver(), nil
+}
+
+func handlerDriversWebSocket(rb *messaging.RabbitMQ, router *messaging.WSRouter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		conn, err := connManager.Upgrade(w, r)
+		// the identity is checked before upgrading, to answer with an HTTP error
+		ctx, identity, err := identify(r, r.URL.Query().Get("userID"), auth.RoleDriver)
 		if err != nil {
-			log.Printf("WebSocket upgrade error: %v", err)
+			writeIdentityError(w, err)
 			return
 		}
-		defer conn.Close()
+		userID := identity.UserID
 
-		userID := r.URL.Query().Get("userID")
-		if userID == "" {
-			log.Printf("UserID is required")
+		packageSlug := r.URL.Query().Get("packageSlug")
+		if packageSlug == "" {
+			writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidArgument, "invalid request", contracts.APIErrorDetail{
+				Field:       "packageSlug",
+				Description: "packageSlug is required",
+			})
 			return
 		}
 
-		packageSlug := r.URL.Query().Get("packageSlug")
-		if userID == "" {
-			log.Printf("No packageSlug provided")
+		lastEventID, err := parseLastEventID(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidArgument, "invalid request", contracts.APIErrorDetail{
+				Field:       "lastEventID",
+				Description: err.Error(),
+			})
+			return
+		}
+
+		if alreadyConnected(ctx, router, userID) {
+			writeAlreadyConnected(w)
 			return
 		}
 
+		conn, err := connManager.Upgrade(w, r)
+		if err != nil {
+			log.Printf("WebSocket upgrade error: %v", err)
+			return
+		}
+		defer conn.Close()
+
 		// Add connection to manager
-		connManager.Add(userID, conn)
+		connID, first, err := connManager.Add(userID, conn)
+		if err != nil {
+			closeRejected(conn, userID, err)
+			return
+		}
 
-		ctx := r.Context()
+		// the driver may be connected to another gateway instance too
+		connectedElsewhere, err := router.Join(ctx, userID)
+		if err != nil {
+			log.Printf("Failed to record driver %s is connected: %v", userID, err)
+			connManager.Remove(userID, connID)
+			return
+		}
+		first = first && !connectedElsewhere
 
 		driverService, err := grpc_clients.NewDriverServiceClient()
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		// Closing connections
+		// Closing connections, the driver is unregistered however the last of their
+		// connections ended, also when their client stopped answering the pings
 		defer func() {
 
-			connManager.Remove(userID)
+			// the driver stays registered while another of their connections is open,
+			// to this gateway instance or another one
+			last := connManager.Remove(userID, connID)
+			connected := router.Leave(context.WithoutCancel(ctx), userID)
+			if !last || connected {
+				driverService.Close()
+				return
+			}
+
+			// the request may be gone with the connection, the identity of the driver is still needed
+			unregisterCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), unregisterTimeout)
+			defer cancel()
 
-			driverService.Client.UnRegisterDriver(ctx, &driver.RegisterDriverRequest{
+			if _, err := driverService.Client.UnRegisterDriver(unregisterCtx, &driver.RegisterDriverRequest{
 				DriverID:    userID,
 				PackageSlug: packageSlug,
-			})
+			}); err != nil {
+				log.Printf("Failed to unregister driver %s: %v", userID, err)
+			}
 			driverService.Close()
 			log.Println("Driver unregistered: ", userID)
 		}()
 
-		driverData, err := driverService.Client.RegisterDriver(ctx, &driver.RegisterDriverRequest{
-			DriverID:    userID,
-			PackageSlug: packageSlug,
-		})
-
+		// the driver is only registered by their first connection, the next ones
+		// get the driver as it is, on its way to a pickup or on a trip
+		var driverData *driver.Driver
+		if !first {
+			driverData, err = connectedDriver(ctx, driverService.Client, userID)
+			// the other connection didn't get to register the driver
+			first = grpcerr.Parse(err).Reason == contracts.ErrCodeDriverNotFound
+		}
+		if first {
+			var res *driver.RegisterDriverResponse
+			res, err = driverService.Client.RegisterDriver(ctx, &driver.RegisterDriverRequest{
+				DriverID:    userID,
+				PackageSlug: packageSlug,
+			})
+			driverData = res.GetDriver()
+		}
 		if err != nil {
 			log.Printf("Error registering driver: %v", err)
 			return
@@ -70,30 +195,29 @@ func handlerDriversWebSocket(rb *messaging.RabbitMQ) http.HandlerFunc {
 
 		if err := connManager.SendMessage(userID, contracts.WSMessage{
 			Type: contracts.DriverCmdRegister,
-			Data: driverData.Driver,
+			Data: driverData,
 		}); err != nil {
 			log.Printf("Error sending message: %v", err)
 			return
 		}
 
-		// Initialize the queue consumers
-		queues := []string{
-			messaging.DriverCmdTripRequestQueue,
+		// the messages of the driver are routed to this instance until they disconnect,
+		// starting with the ones they missed
+		if err := router.Connect(ctx, userID, lastEventID); err != nil {
+			log.Printf("Failed to route messages to driver %s: %v", userID, err)
+			return
 		}
+		defer router.Unbind(userID)
 
-		// Send message from RabbitMQ to websocket (to frontend)
-		for _, queue := range queues {
-			consumer := messaging.NewQueueConsumer(rb, connManager, queue)
-
-			if err := consumer.Start(); err != nil {
-				log.Printf("Failed to start consumer for queue %s: %v", queue, err)
-				return
-			}
-		}
+		var locations locationTracker
 
 		// Read Message from frontend
 		for {
 			_, message, err := conn.ReadMessage()
+			if isTimeout(err) {
+				log.Printf("Driver %s stopped answering the pings", userID)
+				break
+			}
 			if err != nil {
 				log.Printf("Error reading message: %v", err)
 				break
@@ -113,9 +237,29 @@ func handlerDriversWebSocket(rb *messaging.RabbitMQ) http.HandlerFunc {
 			// handle the different types of messages
 			switch driverMsg.Type {
 			case contracts.DriverCmdLocation:
-				// Handle driver location update in the future
-				continue
-			case contracts.DriverCmdTripAccept, contracts.DriverCmdTripDecline:
+				var locationMsg driverLocationMessage
+				if err := json.Unmarshal(driverMsg.Data, &locationMsg); err != nil {
+					log.Printf("Failed to unmarshal driver location: %v", err)
+					continue
+				}
+
+				if err := locations.Accept(locationMsg.Location, time.Now()); err != nil {
+					log.Printf("Rejected location of driver %s: %v", userID, err)
+					continue
+				}
+
+				if _, err := driverService.Client.UpdateDriverLocation(ctx, &driver.UpdateDriverLocationRequest{
+					DriverID: userID,
+					Location: &driver.Location{
+						Latitude:  locationMsg.Location.Latitude,
+						Longitude: locationMsg.Location.Longitude,
+					},
+				}); err != nil {
+					log.Printf("Failed to update driver location: %v", err)
+					continue
+				}
+			case contracts.DriverCmdTripAccept, contracts.DriverCmdTripDecline,
+				contracts.DriverCmdTripStart, contracts.DriverCmdTripComplete:
 				// Forward the message to the rabbitmq
 				if err := rb.PublishMessage(ctx, driverMsg.Type, contracts.AmqpMessage{
 					OwnerID: userID,
@@ -131,8 +275,29 @@ func handlerDriversWebSocket(rb *messaging.RabbitMQ) http.HandlerFunc {
 	}
 }
 
-func handlerRidersWebSocket(rb *messaging.RabbitMQ) http.HandlerFunc {
+func handlerRidersWebSocket(router *messaging.WSRouter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, identity, err := identify(r, r.URL.Query().Get("userID"), auth.RoleRider)
+		if err != nil {
+			writeIdentityError(w, err)
+			return
+		}
+		userID := identity.UserID
+
+		lastEventID, err := parseLastEventID(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, contracts.ErrCodeInvalidArgument, "invalid request", contracts.APIErrorDetail{
+				Field:       "lastEventID",
+				Description: err.Error(),
+			})
+			return
+		}
+
+		if alreadyConnected(ctx, router, userID) {
+			writeAlreadyConnected(w)
+			return
+		}
+
 		conn, err := connManager.Upgrade(w, r)
 		if err != nil {
 			log.Printf("WebSocket upgrade error: %v", err)
@@ -140,40 +305,75 @@ func handlerRidersWebSocket(rb *messaging.RabbitMQ) http.HandlerFunc {
 		}
 		defer conn.Close()
 
-		userID := r.URL.Query().Get("userID")
-		if userID == "" {
-			log.Printf("UserID is required")
+		// Add connection to manager
+		connID, _, err := connManager.Add(userID, conn)
+		if err != nil {
+			closeRejected(conn, userID, err)
 			return
 		}
+		defer connManager.Remove(userID, connID)
 
-		// Add connection to manager
-		connManager.Add(userID, conn)
-		defer connManager.Remove(userID)
-
-		// Initialize the queue consumers
-		queues := []string{
-			messaging.NotifyDriverNoDriversFoundQueue,
-			messaging.NotifyDriverAssignQueue,
+		// closes the connections of the rider to the other gateway instances, under kick_old
+		if _, err := router.Join(ctx, userID); err != nil {
+			log.Printf("Failed to record rider %s is connected: %v", userID, err)
+			return
 		}
+		defer router.Leave(context.WithoutCancel(ctx), userID)
 
-		// Send message from RabbitMQ to websocket (to frontend)
-		for _, queue := range queues {
-			consumer := messaging.NewQueueConsumer(rb, connManager, queue)
+		// the messages of the rider are routed to this instance until they disconnect,
+		// starting with the ones they missed
+		if err := router.Connect(ctx, userID, lastEventID); err != nil {
+			log.Printf("Failed to route messages to rider %s: %v", userID, err)
+			return
+		}
+		defer router.Unbind(userID)
 
-			if err := consumer.Start(); err != nil {
-				log.Printf("Failed to start consumer for queue %s: %v", queue, err)
-				return
-			}
+		driverService, err := grpc_clients.NewDriverServiceClient()
+		if err != nil {
+			log.Printf("Failed to create driver service client: %v", err)
+			return
 		}
+		defer driverService.Close()
+
+		nearbyDrivers := &nearbyDriversWatcher{riderID: userID, client: driverService.Client}
+		defer nearbyDrivers.Stop()
 
 		// Read message from frontend
 		for {
 			_, message, err := conn.ReadMessage()
+			if isTimeout(err) {
+				log.Printf("Rider %s stopped answering the pings", userID)
+				break
+			}
 			if err != nil {
 				log.Printf("WebSocket read error: %v", err)
 				break
 			}
-			log.Printf("Received message: %s", message)
+
+			type RiderMessage struct {
+				Type string          `json:"type"`
+				Data json.RawMessage `json:"data"`
+			}
+
+			var riderMsg RiderMessage
+			if err := json.Unmarshal(message, &riderMsg); err != nil {
+				log.Printf("Failed to unmarshal rider message: %v", err)
+				continue
+			}
+
+			switch riderMsg.Type {
+			case contracts.DriverCmdLocation:
+				// the rider tells where they are to see the drivers around
+				var locationMsg riderLocationMessage
+				if err := json.Unmarshal(riderMsg.Data, &locationMsg); err != nil {
+					log.Printf("Failed to unmarshal rider location: %v", err)
+					continue
+				}
+
+				nearbyDrivers.Watch(ctx, locationMsg.Location)
+			default:
+				log.Printf("Unknown rider message type: %s", riderMsg.Type)
+			}
 		}
 	}
 }
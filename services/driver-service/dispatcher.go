@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/env"
+	"ride-sharing/shared/messaging"
+	pbd "ride-sharing/shared/proto/driver"
+	pb "ride-sharing/shared/proto/trip"
+	"sync"
+	"time"
+)
+
+type DispatchConfig struct {
+	OfferTimeout time.Duration // how long a driver has to answer a trip request
+	MaxAttempts  int           // how many drivers are asked before giving up
+	Deadline     time.Duration // how long we keep looking for a driver overall
+}
+
+// DefaultDispatchConfig returns the dispatch settings, overridable through the environment
+func DefaultDispatchConfig() DispatchConfig {
+	return DispatchConfig{
+		OfferTimeout: time.Duration(env.GetInt("DISPATCH_OFFER_TIMEOUT_SECONDS", 15)) * time.Second,
+		MaxAttempts:  env.GetInt("DISPATCH_MAX_ATTEMPTS", 5),
+		Deadline:     time.Duration(env.GetInt("DISPATCH_DEADLINE_SECONDS", 120)) * time.Second,
+	}
+}
+
+var ErrTripNotOffered = errors.New("trip was not offered to the driver")
+
+// messagePublisher publishes the messages of the dispatch, the RabbitMQ connection in production
+type messagePublisher interface {
+	PublishMessage(ctx context.Context, routingKey string, msg contracts.AmqpMessage) error
+}
+
+// tripDispatch is the state of the search for a driver of a single trip
+type tripDispatch struct {
+	trip            *pb.Trip
+	askedDrivers    map[string]struct{}
+	offeredDriverID string
+	attempts        int
+	deadline        time.Time
+	timer           *time.Timer
+}
+
+// Dispatcher offers a trip to one driver at a time. When the driver declines or
+// doesn't answer in time, the next closest driver that wasn't asked yet gets the offer,
+// until one accepts or we run out of attempts, time or drivers.
+type Dispatcher struct {
+	publisher  messagePublisher
+	service    *Service
+	cfg        DispatchConfig
+	dispatches map[string]*tripDispatch // tripID -> dispatch
+	onOffer    func(driverID string, trip *pb.Trip)
+	mu         sync.Mutex
+}
+
+func NewDispatcher(publisher messagePublisher, service *Service, cfg DispatchConfig) *Dispatcher {
+	return &Dispatcher{
+		publisher:  publisher,
+		service:    service,
+		cfg:        cfg,
+		dispatches: make(map[string]*tripDispatch),
+	}
+}
+
+// Start begins looking for a driver for a newly created trip
+func (d *Dispatcher) Start(ctx context.Context, trip *pb.Trip) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.dispatches[trip.Id]; exists {
+		log.Printf("[Dispatcher] trip %s is already being dispatched", trip.Id)
+		return nil
+	}
+
+	dispatch := d.newDispatch(trip)
+	d.dispatches[trip.Id] = dispatch
+
+	return d.offerOrForget(ctx, dispatch)
+}
+
+// DriverNotInterested moves the trip on to the next driver after driverID declined or timed out
+func (d *Dispatcher) DriverNotInterested(ctx context.Context, trip *pb.Trip, driverID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dispatch, exists := d.dispatches[trip.Id]
+	if !exists {
+		// we lost track of the trip (e.g. after a restart), resume the search without that driver
+		dispatch = d.newDispatch(trip)
+		dispatch.askedDrivers[driverID] = struct{}{}
+		d.dispatches[trip.Id] = dispatch
+	} else if dispatch.offeredDriverID != driverID {
+		log.Printf("[Dispatcher] ignoring stale answer of driver %s for trip %s", driverID, trip.Id)
+		return nil
+	}
+	d.service.ReleaseOffer(driverID, trip.Id)
+
+	if dispatch.timer != nil {
+		dispatch.timer.Stop()
+	}
+	dispatch.offeredDriverID = ""
+
+	return d.offerOrForget(ctx, dispatch)
+}
+
+// offerOrForget offers the trip to the next driver, forgetting the dispatch if it can't:
+// without a pending offer nothing would move it on, and the trip could never be dispatched again.
+// Must be called with d.mu held.
+func (d *Dispatcher) offerOrForget(ctx context.Context, dispatch *tripDispatch) error {
+	if err := d.offerNext(ctx, dispatch); err != nil {
+		delete(d.dispatches, dispatch.trip.Id)
+		return err
+	}
+
+	return nil
+}
+
+// Stop ends the dispatch of the trip and returns the driver with a pending offer, if any.
+// That driver becomes available again unless they were assigned the trip in the meantime.
+func (d *Dispatcher) Stop(tripID string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dispatch, exists := d.dispatches[tripID]
+	if !exists {
+		return "", false
+	}
+
+	if dispatch.timer != nil {
+		dispatch.timer.Stop()
+	}
+	delete(d.dispatches, tripID)
+	if dispatch.offeredDriverID != "" {
+		d.service.ReleaseOffer(dispatch.offeredDriverID, tripID)
+	}
+
+	return dispatch.offeredDriverID, dispatch.offeredDriverID != ""
+}
+
+// Offered tells whether the trip is still looking for a driver and is currently offered to driverID.
+// The drivers who declined or didn't answer in time lost their offer to the next driver.
+func (d *Dispatcher) Offered(tripID, driverID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dispatch, exists := d.dispatches[tripID]
+	if !exists {
+		return false
+	}
+
+	return driverID != "" && dispatch.offeredDriverID == driverID
+}
+
+// OnOffer registers a function called every time a trip is offered to a driver.
+// It is called with the dispatcher locked, so it must not call back into the dispatcher.
+func (d *Dispatcher) OnOffer(fn func(driverID string, trip *pb.Trip)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.onOffer = fn
+}
+
+func (d *Dispatcher) newDispatch(trip *pb.Trip) *tripDispatch {
+	return &tripDispatch{
+		trip:         trip,
+		askedDrivers: make(map[string]struct{}),
+		deadline:     time.Now().Add(d.cfg.Deadline),
+	}
+}
+
+// offerNext sends the trip to the next driver. Must be called with d.mu held.
+func (d *Dispatcher) offerNext(ctx context.Context, dispatch *tripDispatch) error {
+	tripID := dispatch.trip.Id
+
+	if dispatch.attempts >= d.cfg.MaxAttempts || time.Now().After(dispatch.deadline) {
+		log.Printf("[Dispatcher] giving up on trip %s after %d attempts", tripID, dispatch.attempts)
+		return d.giveUp(ctx, dispatch)
+	}
+
+	driverID, found := d.nextDriver(dispatch)
+	if !found {
+		log.Printf("[Dispatcher] no more drivers to ask for trip %s", tripID)
+		return d.giveUp(ctx, dispatch)
+	}
+
+	marshalledEvent, err := json.Marshal(messaging.TripEventData{Trip: dispatch.trip})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	// notify the driver about potential trip
+	if err := d.publisher.PublishMessage(ctx, contracts.DriverCmdTripRequest, contracts.AmqpMessage{
+		OwnerID: driverID,
+		Data:    marshalledEvent,
+	}); err != nil {
+		d.service.ReleaseOffer(driverID, tripID)
+		return fmt.Errorf("failed to publish trip request message: %v", err)
+	}
+
+	dispatch.attempts++
+	dispatch.askedDrivers[driverID] = struct{}{}
+	dispatch.offeredDriverID = driverID
+	dispatch.timer = time.AfterFunc(d.cfg.OfferTimeout, func() {
+		d.offerTimedOut(tripID, driverID)
+	})
+	log.Printf("[Dispatcher] trip %s offered to driver %s (attempt %d/%d)", tripID, driverID, dispatch.attempts, d.cfg.MaxAttempts)
+
+	if d.onOffer != nil {
+		d.onOffer(driverID, dispatch.trip)
+	}
+
+	return nil
+}
+
+// nextDriver reserves the closest available driver that wasn't asked yet
+func (d *Dispatcher) nextDriver(dispatch *tripDispatch) (string, bool) {
+	packageSlug := dispatch.trip.GetSelectedFare().GetPackageSlug()
+
+	for _, driverID := range d.service.FindAvailableDrivers(packageSlug, tripPickup(dispatch.trip)) {
+		if _, asked := dispatch.askedDrivers[driverID]; asked {
+			continue
+		}
+
+		// the driver may have been offered another trip since the search
+		if err := d.service.OfferTrip(driverID, dispatch.trip.Id); err != nil {
+			continue
+		}
+
+		return driverID, true
+	}
+
+	return "", false
+}
+
+// tripPickup returns where the rider waits, the first point of the trip route.
+// Route coordinates keep the OSRM [longitude, latitude] order in the latitude/longitude
+// fields (the frontend swaps them back), hence the swap here.
+func tripPickup(trip *pb.Trip) *pbd.Location {
+	geometry := trip.GetRoute().GetGeometry()
+	if len(geometry) == 0 || len(geometry[0].GetCoordinates()) == 0 {
+		return nil
+	}
+
+	start := geometry[0].GetCoordinates()[0]
+	return &pbd.Location{
+		Latitude:  start.GetLongitude(),
+		Longitude: start.GetLatitude(),
+	}
+}
+
+// giveUp tells the rider that no driver was found, the dispatch is over once they are told.
+// Must be called with d.mu held.
+func (d *Dispatcher) giveUp(ctx context.Context, dispatch *tripDispatch) error {
+	marshalledEvent, err := json.Marshal(messaging.TripEventData{Trip: dispatch.trip})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	if err := d.publisher.PublishMessage(ctx, contracts.TripEventNoDriversFound, contracts.AmqpMessage{
+		OwnerID: dispatch.trip.UserID,
+		Data:    marshalledEvent,
+	}); err != nil {
+		return fmt.Errorf("failed to publish no drivers found message: %v", err)
+	}
+	delete(d.dispatches, dispatch.trip.Id)
+
+	return nil
+}
+
+// offerTimedOut withdraws the offer from a driver that didn't answer and offers the trip
+// to the next driver right away, the search doesn't depend on the broker to move on.
+// Without a driver, it retries a failed offer.
+func (d *Dispatcher) offerTimedOut(tripID, driverID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dispatch, exists := d.dispatches[tripID]
+	if !exists || dispatch.offeredDriverID != driverID {
+		return
+	}
+	dispatch.timer = nil
+
+	ctx := context.Background()
+
+	if driverID != "" {
+		log.Printf("[Dispatcher] driver %s did not answer trip %s in time", driverID, tripID)
+
+		d.service.ReleaseOffer(driverID, tripID)
+		dispatch.offeredDriverID = ""
+		d.withdrawOffer(ctx, dispatch, driverID)
+	}
+
+	if err := d.offerNext(ctx, dispatch); err != nil {
+		// nothing else would move the trip on, the broker may be back by the next try
+		log.Printf("[Dispatcher] failed to move trip %s on, retrying in %s: %v", tripID, d.cfg.OfferTimeout, err)
+		dispatch.timer = time.AfterFunc(d.cfg.OfferTimeout, func() {
+			d.offerTimedOut(tripID, "")
+		})
+	}
+}
+
+// withdrawOffer tells the driver the request on their screen isn't theirs to accept anymore
+func (d *Dispatcher) withdrawOffer(ctx context.Context, dispatch *tripDispatch, driverID string) {
+	marshalledEvent, err := json.Marshal(messaging.TripEventData{
+		Trip:     dispatch.trip,
+		DriverID: driverID,
+	})
+	if err != nil {
+		log.Printf("failed to marshal message: %v", err)
+		return
+	}
+
+	if err := d.publisher.PublishMessage(ctx, contracts.DriverCmdTripCancel, contracts.AmqpMessage{
+		OwnerID: driverID,
+		Data:    marshalledEvent,
+	}); err != nil {
+		log.Printf("failed to publish trip cancel message: %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"ride-sharing/shared/contracts"
+	pbd "ride-sharing/shared/proto/driver"
+	pb "ride-sharing/shared/proto/trip"
+)
+
+type publishedMessage struct {
+	routingKey string
+	ownerID    string
+}
+
+// fakePublisher records the published messages, failing the ones of the routing keys told to
+type fakePublisher struct {
+	mu       sync.Mutex
+	messages []publishedMessage
+	failures map[string]int // routing key -> how many of the next messages fail
+}
+
+func (p *fakePublisher) PublishMessage(ctx context.Context, routingKey string, msg contracts.AmqpMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures[routingKey] > 0 {
+		p.failures[routingKey]--
+		return errors.New("broker unavailable")
+	}
+
+	p.messages = append(p.messages, publishedMessage{routingKey: routingKey, ownerID: msg.OwnerID})
+	return nil
+}
+
+func (p *fakePublisher) failNext(routingKey string, n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures == nil {
+		p.failures = make(map[string]int)
+	}
+	p.failures[routingKey] = n
+}
+
+// sent returns the owners of the messages published with the routing key, in order
+func (p *fakePublisher) sent(routingKey string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var owners []string
+	for _, m := range p.messages {
+		if m.routingKey == routingKey {
+			owners = append(owners, m.ownerID)
+		}
+	}
+	return owners
+}
+
+var testPickup = &pbd.Location{Latitude: 37.7749, Longitude: -122.4194}
+
+func testMatchingConfig() MatchingConfig {
+	return MatchingConfig{IndexPrecision: 6, InitialRadiusKm: 2, MaxRadiusKm: 20, RadiusGrowth: 2}
+}
+
+func testDispatchConfig() DispatchConfig {
+	return DispatchConfig{OfferTimeout: time.Minute, MaxAttempts: 5, Deadline: time.Hour}
+}
+
+// newTestDrivers registers available sedan drivers, the first one closest to the pickup
+func newTestDrivers(t *testing.T, service *Service, driverIDs ...string) {
+	t.Helper()
+
+	for i, driverID := range driverIDs {
+		if _, err := service.RegisterDriverOnRoute(driverID, "sedan", 0); err != nil {
+			t.Fatalf("RegisterDriverOnRoute: %v", err)
+		}
+		location := &pbd.Location{Latitude: testPickup.Latitude + float64(i+1)*0.001, Longitude: testPickup.Longitude}
+		if _, _, _, err := service.UpdateDriverLocation(driverID, location); err != nil {
+			t.Fatalf("UpdateDriverLocation: %v", err)
+		}
+	}
+}
+
+// newTestTrip returns a sedan trip starting at the pickup, the route keeping the OSRM coordinate order
+func newTestTrip(tripID string) *pb.Trip {
+	return &pb.Trip{
+		Id:           tripID,
+		UserID:       "rider-1",
+		SelectedFare: &pb.RideFare{PackageSlug: "sedan"},
+		Route: &pb.Route{Geometry: []*pb.Geometry{{Coordinates: []*pb.Coordinate{
+			{Latitude: testPickup.Longitude, Longitude: testPickup.Latitude},
+		}}}},
+	}
+}
+
+func newTestDispatcher(t *testing.T, cfg DispatchConfig, driverIDs ...string) (*Dispatcher, *Service, *fakePublisher) {
+	t.Helper()
+
+	service := NewService(testMatchingConfig())
+	newTestDrivers(t, service, driverIDs...)
+	publisher := &fakePublisher{}
+
+	return NewDispatcher(publisher, service, cfg), service, publisher
+}
+
+func TestDispatcherOffersOneDriverAtATime(t *testing.T) {
+	dispatcher, service, publisher := newTestDispatcher(t, testDispatchConfig(), "driver-1", "driver-2")
+	trip := newTestTrip("trip-1")
+
+	if err := dispatcher.Start(context.Background(), trip); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	// a redelivered trip doesn't start another search
+	if err := dispatcher.Start(context.Background(), trip); err != nil {
+		t.Fatalf("second Start: %v", err)
+	}
+
+	if got := publisher.sent(contracts.DriverCmdTripRequest); len(got) != 1 || got[0] != "driver-1" {
+		t.Fatalf("trip requested from %v, want the closest driver only", got)
+	}
+	if !dispatcher.Offered(trip.Id, "driver-1") || dispatcher.Offered(trip.Id, "driver-2") {
+		t.Errorf("the trip must only be offered to driver-1")
+	}
+	if status := service.DriverStatus("driver-1"); status != DriverStatusOffered {
+		t.Errorf("driver-1 is %s, want %s", status, DriverStatusOffered)
+	}
+	if status := service.DriverStatus("driver-2"); status != DriverStatusAvailable {
+		t.Errorf("driver-2 is %s, want %s", status, DriverStatusAvailable)
+	}
+}
+
+func TestDispatcherTimeoutOffersNextDriver(t *testing.T) {
+	cfg := testDispatchConfig()
+	cfg.OfferTimeout = 20 * time.Millisecond
+	dispatcher, service, publisher := newTestDispatcher(t, cfg, "driver-1", "driver-2")
+	trip := newTestTrip("trip-1")
+
+	if err := dispatcher.Start(context.Background(), trip); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	waitFor(t, func() bool { return len(publisher.sent(contracts.DriverCmdTripRequest)) == 2 })
+
+	if got := publisher.sent(contracts.DriverCmdTripRequest); got[1] != "driver-2" {
+		t.Errorf("trip requested from %v, want driver-2 next", got)
+	}
+	if got := publisher.sent(contracts.DriverCmdTripCancel); len(got) == 0 || got[0] != "driver-1" {
+		t.Errorf("trip withdrawn from %v, want driver-1", got)
+	}
+	// the driver who timed out can't accept anymore
+	if dispatcher.Offered(trip.Id, "driver-1") {
+		t.Errorf("the trip is still offered to driver-1 after their offer timed out")
+	}
+	if status := service.DriverStatus("driver-1"); status != DriverStatusAvailable {
+		t.Errorf("driver-1 is %s, want %s", status, DriverStatusAvailable)
+	}
+}
+
+func TestDispatcherTimeoutRetriesFailedOffer(t *testing.T) {
+	cfg := testDispatchConfig()
+	cfg.OfferTimeout = 20 * time.Millisecond
+	dispatcher, _, publisher := newTestDispatcher(t, cfg, "driver-1", "driver-2")
+	trip := newTestTrip("trip-1")
+
+	if err := dispatcher.Start(context.Background(), trip); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	// the broker is down when driver-1 times out
+	publisher.failNext(contracts.DriverCmdTripRequest, 1)
+
+	waitFor(t, func() bool { return dispatcher.Offered(trip.Id, "driver-2") })
+
+	if got := publisher.sent(contracts.DriverCmdTripRequest); len(got) != 2 || got[1] != "driver-2" {
+		t.Errorf("trip requested from %v, want driver-2 once the broker is back", got)
+	}
+}
+
+func TestDispatcherDeclineOffersNextDriver(t *testing.T) {
+	ctx := context.Background()
+	dispatcher, service, publisher := newTestDispatcher(t, testDispatchConfig(), "driver-1", "driver-2")
+	trip := newTestTrip("trip-1")
+
+	if err := dispatcher.Start(ctx, trip); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := dispatcher.DriverNotInterested(ctx, trip, "driver-1"); err != nil {
+		t.Fatalf("DriverNotInterested: %v", err)
+	}
+	// a repeated answer of the driver who declined is ignored
+	if err := dispatcher.DriverNotInterested(ctx, trip, "driver-1"); err != nil {
+		t.Fatalf("second DriverNotInterested: %v", err)
+	}
+
+	if got := publisher.sent(contracts.DriverCmdTripRequest); len(got) != 2 || got[1] != "driver-2" {
+		t.Fatalf("trip requested from %v, want driver-1 then driver-2", got)
+	}
+	if dispatcher.Offered(trip.Id, "driver-1") || !dispatcher.Offered(trip.Id, "driver-2") {
+		t.Errorf("the trip must only be offered to driver-2")
+	}
+	if status := service.DriverStatus("driver-1"); status != DriverStatusAvailable {
+		t.Errorf("driver-1 is %s, want %s", status, DriverStatusAvailable)
+	}
+}
+
+func TestDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	cfg := testDispatchConfig()
+	cfg.MaxAttempts = 2
+	dispatcher, _, publisher := newTestDispatcher(t, cfg, "driver-1", "driver-2", "driver-3")
+	trip := newTestTrip("trip-1")
+
+	if err := dispatcher.Start(ctx, trip); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	for _, driverID := range []string{"driver-1", "driver-2"} {
+		if err := dispatcher.DriverNotInterested(ctx, trip, driverID); err != nil {
+			t.Fatalf("DriverNotInterested: %v", err)
+		}
+	}
+
+	if got := publisher.sent(contracts.DriverCmdTripRequest); len(got) != 2 {
+		t.Errorf("trip requested from %v, want 2 drivers", got)
+	}
+	if got := publisher.sent(contracts.TripEventNoDriversFound); len(got) != 1 || got[0] != trip.UserID {
+		t.Errorf("no drivers found sent to %v, want the rider", got)
+	}
+	if dispatcher.Offered(trip.Id, "driver-3") {
+		t.Errorf("the trip is offered after giving up")
+	}
+}
+
+func TestDispatcherGivesUpWithoutDrivers(t *testing.T) {
+	dispatcher, _, publisher := newTestDispatcher(t, testDispatchConfig())
+	trip := newTestTrip("trip-1")
+
+	if err := dispatcher.Start(context.Background(), trip); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	if got := publisher.sent(contracts.TripEventNoDriversFound); len(got) != 1 {
+		t.Errorf("no drivers found sent %d times, want once", len(got))
+	}
+}
+
+func TestDispatcherGivesUpPastDeadline(t *testing.T) {
+	cfg := testDispatchConfig()
+	cfg.OfferTimeout = 40 * time.Millisecond
+	cfg.Deadline = 20 * time.Millisecond
+	dispatcher, _, publisher := newTestDispatcher(t, cfg, "driver-1", "driver-2")
+	trip := newTestTrip("trip-1")
+
+	if err := dispatcher.Start(context.Background(), trip); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// driver-1 times out past the deadline, driver-2 isn't asked
+	waitFor(t, func() bool { return len(publisher.sent(contracts.TripEventNoDriversFound)) == 1 })
+
+	if got := publisher.sent(contracts.DriverCmdTripRequest); len(got) != 1 {
+		t.Errorf("trip requested from %v, want driver-1 only", got)
+	}
+}
+
+func TestDispatcherStopsOnAssignment(t *testing.T) {
+	cfg := testDispatchConfig()
+	cfg.OfferTimeout = 20 * time.Millisecond
+	dispatcher, _, publisher := newTestDispatcher(t, cfg, "driver-1", "driver-2")
+	trip := newTestTrip("trip-1")
+
+	if err := dispatcher.Start(context.Background(), trip); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	offeredDriverID, ok := dispatcher.Stop(trip.Id)
+	if !ok || offeredDriverID != "driver-1" {
+		t.Fatalf("Stop: got %q, %v, want driver-1", offeredDriverID, ok)
+	}
+
+	// the offer timeout doesn't bring the trip back
+	time.Sleep(3 * cfg.OfferTimeout)
+
+	if got := publisher.sent(contracts.DriverCmdTripRequest); len(got) != 1 {
+		t.Errorf("trip requested from %v after the dispatch stopped, want driver-1 only", got)
+	}
+	if dispatcher.Offered(trip.Id, "driver-1") {
+		t.Errorf("the trip is still offered after the dispatch stopped")
+	}
+	if _, ok := dispatcher.Stop(trip.Id); ok {
+		t.Errorf("second Stop found the dispatch")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/messaging"
+	pb "ride-sharing/shared/proto/driver"
+)
+
+type driverEventPublisher struct {
+	rabbitmq *messaging.RabbitMQ
+}
+
+func NewDriverEventPublisher(rabbitmq *messaging.RabbitMQ) *driverEventPublisher {
+	return &driverEventPublisher{
+		rabbitmq: rabbitmq,
+	}
+}
+
+// PublishDriverLocation lets the rider of the trip follow the driver on the map
+func (p *driverEventPublisher) PublishDriverLocation(ctx context.Context, driver *pb.Driver, tripID, riderID string) error {
+	marshalledEvent, err := json.Marshal(messaging.DriverLocationData{
+		TripID: tripID,
+		Driver: driver,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	return p.rabbitmq.PublishMessage(ctx, contracts.DriverEventLocation, contracts.AmqpMessage{
+		OwnerID: riderID,
+		Data:    marshalledEvent,
+	})
+}
+
+// PublishTripResponse answers a trip on behalf of the driver, the way the driver app does through the gateway
+func (p *driverEventPublisher) PublishTripResponse(ctx context.Context, routingKey string, driver *pb.Driver, tripID, riderID string) error {
+	marshalledEvent, err := json.Marshal(messaging.DriverTripResponseData{
+		Driver:  driver,
+		TripID:  tripID,
+		RiderID: riderID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	return p.rabbitmq.PublishMessage(ctx, routingKey, contracts.AmqpMessage{
+		OwnerID: driver.Id,
+		Data:    marshalledEvent,
+	})
+}
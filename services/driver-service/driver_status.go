@@ -0,0 +1,27 @@
+package main
+
+import "errors"
+
+type DriverStatus string
+
+const (
+	// DriverStatusOffline is the status of drivers that aren't registered (disconnected)
+	DriverStatusOffline   DriverStatus = "offline"
+	DriverStatusAvailable DriverStatus = "available"
+	// DriverStatusOffered drivers are waiting to answer a trip request
+	DriverStatusOffered DriverStatus = "offered"
+	// DriverStatusEnRoute drivers were assigned a trip and are driving to the pickup
+	DriverStatusEnRoute DriverStatus = "en_route"
+	DriverStatusOnTrip  DriverStatus = "on_trip"
+)
+
+var (
+	ErrDriverUnavailable = errors.New("driver is not available")
+	// ErrDriverBusy is returned when a driver is assigned a trip while driving for another one
+	ErrDriverBusy = errors.New("driver is already driving for another trip")
+)
+
+// IsBusy reports whether the driver is driving for a trip
+func (s DriverStatus) IsBusy() bool {
+	return s == DriverStatusEnRoute || s == DriverStatusOnTrip
+}
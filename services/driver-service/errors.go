@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"ride-sharing/shared/auth"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/grpcerr"
+	pb "ride-sharing/shared/proto/driver"
+
+	"google.golang.org/grpc/codes"
+)
+
+// toStatus returns the gRPC error for err, with the message built from format and args
+func toStatus(err error, format string, args ...any) error {
+	switch {
+	case errors.Is(err, ErrDriverNotFound):
+		return grpcerr.New(codes.NotFound, contracts.ErrCodeDriverNotFound, format, args...)
+	case errors.Is(err, ErrDriverBusy):
+		return grpcerr.New(codes.FailedPrecondition, contracts.ErrCodeConflict, format, args...)
+	case errors.Is(err, ErrDriverUnavailable):
+		return grpcerr.New(codes.FailedPrecondition, contracts.ErrCodeDriverUnavailable, format, args...)
+	case errors.Is(err, ErrTripNotOffered):
+		return grpcerr.New(codes.PermissionDenied, contracts.ErrCodeTripNotOffered, format, args...)
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return grpcerr.New(codes.Unauthenticated, contracts.ErrCodeUnauthenticated, format, args...)
+	case errors.Is(err, auth.ErrForbidden):
+		return grpcerr.New(codes.PermissionDenied, contracts.ErrCodePermissionDenied, format, args...)
+	}
+
+	return grpcerr.New(codes.Internal, contracts.ErrCodeInternal, format, args...)
+}
+
+type requiredField struct {
+	name  string
+	value string
+}
+
+// validateRequired returns an InvalidArgument error listing the fields left empty
+func validateRequired(fields ...requiredField) error {
+	var violations []grpcerr.FieldViolation
+	for _, f := range fields {
+		if f.value == "" {
+			violations = append(violations, grpcerr.FieldViolation{
+				Field:       f.name,
+				Description: fmt.Sprintf("%s is required", f.name),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return grpcerr.InvalidArgument(contracts.ErrCodeInvalidArgument, violations...)
+	}
+
+	return nil
+}
+
+func validateUpdateDriverLocation(req *pb.UpdateDriverLocationRequest) error {
+	var violations []grpcerr.FieldViolation
+
+	if req.GetDriverID() == "" {
+		violations = append(violations, grpcerr.FieldViolation{Field: "driverID", Description: "driverID is required"})
+	}
+
+	location := req.GetLocation()
+	switch {
+	case location == nil:
+		violations = append(violations, grpcerr.FieldViolation{Field: "location", Description: "location is required"})
+	case location.Latitude < -90 || location.Latitude > 90 || location.Longitude < -180 || location.Longitude > 180:
+		violations = append(violations, grpcerr.FieldViolation{Field: "location", Description: "location is not a valid coordinate"})
+	}
+
+	if len(violations) > 0 {
+		return grpcerr.InvalidArgument(contracts.ErrCodeInvalidArgument, violations...)
+	}
+
+	return nil
+}
+
+func validateStreamDriverLocations(req *pb.StreamDriverLocationsRequest) error {
+	var violations []grpcerr.FieldViolation
+
+	switch {
+	case req.GetDriverID() == "" && len(req.GetGeohashes()) == 0:
+		violations = append(violations, grpcerr.FieldViolation{Field: "driverID", Description: "either driverID or geohashes is required"})
+	case req.GetDriverID() != "" && len(req.GetGeohashes()) > 0:
+		violations = append(violations, grpcerr.FieldViolation{Field: "geohashes", Description: "geohashes can't be combined with driverID"})
+	}
+
+	for _, cell := range req.GetGeohashes() {
+		if cell == "" {
+			violations = append(violations, grpcerr.FieldViolation{Field: "geohashes", Description: "geohashes can't be empty"})
+			break
+		}
+	}
+
+	if len(violations) > 0 {
+		return grpcerr.InvalidArgument(contracts.ErrCodeInvalidArgument, violations...)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math"
+	"ride-sharing/shared/util"
+
+	"github.com/mmcloughlin/geohash"
+)
+
+// geoIndex buckets drivers by every prefix of their geohash, so the drivers of a cell
+// of any size (up to the index precision) can be looked up directly.
+// It is not safe for concurrent use, the Service guards it with its own lock.
+type geoIndex struct {
+	precision uint
+	cells     map[string]map[string]struct{} // geohash prefix -> driver IDs
+	hashes    map[string]string              // driver ID -> geohash it is indexed under
+}
+
+func newGeoIndex(precision uint) *geoIndex {
+	return &geoIndex{
+		precision: precision,
+		cells:     make(map[string]map[string]struct{}),
+		hashes:    make(map[string]string),
+	}
+}
+
+// Insert indexes the driver at the given position, replacing any previous position
+func (g *geoIndex) Insert(driverID string, lat, lon float64) {
+	g.Remove(driverID)
+
+	hash := geohash.EncodeWithPrecision(lat, lon, g.precision)
+	for i := 1; i <= len(hash); i++ {
+		prefix := hash[:i]
+		if g.cells[prefix] == nil {
+			g.cells[prefix] = make(map[string]struct{})
+		}
+		g.cells[prefix][driverID] = struct{}{}
+	}
+
+	g.hashes[driverID] = hash
+}
+
+func (g *geoIndex) Remove(driverID string) {
+	hash, ok := g.hashes[driverID]
+	if !ok {
+		return
+	}
+
+	for i := 1; i <= len(hash); i++ {
+		prefix := hash[:i]
+		delete(g.cells[prefix], driverID)
+		if len(g.cells[prefix]) == 0 {
+			delete(g.cells, prefix)
+		}
+	}
+
+	delete(g.hashes, driverID)
+}
+
+// InCell returns the drivers in the geohash cell. Cells smaller than the index
+// precision can't be told apart, the drivers of the enclosing indexed cell are returned.
+func (g *geoIndex) InCell(cell string) []string {
+	if uint(len(cell)) > g.precision {
+		cell = cell[:g.precision]
+	}
+
+	driverIDs := make([]string, 0, len(g.cells[cell]))
+	for driverID := range g.cells[cell] {
+		driverIDs = append(driverIDs, driverID)
+	}
+
+	return driverIDs
+}
+
+// Nearby returns the drivers in the cell containing the coordinate and its 8 neighbors,
+// using the smallest cells that still cover every point within radiusKm.
+// The result is a superset of the drivers within the radius, callers filter by distance.
+func (g *geoIndex) Nearby(lat, lon, radiusKm float64) []string {
+	hash := geohash.EncodeWithPrecision(lat, lon, g.coveringPrecision(lat, lon, radiusKm))
+
+	var driverIDs []string
+	for _, cell := range append(geohash.Neighbors(hash), hash) {
+		for driverID := range g.cells[cell] {
+			driverIDs = append(driverIDs, driverID)
+		}
+	}
+
+	return driverIDs
+}
+
+// coveringPrecision returns the highest precision whose cells around the coordinate are
+// at least radiusKm wide and high, so the neighboring cells cover the whole radius.
+func (g *geoIndex) coveringPrecision(lat, lon, radiusKm float64) uint {
+	for precision := g.precision; precision > 1; precision-- {
+		box := geohash.BoundingBox(geohash.EncodeWithPrecision(lat, lon, precision))
+
+		heightKm := util.HaversineDistanceKm(box.MinLat, lon, box.MaxLat, lon)
+		// the cell is narrowest on the edge that is the furthest away from the equator
+		edgeLat := math.Max(math.Abs(box.MinLat), math.Abs(box.MaxLat))
+		widthKm := util.HaversineDistanceKm(edgeLat, box.MinLng, edgeLat, box.MaxLng)
+
+		if math.Min(heightKm, widthKm) >= radiusKm {
+			return precision
+		}
+	}
+
+	return 1
+}
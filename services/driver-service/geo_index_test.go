@@ -0,0 +1,163 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	pbd "ride-sharing/shared/proto/driver"
+
+	"github.com/mmcloughlin/geohash"
+)
+
+func TestGeoIndexNearbyAcrossCellBoundary(t *testing.T) {
+	index := newGeoIndex(6)
+
+	// two points a few meters apart on either side of a cell edge
+	box := geohash.BoundingBox(geohash.EncodeWithPrecision(testPickup.Latitude, testPickup.Longitude, 6))
+	edge := box.MaxLat
+	index.Insert("across", edge+0.00005, testPickup.Longitude)
+	index.Insert("far", testPickup.Latitude+1, testPickup.Longitude)
+
+	if geohash.EncodeWithPrecision(edge-0.00005, testPickup.Longitude, 6) == geohash.EncodeWithPrecision(edge+0.00005, testPickup.Longitude, 6) {
+		t.Fatalf("the points are in the same cell, the test doesn't cross a boundary")
+	}
+
+	got := index.Nearby(edge-0.00005, testPickup.Longitude, 0.5)
+	if !slices.Contains(got, "across") {
+		t.Errorf("Nearby missed the driver in the neighboring cell: got %v", got)
+	}
+	if slices.Contains(got, "far") {
+		t.Errorf("Nearby returned a driver 100km away: got %v", got)
+	}
+}
+
+func TestGeoIndexCoveringPrecision(t *testing.T) {
+	index := newGeoIndex(6)
+
+	tests := []struct {
+		radiusKm float64
+		want     uint
+	}{
+		// around the pickup, precision 6 cells are about 0.9km x 0.6km
+		{0.1, 6},
+		// precision 5 cells are about 3.9km wide, narrower than 4km
+		{1, 5},
+		{3, 5},
+		{4, 4},
+		// precision 3 cells are about 120km x 156km
+		{100, 3},
+		// no cell is that large
+		{5000, 1},
+	}
+
+	for _, tt := range tests {
+		if got := index.coveringPrecision(testPickup.Latitude, testPickup.Longitude, tt.radiusKm); got != tt.want {
+			t.Errorf("coveringPrecision(%vkm) = %d, want %d", tt.radiusKm, got, tt.want)
+		}
+	}
+
+	// the index precision is the upper bound
+	if got := newGeoIndex(4).coveringPrecision(testPickup.Latitude, testPickup.Longitude, 0.1); got != 4 {
+		t.Errorf("coveringPrecision of a precision 4 index = %d, want 4", got)
+	}
+}
+
+func TestGeoIndexReindexesMovedDrivers(t *testing.T) {
+	index := newGeoIndex(6)
+
+	index.Insert("driver-1", testPickup.Latitude, testPickup.Longitude)
+	oldCell := geohash.EncodeWithPrecision(testPickup.Latitude, testPickup.Longitude, 6)
+
+	// the driver moves about 11km north
+	index.Insert("driver-1", testPickup.Latitude+0.1, testPickup.Longitude)
+	newCell := geohash.EncodeWithPrecision(testPickup.Latitude+0.1, testPickup.Longitude, 6)
+
+	if got := index.InCell(oldCell); len(got) != 0 {
+		t.Errorf("the old cell still has %v", got)
+	}
+	if got := index.InCell(newCell); !slices.Equal(got, []string{"driver-1"}) {
+		t.Errorf("the new cell has %v, want driver-1", got)
+	}
+	// longer geohashes are looked up in their indexed cell
+	if got := index.InCell(geohash.Encode(testPickup.Latitude+0.1, testPickup.Longitude)); !slices.Equal(got, []string{"driver-1"}) {
+		t.Errorf("the full precision geohash has %v, want driver-1", got)
+	}
+
+	index.Remove("driver-1")
+	if got := index.InCell(newCell[:1]); len(got) != 0 {
+		t.Errorf("removed driver still indexed: %v", got)
+	}
+}
+
+func TestFindAvailableDriversRanksByDistance(t *testing.T) {
+	service := NewService(testMatchingConfig())
+
+	locations := map[string]*pbd.Location{
+		"far":     {Latitude: testPickup.Latitude + 0.015, Longitude: testPickup.Longitude},
+		"closest": {Latitude: testPickup.Latitude + 0.001, Longitude: testPickup.Longitude},
+		"middle":  {Latitude: testPickup.Latitude, Longitude: testPickup.Longitude - 0.01},
+	}
+	for driverID, location := range locations {
+		if _, err := service.RegisterDriverOnRoute(driverID, "sedan", 0); err != nil {
+			t.Fatalf("RegisterDriverOnRoute: %v", err)
+		}
+		if _, _, _, err := service.UpdateDriverLocation(driverID, location); err != nil {
+			t.Fatalf("UpdateDriverLocation: %v", err)
+		}
+	}
+	// the drivers of other packages aren't matched
+	if _, err := service.RegisterDriverOnRoute("suv", "suv", 0); err != nil {
+		t.Fatalf("RegisterDriverOnRoute: %v", err)
+	}
+	if _, _, _, err := service.UpdateDriverLocation("suv", testPickup); err != nil {
+		t.Fatalf("UpdateDriverLocation: %v", err)
+	}
+
+	got := service.FindAvailableDrivers("sedan", testPickup)
+	if want := []string{"closest", "middle", "far"}; !slices.Equal(got, want) {
+		t.Errorf("got drivers %v, want %v", got, want)
+	}
+
+	if err := service.OfferTrip("closest", "trip-1"); err != nil {
+		t.Fatalf("OfferTrip: %v", err)
+	}
+	if got := service.FindAvailableDrivers("sedan", testPickup); slices.Contains(got, "closest") {
+		t.Errorf("the offered driver is still matched: %v", got)
+	}
+}
+
+func TestFindAvailableDriversWidensRadius(t *testing.T) {
+	service := NewService(testMatchingConfig())
+
+	// about 8km away, past the initial 2km radius but within the widened ones
+	if _, err := service.RegisterDriverOnRoute("driver-1", "sedan", 0); err != nil {
+		t.Fatalf("RegisterDriverOnRoute: %v", err)
+	}
+	if _, _, _, err := service.UpdateDriverLocation("driver-1", &pbd.Location{Latitude: testPickup.Latitude + 0.072, Longitude: testPickup.Longitude}); err != nil {
+		t.Fatalf("UpdateDriverLocation: %v", err)
+	}
+	// about 55km away, past the maximum radius
+	if _, err := service.RegisterDriverOnRoute("driver-2", "sedan", 0); err != nil {
+		t.Fatalf("RegisterDriverOnRoute: %v", err)
+	}
+	if _, _, _, err := service.UpdateDriverLocation("driver-2", &pbd.Location{Latitude: testPickup.Latitude + 0.5, Longitude: testPickup.Longitude}); err != nil {
+		t.Fatalf("UpdateDriverLocation: %v", err)
+	}
+
+	if got := service.FindAvailableDrivers("sedan", testPickup); !slices.Equal(got, []string{"driver-1"}) {
+		t.Errorf("got drivers %v, want driver-1 once the radius widened", got)
+	}
+
+	cfg := testMatchingConfig()
+	cfg.MaxRadiusKm = 4
+	narrow := NewService(cfg)
+	if _, err := narrow.RegisterDriverOnRoute("driver-1", "sedan", 0); err != nil {
+		t.Fatalf("RegisterDriverOnRoute: %v", err)
+	}
+	if _, _, _, err := narrow.UpdateDriverLocation("driver-1", &pbd.Location{Latitude: testPickup.Latitude + 0.072, Longitude: testPickup.Longitude}); err != nil {
+		t.Fatalf("UpdateDriverLocation: %v", err)
+	}
+	if got := narrow.FindAvailableDrivers("sedan", testPickup); len(got) != 0 {
+		t.Errorf("got drivers %v past the maximum radius, want none", got)
+	}
+}
@@ -3,28 +3,42 @@ package main
 import (
 	"context"
 	"log"
+	"ride-sharing/shared/auth"
 	pb "ride-sharing/shared/proto/driver"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type gRPCHandler struct {
 	pb.UnimplementedDriverServiceServer
-	service *Service
+	service    *Service
+	dispatcher *Dispatcher
+	publisher  *driverEventPublisher
 }
 
-func NewGRPCHandler(server *grpc.Server, service *Service) {
+func NewGRPCHandler(server *grpc.Server, service *Service, dispatcher *Dispatcher, publisher *driverEventPublisher) {
 	pb.RegisterDriverServiceServer(server, &gRPCHandler{
-		service: service,
+		service:    service,
+		dispatcher: dispatcher,
+		publisher:  publisher,
 	})
 }
 
 func (h *gRPCHandler) RegisterDriver(ctx context.Context, req *pb.RegisterDriverRequest) (*pb.RegisterDriverResponse, error) {
+	if err := validateRequired(
+		requiredField{"driverID", req.GetDriverID()},
+		requiredField{"packageSlug", req.GetPackageSlug()},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, req.GetDriverID(), auth.RoleDriver); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
 	driver, err := h.service.RegisterDriver(req.GetDriverID(), req.GetPackageSlug())
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to register driver")
+		return nil, toStatus(err, "failed to register driver: %v", err)
 	}
 
 	log.Println("[GRPC RegisterDriver] Driver registered successfully:", driver.Id)
@@ -34,7 +48,11 @@ func (h *gRPCHandler) RegisterDriver(ctx context.Context, req *pb.RegisterDriver
 	}, nil
 }
 
-func (h *gRPCHandler) UnregisterDriver(ctx context.Context, req *pb.RegisterDriverRequest) (*pb.RegisterDriverResponse, error) {
+func (h *gRPCHandler) UnRegisterDriver(ctx context.Context, req *pb.RegisterDriverRequest) (*pb.RegisterDriverResponse, error) {
+	if err := auth.Authorize(ctx, req.GetDriverID(), auth.RoleDriver); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
 	h.service.UnregisterDriver(req.GetDriverID())
 
 	return &pb.RegisterDriverResponse{
@@ -43,3 +61,122 @@ func (h *gRPCHandler) UnregisterDriver(ctx context.Context, req *pb.RegisterDriv
 		},
 	}, nil
 }
+
+func (h *gRPCHandler) UpdateDriverLocation(ctx context.Context, req *pb.UpdateDriverLocationRequest) (*pb.UpdateDriverLocationResponse, error) {
+	if err := validateUpdateDriverLocation(req); err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, req.GetDriverID(), auth.RoleDriver); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
+	driver, tripID, riderID, err := h.service.UpdateDriverLocation(req.GetDriverID(), req.GetLocation())
+	if err != nil {
+		return nil, toStatus(err, "failed to update location of driver %s: %v", req.GetDriverID(), err)
+	}
+
+	if riderID != "" {
+		if err := h.publisher.PublishDriverLocation(ctx, driver, tripID, riderID); err != nil {
+			log.Printf("failed to publish location of driver %s: %v", driver.Id, err)
+		}
+	}
+
+	return &pb.UpdateDriverLocationResponse{
+		Driver: driver,
+	}, nil
+}
+
+func (h *gRPCHandler) CountAvailableDrivers(ctx context.Context, req *pb.CountAvailableDriversRequest) (*pb.CountAvailableDriversResponse, error) {
+	if err := validateRequired(requiredField{"geohash", req.GetGeohash()}); err != nil {
+		return nil, err
+	}
+
+	// the supply is only counted for the pricing of the trip service
+	if err := auth.AuthorizeRole(ctx, auth.RoleService); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
+	return &pb.CountAvailableDriversResponse{
+		Count: int32(h.service.CountAvailableDrivers(req.GetGeohash())),
+	}, nil
+}
+
+func (h *gRPCHandler) StreamDriverLocations(req *pb.StreamDriverLocationsRequest, stream grpc.ServerStreamingServer[pb.DriverLocationUpdate]) error {
+	if err := validateStreamDriverLocations(req); err != nil {
+		return err
+	}
+
+	if err := authorizeStreamDriverLocations(stream.Context(), req); err != nil {
+		return toStatus(err, "%v", err)
+	}
+
+	sub, drivers, err := h.service.SubscribeLocations(req.GetDriverID(), req.GetGeohashes())
+	if err != nil {
+		return toStatus(err, "failed to watch driver locations: %v", err)
+	}
+	defer h.service.UnsubscribeLocations(sub)
+
+	for _, driver := range drivers {
+		if err := stream.Send(&pb.DriverLocationUpdate{Driver: driver}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case update := <-sub.Updates():
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+
+			// a single driver stream is over once the driver goes offline
+			if req.GetDriverID() != "" && update.GetRemoved() {
+				return nil
+			}
+		}
+	}
+}
+
+func (h *gRPCHandler) GetTripOffer(ctx context.Context, req *pb.GetTripOfferRequest) (*pb.GetTripOfferResponse, error) {
+	if err := validateRequired(
+		requiredField{"tripID", req.GetTripID()},
+		requiredField{"driverID", req.GetDriverID()},
+	); err != nil {
+		return nil, err
+	}
+
+	// the trip service checks the offer before assigning the driver
+	if err := auth.AuthorizeRole(ctx, auth.RoleService); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
+	if !h.dispatcher.Offered(req.GetTripID(), req.GetDriverID()) {
+		return nil, toStatus(ErrTripNotOffered, "trip %s was not offered to driver %s", req.GetTripID(), req.GetDriverID())
+	}
+
+	driver, err := h.service.Driver(req.GetDriverID())
+	if err != nil {
+		return nil, toStatus(err, "failed to get driver %s: %v", req.GetDriverID(), err)
+	}
+
+	return &pb.GetTripOfferResponse{
+		Driver: driver,
+	}, nil
+}
+
+// authorizeStreamDriverLocations lets the drivers follow themselves and the riders
+// watch the drivers around them, the services watch any driver
+func authorizeStreamDriverLocations(ctx context.Context, req *pb.StreamDriverLocationsRequest) error {
+	if auth.AuthorizeRole(ctx, auth.RoleService) == nil {
+		return nil
+	}
+
+	if req.GetDriverID() != "" {
+		return auth.Authorize(ctx, req.GetDriverID(), auth.RoleDriver)
+	}
+
+	return auth.AuthorizeRole(ctx, auth.RoleRider)
+}
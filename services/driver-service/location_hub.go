@@ -0,0 +1,106 @@
+package main
+
+import (
+	"log"
+	pb "ride-sharing/shared/proto/driver"
+	"strings"
+	"sync"
+)
+
+// Updates a subscriber hasn't read yet, past this the newest updates are dropped
+const locationSubscriptionBuffer = 64
+
+// locationSubscription receives the location updates of a single driver,
+// or of every driver within a set of geohash cells
+type locationSubscription struct {
+	driverID string
+	cells    []string
+	updates  chan *pb.DriverLocationUpdate
+}
+
+// Updates returns the channel the updates are delivered on, closed once unsubscribed
+func (s *locationSubscription) Updates() <-chan *pb.DriverLocationUpdate {
+	return s.updates
+}
+
+func (s *locationSubscription) watches(driverID, hash string) bool {
+	if s.driverID != "" {
+		return s.driverID == driverID
+	}
+
+	if hash == "" {
+		return false
+	}
+
+	for _, cell := range s.cells {
+		if strings.HasPrefix(hash, cell) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// locationHub fans the driver location changes out to the streams watching them
+type locationHub struct {
+	subscriptions map[*locationSubscription]struct{}
+	mu            sync.Mutex
+}
+
+func newLocationHub() *locationHub {
+	return &locationHub{
+		subscriptions: make(map[*locationSubscription]struct{}),
+	}
+}
+
+func (h *locationHub) Subscribe(driverID string, cells []string) *locationSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &locationSubscription{
+		driverID: driverID,
+		cells:    cells,
+		updates:  make(chan *pb.DriverLocationUpdate, locationSubscriptionBuffer),
+	}
+	h.subscriptions[sub] = struct{}{}
+
+	return sub
+}
+
+func (h *locationHub) Unsubscribe(sub *locationSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscriptions[sub]; !ok {
+		return
+	}
+
+	delete(h.subscriptions, sub)
+	close(sub.updates)
+}
+
+// Publish notifies the subscribers watching the driver where it is now, or where it was before.
+// Drivers leaving the cells of a subscriber are reported as removed to it.
+// It never blocks, updates are dropped for the subscribers that don't keep up.
+func (h *locationHub) Publish(driver *pb.Driver, previousGeohash string, offline bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscriptions {
+		inside := sub.watches(driver.Id, driver.Geohash)
+		if !inside && !sub.watches(driver.Id, previousGeohash) {
+			continue
+		}
+
+		update := &pb.DriverLocationUpdate{
+			Driver:  driver,
+			Removed: offline || !inside,
+		}
+
+		select {
+		case sub.updates <- update:
+		default:
+			log.Printf("[Driver-Service] dropped location update of driver %s, subscriber is too slow", driver.Id)
+		}
+	}
+}
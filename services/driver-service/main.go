@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"ride-sharing/shared/auth"
 	"ride-sharing/shared/env"
 	"ride-sharing/shared/messaging"
 	"syscall"
@@ -41,10 +42,30 @@ func main() {
 	defer rabbitmq.Close()
 	log.Println("Starting RabbitMQ connection")
 
+	serviceKey, err := auth.LoadServiceKey()
+	if err != nil {
+		log.Fatalf("Failed to load the service key: %v", err)
+	}
+
 	// Initialize the driver service
-	service := NewService()
+	service := NewService(DefaultMatchingConfig())
+
+	dispatcher := NewDispatcher(rabbitmq, service, DefaultDispatchConfig())
+	publisher := NewDriverEventPublisher(rabbitmq)
+
+	// simulated drivers for local development, disabled unless SIMULATION_DRIVERS is set
+	if simulationCfg := DefaultSimulationConfig(); simulationCfg.Drivers > 0 {
+		simulator := NewSimulator(service, publisher, simulationCfg)
+		dispatcher.OnOffer(simulator.TripOffered)
+
+		go func() {
+			if err := simulator.Run(ctx); err != nil {
+				log.Printf("Failed to run the driver simulation: %v", err)
+			}
+		}()
+	}
 
-	consumer := NewTripConsumer(rabbitmq, service)
+	consumer := NewTripConsumer(rabbitmq, service, dispatcher)
 	go func() {
 		if err := consumer.Listen(ctx); err != nil {
 			log.Printf("Failed to listen to the message: %v", err)
@@ -52,8 +73,12 @@ func main() {
 	}()
 
 	// Starting the gRPC service
-	grpcServer := grpc.NewServer()
-	NewGRPCHandler(grpcServer, service)
+	grpcServer := grpc.NewServer(
+		// the identity of the user the api-gateway calls on behalf of
+		grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(serviceKey)),
+		grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(serviceKey)),
+	)
+	NewGRPCHandler(grpcServer, service, dispatcher, publisher)
 
 	log.Printf("Starting gRPC Driver Service on port %s", lis.Addr().String())
 	go func() {
@@ -1,30 +1,64 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	math "math/rand/v2"
+	"ride-sharing/shared/env"
 	pb "ride-sharing/shared/proto/driver"
 	"ride-sharing/shared/util"
+	"sort"
 	"sync"
 
 	"github.com/mmcloughlin/geohash"
+	"google.golang.org/protobuf/proto"
 )
 
 type driverInMap struct {
 	Driver *pb.Driver
+	Status DriverStatus
+	// Trip the driver is offered or assigned to, empty when available
+	TripID string
+	// Rider of the assigned trip, empty until the driver is assigned
+	RiderID string
 	// Index int
 	// TODO: route
 }
 
+var ErrDriverNotFound = errors.New("driver not found")
+
+type MatchingConfig struct {
+	IndexPrecision  uint    // geohash precision drivers are indexed with
+	InitialRadiusKm float64 // radius of the first search around the pickup
+	MaxRadiusKm     float64 // the search stops widening past this radius
+	RadiusGrowth    float64 // factor the radius grows by when no driver was found
+}
+
+// DefaultMatchingConfig returns the matching settings, overridable through the environment
+func DefaultMatchingConfig() MatchingConfig {
+	return MatchingConfig{
+		IndexPrecision:  uint(env.GetInt("MATCHING_GEOHASH_PRECISION", 6)),
+		InitialRadiusKm: env.GetFloat("MATCHING_INITIAL_RADIUS_KM", 2),
+		MaxRadiusKm:     env.GetFloat("MATCHING_MAX_RADIUS_KM", 20),
+		RadiusGrowth:    env.GetFloat("MATCHING_RADIUS_GROWTH", 2),
+	}
+}
+
 type Service struct {
-	drivers []*driverInMap
-	mu      sync.RWMutex
+	drivers   map[string]*driverInMap // driverID -> driver
+	index     *geoIndex
+	matching  MatchingConfig
+	locations *locationHub
+	mu        sync.RWMutex
 }
 
-func NewService() *Service {
+func NewService(matching MatchingConfig) *Service {
 	return &Service{
-		drivers: make([]*driverInMap, 0),
+		drivers:   make(map[string]*driverInMap),
+		index:     newGeoIndex(matching.IndexPrecision),
+		matching:  matching,
+		locations: newLocationHub(),
 	}
 }
 
@@ -35,34 +69,114 @@ func (s *Service) GetLength() int {
 	return len(s.drivers)
 }
 
-func (s *Service) FindAvailableDrivers(packageType string) []string {
+// FindAvailableDrivers returns the drivers of the package closest to the pickup, nearest first.
+// The search starts with a small radius and widens until drivers are found or the
+// maximum radius is reached. Without a pickup, every driver of the package matches.
+func (s *Service) FindAvailableDrivers(packageType string, pickup *pb.Location) []string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	fmt.Println("[Driver-Service] FindAvailableDrivers length:", len(s.drivers))
-	var matchingDrivers []string
 
-	for _, driver := range s.drivers {
-		if driver.Driver.PackageSlug == packageType {
-			matchingDrivers = append(matchingDrivers, driver.Driver.Id)
+	if pickup == nil {
+		var matchingDrivers []string
+		for _, driver := range s.drivers {
+			if driver.Status == DriverStatusAvailable && driver.Driver.PackageSlug == packageType {
+				matchingDrivers = append(matchingDrivers, driver.Driver.Id)
+			}
+		}
+
+		return matchingDrivers
+	}
+
+	radius := s.matching.InitialRadiusKm
+	for {
+		if matchingDrivers := s.findDriversWithin(packageType, pickup, radius); len(matchingDrivers) > 0 {
+			log.Printf("[Driver-Service] Found %d drivers within %.1fkm", len(matchingDrivers), radius)
+			return matchingDrivers
+		}
+
+		if radius >= s.matching.MaxRadiusKm || s.matching.RadiusGrowth <= 1 {
+			return []string{}
+		}
+		radius = min(radius*s.matching.RadiusGrowth, s.matching.MaxRadiusKm)
+	}
+}
+
+// CountAvailableDrivers returns how many drivers are available in the geohash cell
+func (s *Service) CountAvailableDrivers(cell string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, driverID := range s.index.InCell(cell) {
+		if driver, ok := s.drivers[driverID]; ok && driver.Status == DriverStatusAvailable {
+			count++
+		}
+	}
+
+	return count
+}
+
+// findDriversWithin must be called with s.mu held
+func (s *Service) findDriversWithin(packageType string, pickup *pb.Location, radiusKm float64) []string {
+	type candidate struct {
+		id         string
+		distanceKm float64
+	}
+
+	var candidates []candidate
+	for _, driverID := range s.index.Nearby(pickup.Latitude, pickup.Longitude, radiusKm) {
+		driver, ok := s.drivers[driverID]
+		if !ok || driver.Status != DriverStatusAvailable || driver.Driver.PackageSlug != packageType {
+			continue
+		}
+
+		location := driver.Driver.Location
+		distance := util.HaversineDistanceKm(pickup.Latitude, pickup.Longitude, location.Latitude, location.Longitude)
+		if distance <= radiusKm {
+			candidates = append(candidates, candidate{id: driverID, distanceKm: distance})
 		}
 	}
 
-	if len(matchingDrivers) == 0 {
-		return []string{}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].distanceKm < candidates[j].distanceKm
+	})
+
+	matchingDrivers := make([]string, len(candidates))
+	for i, c := range candidates {
+		matchingDrivers[i] = c.id
 	}
 
 	return matchingDrivers
 }
 
 func (s *Service) RegisterDriver(driverId string, packageSlug string) (*pb.Driver, error) {
+	return s.RegisterDriverOnRoute(driverId, packageSlug, math.IntN(len(PredefinedRoutes)))
+}
+
+// RegisterDriverOnRoute registers the driver at the first point of the predefined route.
+// A driver that is already registered (e.g. reconnecting) keeps their status, trip and location.
+func (s *Service) RegisterDriverOnRoute(driverId string, packageSlug string, routeIndex int) (*pb.Driver, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	randomIndex := math.IntN(len(PredefinedRoutes))
-	randomRoute := PredefinedRoutes[randomIndex]
+	if existing, ok := s.drivers[driverId]; ok {
+		if existing.Driver.PackageSlug != packageSlug {
+			// replace the driver rather than mutating it, snapshots may still be read elsewhere
+			updated := proto.Clone(existing.Driver).(*pb.Driver)
+			updated.PackageSlug = packageSlug
+			existing.Driver = updated
+		}
+
+		log.Println("[Driver-Service] Driver reconnected: ", driverId, "with status:", existing.Status)
+
+		return existing.Driver, nil
+	}
+
+	randomRoute := PredefinedRoutes[routeIndex]
 
 	randomPlate := GenerateRandomPlate()
-	randomAvatar := util.GetRandomAvatar(randomIndex)
+	randomAvatar := util.GetRandomAvatar(routeIndex)
 
 	// we can ignore this property for now, but it must be sent to the frontend.
 	geohash := geohash.Encode(randomRoute[0][0], randomRoute[0][1])
@@ -77,9 +191,12 @@ func (s *Service) RegisterDriver(driverId string, packageSlug string) (*pb.Drive
 		CarPlate:       randomPlate,
 	}
 
-	s.drivers = append(s.drivers, &driverInMap{
+	s.drivers[driverId] = &driverInMap{
 		Driver: driver,
-	})
+		Status: DriverStatusAvailable,
+	}
+	s.index.Insert(driverId, driver.Location.Latitude, driver.Location.Longitude)
+	s.locations.Publish(driver, "", false)
 
 	log.Println("[Driver-Service] Driver registered: ", driver.Id, "with package:", packageSlug)
 
@@ -90,9 +207,197 @@ func (s *Service) UnregisterDriver(driverId string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for i, driver := range s.drivers {
-		if driver.Driver.Id == driverId {
-			s.drivers = append(s.drivers[:i], s.drivers[i+1:]...)
+	driver, ok := s.drivers[driverId]
+	if !ok {
+		return
+	}
+
+	delete(s.drivers, driverId)
+	s.index.Remove(driverId)
+	s.locations.Publish(driver.Driver, driver.Driver.Geohash, true)
+}
+
+// SubscribeLocations starts watching the location of a driver, or of every driver within the
+// geohash cells, and returns where the watched drivers currently are
+func (s *Service) SubscribeLocations(driverId string, cells []string) (*locationSubscription, []*pb.Driver, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.drivers[driverId]; driverId != "" && !ok {
+		return nil, nil, ErrDriverNotFound
+	}
+
+	// location changes are published with the write lock held, so none can happen until the snapshot is taken
+	sub := s.locations.Subscribe(driverId, cells)
+
+	var drivers []*pb.Driver
+	for _, driver := range s.drivers {
+		if sub.watches(driver.Driver.Id, driver.Driver.Geohash) {
+			drivers = append(drivers, driver.Driver)
 		}
 	}
+
+	return sub, drivers, nil
+}
+
+// UnsubscribeLocations stops the updates of the subscription
+func (s *Service) UnsubscribeLocations(sub *locationSubscription) {
+	s.locations.Unsubscribe(sub)
+}
+
+// Driver returns a snapshot of the driver
+func (s *Service) Driver(driverId string) (*pb.Driver, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	driver, ok := s.drivers[driverId]
+	if !ok {
+		return nil, ErrDriverNotFound
+	}
+
+	return driver.Driver, nil
+}
+
+// DriverStatus returns the status of the driver, offline when it isn't registered
+func (s *Service) DriverStatus(driverId string) DriverStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	driver, ok := s.drivers[driverId]
+	if !ok {
+		return DriverStatusOffline
+	}
+
+	return driver.Status
+}
+
+// DriverTrip returns the status of the driver along with the trip it is offered or driving for
+func (s *Service) DriverTrip(driverId string) (DriverStatus, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	driver, ok := s.drivers[driverId]
+	if !ok {
+		return DriverStatusOffline, ""
+	}
+
+	return driver.Status, driver.TripID
+}
+
+// UpdateDriverLocation moves the driver and returns a snapshot of it along with the
+// trip it is driving for and that trip's rider, if any
+func (s *Service) UpdateDriverLocation(driverId string, location *pb.Location) (*pb.Driver, string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	driver, ok := s.drivers[driverId]
+	if !ok {
+		return nil, "", "", ErrDriverNotFound
+	}
+
+	// replace the driver rather than mutating it, snapshots may still be read elsewhere
+	updated := proto.Clone(driver.Driver).(*pb.Driver)
+	updated.Location = &pb.Location{Latitude: location.Latitude, Longitude: location.Longitude}
+	updated.Geohash = geohash.Encode(location.Latitude, location.Longitude)
+
+	previousGeohash := driver.Driver.Geohash
+	driver.Driver = updated
+	s.index.Insert(driverId, location.Latitude, location.Longitude)
+	s.locations.Publish(updated, previousGeohash, false)
+
+	if !driver.Status.IsBusy() {
+		return updated, "", "", nil
+	}
+
+	return updated, driver.TripID, driver.RiderID, nil
+}
+
+// OfferTrip reserves an available driver for the trip while they answer the request,
+// so they aren't offered other trips in the meantime
+func (s *Service) OfferTrip(driverId, tripID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	driver, ok := s.drivers[driverId]
+	if !ok {
+		return ErrDriverNotFound
+	}
+
+	if driver.Status != DriverStatusAvailable {
+		return ErrDriverUnavailable
+	}
+
+	driver.Status = DriverStatusOffered
+	driver.TripID = tripID
+
+	return nil
+}
+
+// ReleaseOffer makes the driver available again if the trip is still offered to them
+func (s *Service) ReleaseOffer(driverId, tripID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	driver, ok := s.drivers[driverId]
+	if !ok || driver.Status != DriverStatusOffered || driver.TripID != tripID {
+		return
+	}
+
+	driver.Status = DriverStatusAvailable
+	driver.TripID = ""
+}
+
+// AssignTrip records the trip the driver is now driving to the pickup for. Only an available
+// driver or the one offered the trip can be assigned it, assigning it again changes nothing.
+func (s *Service) AssignTrip(driverId, tripID, riderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	driver, ok := s.drivers[driverId]
+	if !ok {
+		return ErrDriverNotFound
+	}
+
+	switch {
+	case driver.Status.IsBusy() && driver.TripID == tripID:
+		return nil
+	case driver.Status.IsBusy():
+		return fmt.Errorf("%w: driver %s is %s for trip %s", ErrDriverBusy, driverId, driver.Status, driver.TripID)
+	case driver.Status == DriverStatusOffered && driver.TripID != tripID:
+		return fmt.Errorf("%w: driver %s is offered trip %s", ErrDriverBusy, driverId, driver.TripID)
+	}
+
+	driver.Status = DriverStatusEnRoute
+	driver.TripID = tripID
+	driver.RiderID = riderID
+
+	return nil
+}
+
+// StartTrip marks the driver as driving the rider of the trip
+func (s *Service) StartTrip(driverId, tripID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	driver, ok := s.drivers[driverId]
+	if !ok || driver.TripID != tripID || !driver.Status.IsBusy() {
+		return
+	}
+
+	driver.Status = DriverStatusOnTrip
+}
+
+// ReleaseTrip makes the driver available again if it is still driving for the trip
+func (s *Service) ReleaseTrip(driverId, tripID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	driver, ok := s.drivers[driverId]
+	if !ok || driver.TripID != tripID || !driver.Status.IsBusy() {
+		return
+	}
+
+	driver.Status = DriverStatusAvailable
+	driver.TripID = ""
+	driver.RiderID = ""
 }
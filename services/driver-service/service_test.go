@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// newDriverWithStatus registers a driver and walks them to the status, for trip-1 when busy
+func newDriverWithStatus(t *testing.T, service *Service, driverID string, status DriverStatus) {
+	t.Helper()
+
+	if status == DriverStatusOffline {
+		return
+	}
+
+	if _, err := service.RegisterDriverOnRoute(driverID, "sedan", 0); err != nil {
+		t.Fatalf("RegisterDriverOnRoute: %v", err)
+	}
+
+	switch status {
+	case DriverStatusOffered:
+		if err := service.OfferTrip(driverID, "trip-1"); err != nil {
+			t.Fatalf("OfferTrip: %v", err)
+		}
+	case DriverStatusEnRoute, DriverStatusOnTrip:
+		if err := service.AssignTrip(driverID, "trip-1", "rider-1"); err != nil {
+			t.Fatalf("AssignTrip: %v", err)
+		}
+		if status == DriverStatusOnTrip {
+			service.StartTrip(driverID, "trip-1")
+		}
+	}
+
+	if got := service.DriverStatus(driverID); got != status {
+		t.Fatalf("driver is %s, want %s", got, status)
+	}
+}
+
+func TestAssignTripTransitions(t *testing.T) {
+	tests := []struct {
+		from       DriverStatus
+		tripID     string
+		wantErr    error
+		wantStatus DriverStatus
+		wantTripID string
+	}{
+		{DriverStatusOffline, "trip-1", ErrDriverNotFound, DriverStatusOffline, ""},
+		{DriverStatusAvailable, "trip-1", nil, DriverStatusEnRoute, "trip-1"},
+		{DriverStatusOffered, "trip-1", nil, DriverStatusEnRoute, "trip-1"},
+		{DriverStatusOffered, "trip-2", ErrDriverBusy, DriverStatusOffered, "trip-1"},
+		// a redelivered assignment changes nothing
+		{DriverStatusEnRoute, "trip-1", nil, DriverStatusEnRoute, "trip-1"},
+		{DriverStatusOnTrip, "trip-1", nil, DriverStatusOnTrip, "trip-1"},
+		{DriverStatusEnRoute, "trip-2", ErrDriverBusy, DriverStatusEnRoute, "trip-1"},
+		{DriverStatusOnTrip, "trip-2", ErrDriverBusy, DriverStatusOnTrip, "trip-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+tt.tripID, func(t *testing.T) {
+			service := NewService(testMatchingConfig())
+			newDriverWithStatus(t, service, "driver-1", tt.from)
+
+			if err := service.AssignTrip("driver-1", tt.tripID, "rider-1"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("AssignTrip: got %v, want %v", err, tt.wantErr)
+			}
+
+			status, tripID := service.DriverTrip("driver-1")
+			if status != tt.wantStatus || tripID != tt.wantTripID {
+				t.Errorf("driver is %s for trip %q, want %s for trip %q", status, tripID, tt.wantStatus, tt.wantTripID)
+			}
+		})
+	}
+}
+
+func TestTripLifecycleTransitions(t *testing.T) {
+	service := NewService(testMatchingConfig())
+	newDriverWithStatus(t, service, "driver-1", DriverStatusAvailable)
+
+	// starting or ending a trip the driver doesn't drive for changes nothing
+	service.StartTrip("driver-1", "trip-1")
+	service.ReleaseTrip("driver-1", "trip-1")
+	if status := service.DriverStatus("driver-1"); status != DriverStatusAvailable {
+		t.Fatalf("driver is %s, want %s", status, DriverStatusAvailable)
+	}
+
+	if err := service.OfferTrip("driver-1", "trip-1"); err != nil {
+		t.Fatalf("OfferTrip: %v", err)
+	}
+	if err := service.OfferTrip("driver-1", "trip-2"); !errors.Is(err, ErrDriverUnavailable) {
+		t.Errorf("OfferTrip to an offered driver: got %v, want %v", err, ErrDriverUnavailable)
+	}
+	// an offered driver doesn't start the trip before being assigned it
+	service.StartTrip("driver-1", "trip-1")
+	if status := service.DriverStatus("driver-1"); status != DriverStatusOffered {
+		t.Errorf("driver is %s, want %s", status, DriverStatusOffered)
+	}
+
+	if err := service.AssignTrip("driver-1", "trip-1", "rider-1"); err != nil {
+		t.Fatalf("AssignTrip: %v", err)
+	}
+	// the offer of an assigned trip isn't released anymore
+	service.ReleaseOffer("driver-1", "trip-1")
+	if status := service.DriverStatus("driver-1"); status != DriverStatusEnRoute {
+		t.Errorf("driver is %s, want %s", status, DriverStatusEnRoute)
+	}
+
+	service.StartTrip("driver-1", "trip-2")
+	service.StartTrip("driver-1", "trip-1")
+	if status := service.DriverStatus("driver-1"); status != DriverStatusOnTrip {
+		t.Errorf("driver is %s, want %s", status, DriverStatusOnTrip)
+	}
+
+	// a driver registering again keeps driving
+	if _, err := service.RegisterDriverOnRoute("driver-1", "sedan", 1); err != nil {
+		t.Fatalf("RegisterDriverOnRoute: %v", err)
+	}
+	if status := service.DriverStatus("driver-1"); status != DriverStatusOnTrip {
+		t.Errorf("driver is %s after registering again, want %s", status, DriverStatusOnTrip)
+	}
+
+	service.ReleaseTrip("driver-1", "trip-2")
+	service.ReleaseTrip("driver-1", "trip-1")
+	if status, tripID := service.DriverTrip("driver-1"); status != DriverStatusAvailable || tripID != "" {
+		t.Errorf("driver is %s for trip %q, want %s", status, tripID, DriverStatusAvailable)
+	}
+
+	service.UnregisterDriver("driver-1")
+	if status := service.DriverStatus("driver-1"); status != DriverStatusOffline {
+		t.Errorf("driver is %s, want %s", status, DriverStatusOffline)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/env"
+	pbd "ride-sharing/shared/proto/driver"
+	pb "ride-sharing/shared/proto/trip"
+	"ride-sharing/shared/util"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SimulationConfig struct {
+	Drivers     int           // simulated drivers registered on startup, none disables the simulation
+	Packages    []string      // packages the simulated drivers are spread over
+	SpeedKmh    float64       // how fast the simulated drivers drive
+	Tick        time.Duration // how often the simulated drivers move and report their location
+	AnswerDelay time.Duration // how long a simulated driver takes to accept a trip
+	StopTime    time.Duration // how long a simulated driver stays at the pickup and at the dropoff
+}
+
+// DefaultSimulationConfig returns the simulation settings, overridable through the environment
+func DefaultSimulationConfig() SimulationConfig {
+	return SimulationConfig{
+		Drivers:     env.GetInt("SIMULATION_DRIVERS", 0),
+		Packages:    strings.Split(env.GetString("SIMULATION_PACKAGES", "sedan,suv,van,luxury"), ","),
+		SpeedKmh:    env.GetFloat("SIMULATION_SPEED_KMH", 40),
+		Tick:        time.Duration(env.GetInt("SIMULATION_TICK_MS", 1000)) * time.Millisecond,
+		AnswerDelay: time.Duration(env.GetInt("SIMULATION_ANSWER_DELAY_SECONDS", 3)) * time.Second,
+		StopTime:    time.Duration(env.GetInt("SIMULATION_STOP_SECONDS", 5)) * time.Second,
+	}
+}
+
+type simPoint struct {
+	lat float64
+	lon float64
+}
+
+// simLeg is what a simulated driver is driving for
+type simLeg int
+
+const (
+	simLegIdle    simLeg = iota // cruising its predefined route, waiting for trips
+	simLegPickup                // driving to the rider
+	simLegDropoff               // driving the rider to the destination
+)
+
+type simulatedDriver struct {
+	driver   *pbd.Driver
+	location simPoint
+	route    []simPoint // predefined route cruised while idle
+	path     []simPoint // points left to drive through, the first one is the next target
+	leg      simLeg
+
+	offers    map[string]*pb.Trip // tripID -> trips offered to the driver
+	offeredAt time.Time
+	trip      *pb.Trip  // trip being driven, nil while idle
+	arrivedAt time.Time // when the driver reached the end of the leg, zero while driving
+	reported  bool      // whether the end of the leg was reported to the trip service
+}
+
+// Simulator drives simulated drivers along the predefined routes, and through the trips they are
+// offered: they accept, drive to the pickup, then along the trip route to the dropoff.
+// This lets the frontend and the matching be exercised locally without real drivers.
+type Simulator struct {
+	service   *Service
+	publisher *driverEventPublisher
+	cfg       SimulationConfig
+	drivers   map[string]*simulatedDriver // driverID -> driver
+	mu        sync.Mutex
+}
+
+func NewSimulator(service *Service, publisher *driverEventPublisher, cfg SimulationConfig) *Simulator {
+	return &Simulator{
+		service:   service,
+		publisher: publisher,
+		cfg:       cfg,
+		drivers:   make(map[string]*simulatedDriver),
+	}
+}
+
+// Run registers the simulated drivers and moves them every tick until ctx is cancelled
+func (s *Simulator) Run(ctx context.Context) error {
+	if err := s.registerDrivers(); err != nil {
+		return err
+	}
+	log.Printf("[Simulator] simulating %d drivers at %.0fkm/h", len(s.drivers), s.cfg.SpeedKmh)
+
+	ticker := time.NewTicker(s.cfg.Tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.unregisterDrivers()
+			return nil
+		case now := <-ticker.C:
+			s.tick(ctx, now)
+		}
+	}
+}
+
+// TripOffered remembers the trip offered to a simulated driver, so it can accept it
+func (s *Simulator) TripOffered(driverID string, trip *pb.Trip) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.drivers[driverID]
+	if !ok {
+		return
+	}
+
+	d.offers[trip.GetId()] = trip
+	d.offeredAt = time.Now()
+}
+
+func (s *Simulator) registerDrivers() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < s.cfg.Drivers; i++ {
+		driverID := fmt.Sprintf("sim-driver-%d", i+1)
+		packageSlug := strings.TrimSpace(s.cfg.Packages[i%len(s.cfg.Packages)])
+		routeIndex := i % len(PredefinedRoutes)
+
+		driver, err := s.service.RegisterDriverOnRoute(driverID, packageSlug, routeIndex)
+		if err != nil {
+			return fmt.Errorf("failed to register simulated driver %s: %v", driverID, err)
+		}
+
+		route := make([]simPoint, len(PredefinedRoutes[routeIndex]))
+		for j, point := range PredefinedRoutes[routeIndex] {
+			route[j] = simPoint{lat: point[0], lon: point[1]}
+		}
+
+		s.drivers[driverID] = &simulatedDriver{
+			driver:   driver,
+			location: route[0],
+			route:    route,
+			path:     route[1:],
+			offers:   make(map[string]*pb.Trip),
+		}
+	}
+
+	return nil
+}
+
+func (s *Simulator) unregisterDrivers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for driverID := range s.drivers {
+		s.service.UnregisterDriver(driverID)
+	}
+}
+
+func (s *Simulator) tick(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	distanceKm := s.cfg.SpeedKmh * s.cfg.Tick.Hours()
+
+	for driverID, d := range s.drivers {
+		status, tripID := s.service.DriverTrip(driverID)
+		s.plan(ctx, d, status, tripID, now)
+
+		if d.drive(distanceKm) {
+			s.report(ctx, d)
+		}
+	}
+}
+
+// plan follows the status of the driver, answering offers and picking the leg to drive
+func (s *Simulator) plan(ctx context.Context, d *simulatedDriver, status DriverStatus, tripID string, now time.Time) {
+	switch status {
+	case DriverStatusOffered:
+		trip, ok := d.offers[tripID]
+		if !ok || now.Sub(d.offeredAt) < s.cfg.AnswerDelay {
+			return
+		}
+
+		delete(d.offers, tripID)
+		s.respond(ctx, d, contracts.DriverCmdTripAccept, trip)
+	case DriverStatusEnRoute:
+		if d.leg == simLegPickup {
+			s.reportArrival(ctx, d, contracts.DriverCmdTripStart, now)
+			return
+		}
+
+		// only trips the simulated driver accepted itself are driven
+		if d.trip == nil || d.trip.GetId() != tripID {
+			return
+		}
+
+		pickup := tripPickup(d.trip)
+		if pickup == nil {
+			log.Printf("[Simulator] trip %s has no route, driver %s can't drive it", tripID, d.driver.Id)
+			return
+		}
+
+		d.startLeg(simLegPickup, d.trip, []simPoint{{lat: pickup.Latitude, lon: pickup.Longitude}})
+	case DriverStatusOnTrip:
+		if d.leg == simLegDropoff {
+			s.reportArrival(ctx, d, contracts.DriverCmdTripComplete, now)
+			return
+		}
+
+		if d.trip == nil || d.trip.GetId() != tripID {
+			return
+		}
+
+		d.startLeg(simLegDropoff, d.trip, tripRoute(d.trip))
+	case DriverStatusAvailable:
+		// offers that timed out or went to other drivers
+		clear(d.offers)
+
+		if d.leg != simLegIdle {
+			// the trip is over, completed or cancelled, back to the predefined route
+			d.startLeg(simLegIdle, nil, d.route)
+			return
+		}
+
+		if len(d.path) == 0 {
+			// drive the predefined route back and forth
+			reversed := make([]simPoint, len(d.route))
+			for i, point := range d.route {
+				reversed[len(d.route)-1-i] = point
+			}
+			d.route = reversed
+			d.path = reversed[1:]
+		}
+	}
+}
+
+// reportArrival tells the trip service the driver reached the end of its leg, after a short stop
+func (s *Simulator) reportArrival(ctx context.Context, d *simulatedDriver, routingKey string, now time.Time) {
+	if len(d.path) > 0 || d.reported {
+		return
+	}
+
+	if d.arrivedAt.IsZero() {
+		d.arrivedAt = now
+	}
+	if now.Sub(d.arrivedAt) < s.cfg.StopTime {
+		return
+	}
+
+	d.reported = true
+	s.respond(ctx, d, routingKey, d.trip)
+}
+
+func (s *Simulator) respond(ctx context.Context, d *simulatedDriver, routingKey string, trip *pb.Trip) {
+	if err := s.publisher.PublishTripResponse(ctx, routingKey, d.driver, trip.GetId(), trip.GetUserID()); err != nil {
+		log.Printf("[Simulator] driver %s failed to send %s for trip %s: %v", d.driver.Id, routingKey, trip.GetId(), err)
+		return
+	}
+
+	if routingKey == contracts.DriverCmdTripAccept {
+		d.trip = trip
+	}
+	log.Printf("[Simulator] driver %s sent %s for trip %s", d.driver.Id, routingKey, trip.GetId())
+}
+
+// report updates the location of the driver, notifying the rider of its trip
+func (s *Simulator) report(ctx context.Context, d *simulatedDriver) {
+	driver, tripID, riderID, err := s.service.UpdateDriverLocation(d.driver.Id, &pbd.Location{
+		Latitude:  d.location.lat,
+		Longitude: d.location.lon,
+	})
+	if err != nil {
+		log.Printf("[Simulator] failed to move driver %s: %v", d.driver.Id, err)
+		return
+	}
+	d.driver = driver
+
+	if riderID != "" {
+		if err := s.publisher.PublishDriverLocation(ctx, driver, tripID, riderID); err != nil {
+			log.Printf("[Simulator] failed to publish location of driver %s: %v", driver.Id, err)
+		}
+	}
+}
+
+func (d *simulatedDriver) startLeg(leg simLeg, trip *pb.Trip, path []simPoint) {
+	d.leg = leg
+	d.trip = trip
+	d.path = path
+	d.arrivedAt = time.Time{}
+	d.reported = false
+}
+
+// drive moves the driver up to distanceKm along its path, returning false if it had nowhere to go
+func (d *simulatedDriver) drive(distanceKm float64) bool {
+	if len(d.path) == 0 {
+		return false
+	}
+
+	current := d.location
+	for distanceKm > 0 && len(d.path) > 0 {
+		target := d.path[0]
+		remainingKm := util.HaversineDistanceKm(current.lat, current.lon, target.lat, target.lon)
+
+		if remainingKm <= distanceKm {
+			current = target
+			distanceKm -= remainingKm
+			d.path = d.path[1:]
+			continue
+		}
+
+		ratio := distanceKm / remainingKm
+		current = simPoint{
+			lat: current.lat + (target.lat-current.lat)*ratio,
+			lon: current.lon + (target.lon-current.lon)*ratio,
+		}
+		distanceKm = 0
+	}
+
+	d.location = current
+	return true
+}
+
+// tripRoute returns the points of the trip route, see tripPickup for the coordinate order
+func tripRoute(trip *pb.Trip) []simPoint {
+	var points []simPoint
+	for _, geometry := range trip.GetRoute().GetGeometry() {
+		for _, coord := range geometry.GetCoordinates() {
+			points = append(points, simPoint{lat: coord.GetLongitude(), lon: coord.GetLatitude()})
+		}
+	}
+
+	return points
+}
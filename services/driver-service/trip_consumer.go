@@ -12,18 +12,24 @@ import (
 )
 
 type tripConsumer struct {
-	rabbitmq *messaging.RabbitMQ
-	service  *Service
+	rabbitmq   *messaging.RabbitMQ
+	service    *Service
+	dispatcher *Dispatcher
 }
 
-func NewTripConsumer(rabbitmq *messaging.RabbitMQ, service *Service) *tripConsumer {
+func NewTripConsumer(rabbitmq *messaging.RabbitMQ, service *Service, dispatcher *Dispatcher) *tripConsumer {
 	return &tripConsumer{
-		rabbitmq: rabbitmq,
-		service:  service,
+		rabbitmq:   rabbitmq,
+		service:    service,
+		dispatcher: dispatcher,
 	}
 }
 
 func (c *tripConsumer) Listen(ctx context.Context) error {
+	if err := c.listenTripEvents(); err != nil {
+		return err
+	}
+
 	return c.rabbitmq.ConsumeMessages(messaging.FindAvailableDriverQueue, func(ctx context.Context, msg amqp.Delivery) error {
 		var tripEvent contracts.AmqpMessage
 		if err := json.Unmarshal(msg.Body, &tripEvent); err != nil {
@@ -40,8 +46,10 @@ func (c *tripConsumer) Listen(ctx context.Context) error {
 		log.Printf("driver service received a message: %+v", payload)
 
 		switch msg.RoutingKey {
-		case contracts.TripEventCreated, contracts.TripEventDriverNotInterested:
-			return c.handleFindAndNotifyDrivers(ctx, payload)
+		case contracts.TripEventCreated:
+			return c.dispatcher.Start(ctx, payload.Trip)
+		case contracts.TripEventDriverNotInterested:
+			return c.dispatcher.DriverNotInterested(ctx, payload.Trip, payload.DriverID)
 		}
 
 		log.Printf("unknown trip event key: %+v", payload)
@@ -50,37 +58,96 @@ func (c *tripConsumer) Listen(ctx context.Context) error {
 	})
 }
 
-func (c *tripConsumer) handleFindAndNotifyDrivers(ctx context.Context, payload messaging.TripEventData) error {
-	log.Println("[handleFindAndNotifyDrivers] Get length:", c.service.GetLength())
-	log.Println("[handleFindAndNotifyDrivers]", payload)
-	suitableDrivers := c.service.FindAvailableDrivers(payload.Trip.SelectedFare.PackageSlug)
-	log.Printf("Found suitable drivers: %d", len(suitableDrivers))
-
-	if len(suitableDrivers) == 0 {
-		// Notify the driver that no drivers are available
-		if err := c.rabbitmq.PublishMessage(ctx, contracts.TripEventNoDriversFound, contracts.AmqpMessage{
-			OwnerID: payload.Trip.UserID,
-		}); err != nil {
-			log.Printf("failed to publish no drivers found message: %v", err)
-			return err
+// listenTripEvents follows the lifecycle of the trips offered to our drivers
+func (c *tripConsumer) listenTripEvents() error {
+	return c.rabbitmq.ConsumeMessages(messaging.DriverTripEventsQueue, func(ctx context.Context, msg amqp.Delivery) error {
+		var tripEvent contracts.AmqpMessage
+		if err := json.Unmarshal(msg.Body, &tripEvent); err != nil {
+			log.Printf("failed to unmarshal message: %v", err)
+			return nil
 		}
 
+		var payload messaging.TripEventData
+		if err := json.Unmarshal(tripEvent.Data, &payload); err != nil {
+			log.Printf("failed to unmarshal message: %v", err)
+			return nil
+		}
+
+		switch msg.RoutingKey {
+		case contracts.TripEventDriverAssigned:
+			return c.handleTripAssigned(ctx, payload)
+		case contracts.TripEventStarted:
+			c.service.StartTrip(payload.Trip.GetDriver().GetId(), payload.Trip.GetId())
+			return nil
+		case contracts.TripEventCompleted:
+			c.service.ReleaseTrip(payload.Trip.GetDriver().GetId(), payload.Trip.GetId())
+			return nil
+		case contracts.TripEventCancelled, contracts.TripEventPaymentFailed:
+			return c.handleTripCancelled(ctx, payload)
+		}
+
+		log.Printf("unknown trip event key: %s", msg.RoutingKey)
+
 		return nil
+	})
+}
+
+// handleTripAssigned ends the dispatch of the trip, withdrawing the offer from another
+// driver if the offer moved on while the assignment was on its way
+func (c *tripConsumer) handleTripAssigned(ctx context.Context, payload messaging.TripEventData) error {
+	driverID := payload.Trip.GetDriver().GetId()
+
+	// the trip has a driver either way, the dispatch is over
+	assignErr := c.service.AssignTrip(driverID, payload.Trip.GetId(), payload.Trip.GetUserID())
+	if assignErr != nil {
+		assignErr = fmt.Errorf("failed to assign trip %s to driver %s: %w", payload.Trip.GetId(), driverID, assignErr)
+	}
+
+	offeredDriverID, ok := c.dispatcher.Stop(payload.Trip.GetId())
+	if ok && offeredDriverID != driverID {
+		if err := c.withdrawTrip(ctx, offeredDriverID, payload); err != nil {
+			return err
+		}
+	}
+
+	return assignErr
+}
+
+// handleTripCancelled withdraws a pending offer and releases the assigned driver of a trip
+// that ended before completion (cancelled or payment failed)
+func (c *tripConsumer) handleTripCancelled(ctx context.Context, payload messaging.TripEventData) error {
+	driverIDs := make(map[string]struct{})
+
+	if offeredDriverID, ok := c.dispatcher.Stop(payload.Trip.GetId()); ok {
+		driverIDs[offeredDriverID] = struct{}{}
+	}
+
+	if assignedDriverID := payload.Trip.GetDriver().GetId(); assignedDriverID != "" {
+		c.service.ReleaseTrip(assignedDriverID, payload.Trip.GetId())
+		driverIDs[assignedDriverID] = struct{}{}
+	}
+
+	for driverID := range driverIDs {
+		if err := c.withdrawTrip(ctx, driverID, payload); err != nil {
+			return err
+		}
 	}
 
-	suitableDriversId := suitableDrivers[0]
+	return nil
+}
 
+// withdrawTrip tells the driver to drop the trip from their screen
+func (c *tripConsumer) withdrawTrip(ctx context.Context, driverID string, payload messaging.TripEventData) error {
 	marshalledEvent, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %v", err)
 	}
 
-	// notify the driver about potential trip
-	if err := c.rabbitmq.PublishMessage(ctx, contracts.DriverCmdTripRequest, contracts.AmqpMessage{
-		OwnerID: suitableDriversId,
+	if err := c.rabbitmq.PublishMessage(ctx, contracts.DriverCmdTripCancel, contracts.AmqpMessage{
+		OwnerID: driverID,
 		Data:    marshalledEvent,
 	}); err != nil {
-		log.Printf("failed to publish trip request message: %v", err)
+		log.Printf("failed to publish trip cancel message: %v", err)
 		return err
 	}
 
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending   PaymentStatus = "pending"
+	PaymentStatusSucceeded PaymentStatus = "succeeded"
+	PaymentStatusFailed    PaymentStatus = "failed"
+	PaymentStatusCancelled PaymentStatus = "cancelled"
+)
+
+var (
+	ErrPaymentSessionNotFound = errors.New("payment session not found")
+	ErrInvalidWebhook         = errors.New("invalid payment webhook")
+	// ErrPaymentStatusConflict is returned when the session changed status since it was read
+	ErrPaymentStatusConflict = errors.New("payment session status changed concurrently")
+)
+
+// IsFinal reports whether the payment outcome is known
+func (s PaymentStatus) IsFinal() bool {
+	return s == PaymentStatusSucceeded || s == PaymentStatusFailed || s == PaymentStatusCancelled
+}
+
+type PaymentSessionModel struct {
+	ID        string // session ID given by the payment provider
+	TripID    string
+	UserID    string
+	DriverID  string
+	Amount    int64 // in cents
+	Currency  string
+	Status    PaymentStatus
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PaymentWebhookEvent is the outcome of a payment session reported by the provider
+type PaymentWebhookEvent struct {
+	SessionID string
+	Status    PaymentStatus
+}
+
+// PaymentProvider is the payment processor the sessions are created with (e.g. Stripe)
+type PaymentProvider interface {
+	// CreateSession opens a checkout session for the payment and returns its ID
+	CreateSession(ctx context.Context, session *PaymentSessionModel) (string, error)
+	// ParseWebhook verifies a webhook sent by the provider and extracts the payment outcome
+	ParseWebhook(payload []byte, signature string) (*PaymentWebhookEvent, error)
+}
+
+type PaymentRepository interface {
+	SaveSession(ctx context.Context, session *PaymentSessionModel) error
+	// UpdateSession stores the session, failing with ErrPaymentStatusConflict
+	// if the stored status is no longer the one the change was based on.
+	UpdateSession(ctx context.Context, session *PaymentSessionModel, from PaymentStatus) error
+	GetSessionByID(ctx context.Context, sessionID string) (*PaymentSessionModel, error)
+	GetSessionByTripID(ctx context.Context, tripID string) (*PaymentSessionModel, error)
+}
+
+type PaymentEventPublisher interface {
+	PublishSessionCreated(ctx context.Context, session *PaymentSessionModel) error
+	PublishPaymentStatusChanged(ctx context.Context, session *PaymentSessionModel) error
+}
+
+type PaymentService interface {
+	// CreatePaymentSession opens the payment session of a trip, or returns the existing one.
+	// The configured currency is used when currency is empty.
+	CreatePaymentSession(ctx context.Context, tripID, userID, driverID string, amount int64, currency string) (*PaymentSessionModel, error)
+	// HandleWebhook records the outcome reported by the provider and announces it
+	HandleWebhook(ctx context.Context, payload []byte, signature string) (*PaymentSessionModel, error)
+}
@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"ride-sharing/services/payment-service/internal/domain"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/messaging"
+)
+
+// paymentStatusEvents maps every payment outcome onto the event announcing it
+var paymentStatusEvents = map[domain.PaymentStatus]string{
+	domain.PaymentStatusSucceeded: contracts.PaymentEventSuccess,
+	domain.PaymentStatusFailed:    contracts.PaymentEventFailed,
+	domain.PaymentStatusCancelled: contracts.PaymentEventCancelled,
+}
+
+type PaymentEventPublisher struct {
+	rabbitmq *messaging.RabbitMQ
+}
+
+func NewPaymentEventPublisher(rabbitmq *messaging.RabbitMQ) *PaymentEventPublisher {
+	return &PaymentEventPublisher{
+		rabbitmq: rabbitmq,
+	}
+}
+
+// PublishSessionCreated sends the session to the rider so they can pay for the trip
+func (p *PaymentEventPublisher) PublishSessionCreated(ctx context.Context, session *domain.PaymentSessionModel) error {
+	marshalledEvent, err := json.Marshal(messaging.PaymentEventSessionCreatedData{
+		TripID:    session.TripID,
+		SessionID: session.ID,
+		Amount:    session.Amount,
+		Currency:  session.Currency,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	return p.rabbitmq.PublishMessage(ctx, contracts.PaymentEventSessionCreated, contracts.AmqpMessage{
+		OwnerID: session.UserID,
+		Data:    marshalledEvent,
+	})
+}
+
+func (p *PaymentEventPublisher) PublishPaymentStatusChanged(ctx context.Context, session *domain.PaymentSessionModel) error {
+	routingKey, ok := paymentStatusEvents[session.Status]
+	if !ok {
+		return fmt.Errorf("no event for payment status %q", session.Status)
+	}
+
+	marshalledEvent, err := json.Marshal(messaging.PaymentStatusUpdateData{
+		TripID:    session.TripID,
+		UserID:    session.UserID,
+		DriverID:  session.DriverID,
+		SessionID: session.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	return p.rabbitmq.PublishMessage(ctx, routingKey, contracts.AmqpMessage{
+		OwnerID: session.UserID,
+		Data:    marshalledEvent,
+	})
+}
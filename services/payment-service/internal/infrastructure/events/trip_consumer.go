@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"ride-sharing/services/payment-service/internal/domain"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/messaging"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type tripConsumer struct {
+	rabbitmq *messaging.RabbitMQ
+	service  domain.PaymentService
+}
+
+func NewTripConsumer(rabbitmq *messaging.RabbitMQ, service domain.PaymentService) *tripConsumer {
+	return &tripConsumer{
+		rabbitmq: rabbitmq,
+		service:  service,
+	}
+}
+
+func (c *tripConsumer) Listen() error {
+	return c.rabbitmq.ConsumeMessages(messaging.PaymentTripResponseQueue, func(ctx context.Context, msg amqp.Delivery) error {
+		var message contracts.AmqpMessage
+		if err := json.Unmarshal(msg.Body, &message); err != nil {
+			log.Printf("failed to unmarshal message: %v", err)
+			return nil
+		}
+
+		var payload messaging.PaymentTripResponseData
+		if err := json.Unmarshal(message.Data, &payload); err != nil {
+			log.Printf("failed to unmarshal message: %v", err)
+			return nil
+		}
+
+		switch msg.RoutingKey {
+		case contracts.PaymentCmdCreateSession:
+			if _, err := c.service.CreatePaymentSession(ctx, payload.TripID, payload.UserID, payload.DriverID, payload.Amount, payload.Currency); err != nil {
+				log.Printf("failed to create payment session: %v", err)
+				return err
+			}
+			return nil
+		}
+
+		log.Printf("unknown payment command key: %s", msg.RoutingKey)
+
+		return nil
+	})
+}
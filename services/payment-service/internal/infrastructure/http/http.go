@@ -0,0 +1,43 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"ride-sharing/services/payment-service/internal/domain"
+)
+
+// maxWebhookBodyBytes caps the size of the webhooks we read
+const maxWebhookBodyBytes = 64 << 10
+
+type HttpHandler struct {
+	Service domain.PaymentService
+}
+
+// HandleWebhook receives the payment outcomes sent by the payment provider
+func (s *HttpHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	_, err = s.Service.HandleWebhook(r.Context(), payload, r.Header.Get("Payment-Signature"))
+	switch {
+	case errors.Is(err, domain.ErrInvalidWebhook):
+		log.Printf("rejected payment webhook: %v", err)
+		http.Error(w, "invalid webhook", http.StatusBadRequest)
+		return
+	case errors.Is(err, domain.ErrPaymentSessionNotFound):
+		http.Error(w, "payment session not found", http.StatusNotFound)
+		return
+	case err != nil:
+		log.Printf("failed to handle payment webhook: %v", err)
+		http.Error(w, "failed to handle webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"ride-sharing/services/payment-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeProvider is a payment provider that doesn't charge anyone, for local development
+// and tests. Payments are settled by posting their outcome to the webhook endpoint:
+//
+//	{"sessionID": "fake_cs_...", "status": "succeeded"}
+type fakeProvider struct {
+	webhookSecret string
+}
+
+func NewFakeProvider(webhookSecret string) *fakeProvider {
+	return &fakeProvider{
+		webhookSecret: webhookSecret,
+	}
+}
+
+type fakeWebhookPayload struct {
+	SessionID string `json:"sessionID"`
+	Status    string `json:"status"`
+}
+
+func (p *fakeProvider) CreateSession(ctx context.Context, session *domain.PaymentSessionModel) (string, error) {
+	sessionID := "fake_cs_" + uuid.NewString()
+	log.Printf("[fake payment provider] session %s created for trip %s: %d %s", sessionID, session.TripID, session.Amount, session.Currency)
+
+	return sessionID, nil
+}
+
+// ParseWebhook expects the webhook secret as signature, when one is configured
+func (p *fakeProvider) ParseWebhook(payload []byte, signature string) (*domain.PaymentWebhookEvent, error) {
+	if p.webhookSecret != "" && subtle.ConstantTimeCompare([]byte(p.webhookSecret), []byte(signature)) != 1 {
+		return nil, fmt.Errorf("%w: bad signature", domain.ErrInvalidWebhook)
+	}
+
+	var webhook fakeWebhookPayload
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidWebhook, err)
+	}
+
+	if webhook.SessionID == "" {
+		return nil, fmt.Errorf("%w: missing session ID", domain.ErrInvalidWebhook)
+	}
+
+	status := domain.PaymentStatus(webhook.Status)
+	if status != domain.PaymentStatusPending && !status.IsFinal() {
+		return nil, fmt.Errorf("%w: unknown status %q", domain.ErrInvalidWebhook, webhook.Status)
+	}
+
+	return &domain.PaymentWebhookEvent{
+		SessionID: webhook.SessionID,
+		Status:    status,
+	}, nil
+}
@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"fmt"
+	"ride-sharing/services/payment-service/internal/domain"
+	"ride-sharing/services/payment-service/pkg/types"
+)
+
+// NewProvider builds the payment provider selected in the config
+func NewProvider(cfg *types.PaymentConfig) (domain.PaymentProvider, error) {
+	// anyone could settle the payments with unsigned webhooks
+	if cfg.WebhookSecret == "" && !cfg.InsecureWebhooks {
+		return nil, fmt.Errorf("a webhook secret is required unless insecure webhooks are allowed")
+	}
+
+	switch cfg.Provider {
+	case "fake":
+		return NewFakeProvider(cfg.WebhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"ride-sharing/services/payment-service/internal/domain"
+	"sync"
+)
+
+type inmemRepository struct {
+	sessions     map[string]*domain.PaymentSessionModel
+	tripSessions map[string]string // tripID -> sessionID
+	mu           sync.RWMutex
+}
+
+func NewInmemRepository() *inmemRepository {
+	return &inmemRepository{
+		sessions:     make(map[string]*domain.PaymentSessionModel),
+		tripSessions: make(map[string]string),
+	}
+}
+
+func (r *inmemRepository) SaveSession(ctx context.Context, session *domain.PaymentSessionModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *session
+	r.sessions[session.ID] = &stored
+	r.tripSessions[session.TripID] = session.ID
+
+	return nil
+}
+
+func (r *inmemRepository) UpdateSession(ctx context.Context, session *domain.PaymentSessionModel, from domain.PaymentStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.sessions[session.ID]
+	if !ok {
+		return domain.ErrPaymentSessionNotFound
+	}
+
+	if stored.Status != from {
+		return domain.ErrPaymentStatusConflict
+	}
+
+	updated := *session
+	r.sessions[session.ID] = &updated
+
+	return nil
+}
+
+func (r *inmemRepository) GetSessionByID(ctx context.Context, sessionID string) (*domain.PaymentSessionModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, domain.ErrPaymentSessionNotFound
+	}
+
+	found := *session
+	return &found, nil
+}
+
+func (r *inmemRepository) GetSessionByTripID(ctx context.Context, tripID string) (*domain.PaymentSessionModel, error) {
+	r.mu.RLock()
+	sessionID, ok := r.tripSessions[tripID]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, domain.ErrPaymentSessionNotFound
+	}
+
+	return r.GetSessionByID(ctx, sessionID)
+}
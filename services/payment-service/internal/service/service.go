@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"ride-sharing/services/payment-service/internal/domain"
+	"ride-sharing/services/payment-service/pkg/types"
+	"time"
+)
+
+type service struct {
+	repo      domain.PaymentRepository
+	provider  domain.PaymentProvider
+	publisher domain.PaymentEventPublisher
+	cfg       *types.PaymentConfig
+}
+
+func NewService(
+	repo domain.PaymentRepository,
+	provider domain.PaymentProvider,
+	publisher domain.PaymentEventPublisher,
+	cfg *types.PaymentConfig,
+) *service {
+	return &service{
+		repo:      repo,
+		provider:  provider,
+		publisher: publisher,
+		cfg:       cfg,
+	}
+}
+
+func (s *service) CreatePaymentSession(
+	ctx context.Context,
+	tripID, userID, driverID string,
+	amount int64,
+	currency string,
+) (*domain.PaymentSessionModel, error) {
+	// the command can be delivered more than once, a trip is only charged through one session
+	existing, err := s.repo.GetSessionByTripID(ctx, tripID)
+	if err == nil {
+		log.Printf("payment session %s already exists for trip %s", existing.ID, tripID)
+
+		// the rider may never have been sent the session, e.g. the publish failed the first time
+		if existing.Status == domain.PaymentStatusPending {
+			if err := s.publisher.PublishSessionCreated(ctx, existing); err != nil {
+				return nil, fmt.Errorf("failed to publish payment session %s: %v", existing.ID, err)
+			}
+		}
+
+		return existing, nil
+	}
+	if !errors.Is(err, domain.ErrPaymentSessionNotFound) {
+		return nil, err
+	}
+
+	if currency == "" {
+		currency = s.cfg.Currency
+	}
+
+	now := time.Now()
+	session := &domain.PaymentSessionModel{
+		TripID:    tripID,
+		UserID:    userID,
+		DriverID:  driverID,
+		Amount:    amount,
+		Currency:  currency,
+		Status:    domain.PaymentStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	sessionID, err := s.provider.CreateSession(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment session for trip %s: %v", tripID, err)
+	}
+	session.ID = sessionID
+
+	if err := s.repo.SaveSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	if err := s.publisher.PublishSessionCreated(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to publish payment session %s: %v", sessionID, err)
+	}
+
+	return session, nil
+}
+
+func (s *service) HandleWebhook(ctx context.Context, payload []byte, signature string) (*domain.PaymentSessionModel, error) {
+	event, err := s.provider.ParseWebhook(payload, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.repo.GetSessionByID(ctx, event.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// providers retry webhooks until acknowledged, only the first outcome counts
+	if session.Status.IsFinal() {
+		log.Printf("ignoring webhook for payment session %s: already %s", session.ID, session.Status)
+		return s.announceStatus(ctx, session)
+	}
+
+	if !event.Status.IsFinal() {
+		return session, nil
+	}
+
+	from := session.Status
+	session.Status = event.Status
+	session.UpdatedAt = time.Now()
+
+	// concurrent retries may all have read the session before it was settled, only the first one applies
+	err = s.repo.UpdateSession(ctx, session, from)
+	if errors.Is(err, domain.ErrPaymentStatusConflict) {
+		stored, err := s.repo.GetSessionByID(ctx, session.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("ignoring webhook for payment session %s: already %s", stored.ID, stored.Status)
+		return s.announceStatus(ctx, stored)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.announceStatus(ctx, session)
+}
+
+// announceStatus publishes the outcome of the session before the webhook is acknowledged.
+// A settled session is announced again on every retry of the provider, the announcement of
+// the first outcome may have failed, the trip service ignores the ones it already applied.
+func (s *service) announceStatus(ctx context.Context, session *domain.PaymentSessionModel) (*domain.PaymentSessionModel, error) {
+	if err := s.publisher.PublishPaymentStatusChanged(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to publish payment session %s status change: %v", session.ID, err)
+	}
+
+	return session, nil
+}
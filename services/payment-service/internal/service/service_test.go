@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"ride-sharing/services/payment-service/internal/domain"
+	"ride-sharing/services/payment-service/internal/infrastructure/provider"
+	"ride-sharing/services/payment-service/internal/infrastructure/repository"
+	"ride-sharing/services/payment-service/pkg/types"
+)
+
+const testWebhookSecret = "test-secret"
+
+// recordingPublisher keeps the events the service publishes, failing them while told to
+type recordingPublisher struct {
+	createdSessions []string
+	statusChanges   []domain.PaymentSessionModel
+	fail            bool
+	mu              sync.Mutex
+}
+
+func (p *recordingPublisher) PublishSessionCreated(ctx context.Context, session *domain.PaymentSessionModel) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fail {
+		return errors.New("broker unavailable")
+	}
+
+	p.createdSessions = append(p.createdSessions, session.ID)
+	return nil
+}
+
+func (p *recordingPublisher) PublishPaymentStatusChanged(ctx context.Context, session *domain.PaymentSessionModel) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fail {
+		return errors.New("broker unavailable")
+	}
+
+	p.statusChanges = append(p.statusChanges, *session)
+	return nil
+}
+
+func (p *recordingPublisher) SetFail(fail bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.fail = fail
+}
+
+func (p *recordingPublisher) CreatedSessions() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string(nil), p.createdSessions...)
+}
+
+func (p *recordingPublisher) StatusChanges() []domain.PaymentSessionModel {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]domain.PaymentSessionModel(nil), p.statusChanges...)
+}
+
+func newTestService(t *testing.T) (*service, *recordingPublisher, *domain.PaymentSessionModel) {
+	t.Helper()
+
+	svc, publisher := newTestServiceWithoutSession()
+
+	session, err := svc.CreatePaymentSession(context.Background(), "trip-1", "rider-1", "driver-1", 1250, "")
+	if err != nil {
+		t.Fatalf("CreatePaymentSession: %v", err)
+	}
+
+	return svc, publisher, session
+}
+
+func newTestServiceWithoutSession() (*service, *recordingPublisher) {
+	publisher := &recordingPublisher{}
+	svc := NewService(
+		repository.NewInmemRepository(),
+		provider.NewFakeProvider(testWebhookSecret),
+		publisher,
+		&types.PaymentConfig{Provider: "fake", Currency: "usd", WebhookSecret: testWebhookSecret},
+	)
+
+	return svc, publisher
+}
+
+func TestCreatePaymentSessionRepublishesPendingSession(t *testing.T) {
+	svc, publisher := newTestServiceWithoutSession()
+	ctx := context.Background()
+
+	publisher.SetFail(true)
+	if _, err := svc.CreatePaymentSession(ctx, "trip-1", "rider-1", "driver-1", 1250, ""); err == nil {
+		t.Fatalf("CreatePaymentSession succeeded, want the publishing error")
+	}
+
+	// the command is delivered again once the broker is back
+	publisher.SetFail(false)
+	session, err := svc.CreatePaymentSession(ctx, "trip-1", "rider-1", "driver-1", 1250, "")
+	if err != nil {
+		t.Fatalf("CreatePaymentSession retry: %v", err)
+	}
+
+	if created := publisher.CreatedSessions(); len(created) != 1 || created[0] != session.ID {
+		t.Errorf("published sessions %v, want the saved session %s", created, session.ID)
+	}
+
+	// a settled session isn't sent to the rider again
+	if _, err := svc.HandleWebhook(ctx, webhook(session.ID, domain.PaymentStatusSucceeded), testWebhookSecret); err != nil {
+		t.Fatalf("HandleWebhook: %v", err)
+	}
+	if _, err := svc.CreatePaymentSession(ctx, "trip-1", "rider-1", "driver-1", 1250, ""); err != nil {
+		t.Fatalf("CreatePaymentSession after payment: %v", err)
+	}
+	if created := publisher.CreatedSessions(); len(created) != 1 {
+		t.Errorf("published %d sessions, want 1", len(created))
+	}
+}
+
+func TestHandleWebhookRepublishesLostOutcome(t *testing.T) {
+	svc, publisher, session := newTestService(t)
+	ctx := context.Background()
+
+	publisher.SetFail(true)
+	if _, err := svc.HandleWebhook(ctx, webhook(session.ID, domain.PaymentStatusSucceeded), testWebhookSecret); err == nil {
+		t.Fatalf("HandleWebhook succeeded, want the publishing error so the provider retries")
+	}
+
+	// the provider retries the webhook once the broker is back
+	publisher.SetFail(false)
+	got, err := svc.HandleWebhook(ctx, webhook(session.ID, domain.PaymentStatusSucceeded), testWebhookSecret)
+	if err != nil {
+		t.Fatalf("HandleWebhook retry: %v", err)
+	}
+	if got.Status != domain.PaymentStatusSucceeded {
+		t.Errorf("got status %s, want %s", got.Status, domain.PaymentStatusSucceeded)
+	}
+
+	changes := publisher.StatusChanges()
+	if len(changes) != 1 || changes[0].Status != domain.PaymentStatusSucceeded {
+		t.Errorf("published status changes %+v, want the succeeded payment", changes)
+	}
+}
+
+func webhook(sessionID string, status domain.PaymentStatus) []byte {
+	return []byte(fmt.Sprintf(`{"sessionID": %q, "status": %q}`, sessionID, status))
+}
+
+func TestHandleWebhookRejectsBadSignature(t *testing.T) {
+	svc, publisher, session := newTestService(t)
+
+	_, err := svc.HandleWebhook(context.Background(), webhook(session.ID, domain.PaymentStatusSucceeded), "wrong-secret")
+	if !errors.Is(err, domain.ErrInvalidWebhook) {
+		t.Fatalf("got %v, want %v", err, domain.ErrInvalidWebhook)
+	}
+
+	if changes := publisher.StatusChanges(); len(changes) != 0 {
+		t.Errorf("published %d status changes, want none", len(changes))
+	}
+}
+
+func TestHandleWebhookUnknownSession(t *testing.T) {
+	svc, publisher, _ := newTestService(t)
+
+	_, err := svc.HandleWebhook(context.Background(), webhook("fake_cs_unknown", domain.PaymentStatusSucceeded), testWebhookSecret)
+	if !errors.Is(err, domain.ErrPaymentSessionNotFound) {
+		t.Fatalf("got %v, want %v", err, domain.ErrPaymentSessionNotFound)
+	}
+
+	if changes := publisher.StatusChanges(); len(changes) != 0 {
+		t.Errorf("published %d status changes, want none", len(changes))
+	}
+}
+
+func TestHandleWebhookIgnoresDuplicateFinalEvent(t *testing.T) {
+	svc, publisher, session := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.HandleWebhook(ctx, webhook(session.ID, domain.PaymentStatusSucceeded), testWebhookSecret); err != nil {
+		t.Fatalf("first webhook: %v", err)
+	}
+
+	// a retry, then a late event disagreeing with the first outcome
+	for _, status := range []domain.PaymentStatus{domain.PaymentStatusSucceeded, domain.PaymentStatusFailed} {
+		got, err := svc.HandleWebhook(ctx, webhook(session.ID, status), testWebhookSecret)
+		if err != nil {
+			t.Fatalf("%s webhook: %v", status, err)
+		}
+		if got.Status != domain.PaymentStatusSucceeded {
+			t.Errorf("%s webhook: got status %s, want %s", status, got.Status, domain.PaymentStatusSucceeded)
+		}
+	}
+
+	// every retry announces the first outcome again
+	changes := publisher.StatusChanges()
+	if len(changes) != 3 {
+		t.Errorf("published %d status changes, want 3", len(changes))
+	}
+	for _, change := range changes {
+		if change.Status != domain.PaymentStatusSucceeded {
+			t.Errorf("published status %s, want %s", change.Status, domain.PaymentStatusSucceeded)
+		}
+	}
+}
+
+// racingRepository holds the first reads of the sessions until as many were made,
+// so concurrent webhooks all read the session before any of them settles it
+type racingRepository struct {
+	domain.PaymentRepository
+	reads   sync.WaitGroup
+	pending atomic.Int32
+}
+
+func newRacingRepository(repo domain.PaymentRepository, readers int) *racingRepository {
+	r := &racingRepository{PaymentRepository: repo}
+	r.reads.Add(readers)
+	r.pending.Store(int32(readers))
+	return r
+}
+
+func (r *racingRepository) GetSessionByID(ctx context.Context, sessionID string) (*domain.PaymentSessionModel, error) {
+	session, err := r.PaymentRepository.GetSessionByID(ctx, sessionID)
+
+	if r.pending.Add(-1) >= 0 {
+		r.reads.Done()
+		r.reads.Wait()
+	}
+
+	return session, err
+}
+
+func TestHandleWebhookConcurrentEvents(t *testing.T) {
+	svc, publisher, session := newTestService(t)
+	ctx := context.Background()
+
+	statuses := []domain.PaymentStatus{domain.PaymentStatusSucceeded, domain.PaymentStatusFailed}
+	svc.repo = newRacingRepository(svc.repo, len(statuses))
+
+	var wg sync.WaitGroup
+	for _, status := range statuses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.HandleWebhook(ctx, webhook(session.ID, status), testWebhookSecret); err != nil {
+				t.Errorf("%s webhook: %v", status, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	changes := publisher.StatusChanges()
+	if len(changes) == 0 {
+		t.Fatalf("published no status change")
+	}
+
+	// the event that lost the race announces the outcome that won it
+	stored, err := svc.repo.GetSessionByID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("GetSessionByID: %v", err)
+	}
+	for _, change := range changes {
+		if change.Status != stored.Status {
+			t.Errorf("stored status %s, published %s", stored.Status, change.Status)
+		}
+	}
+}
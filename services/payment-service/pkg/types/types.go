@@ -0,0 +1,9 @@
+package types
+
+type PaymentConfig struct {
+	Provider      string // payment provider sessions are created with, "fake" for local development
+	Currency      string // used when the trip doesn't tell the currency of its fare
+	WebhookSecret string // shared secret the provider signs its webhooks with
+	// InsecureWebhooks accepts unsigned webhooks when no secret is set, for local development only
+	InsecureWebhooks bool
+}
@@ -2,16 +2,23 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"ride-sharing/services/trip-service/internal/domain"
 	"ride-sharing/services/trip-service/internal/infrastructure/events"
 	"ride-sharing/services/trip-service/internal/infrastructure/grpc"
 	"ride-sharing/services/trip-service/internal/infrastructure/repository"
+	"ride-sharing/services/trip-service/internal/infrastructure/routing"
 	"ride-sharing/services/trip-service/internal/service"
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+	"ride-sharing/shared/auth"
+	"ride-sharing/shared/db"
 	"ride-sharing/shared/env"
 	"ride-sharing/shared/messaging"
 
@@ -22,12 +29,15 @@ var GrpcAddr = ":9093"
 
 func main() {
 
-	inmemRepo := repository.NewInmemRepository()
-	svc := service.NewService(inmemRepo)
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	repo, closeRepo, err := newRepository(ctx, env.GetString("TRIP_REPOSITORY", "inmem"))
+	if err != nil {
+		log.Fatalf("Failed to create the trip repository: %v", err)
+	}
+	defer closeRepo()
+
 	go func() {
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
@@ -50,15 +60,48 @@ func main() {
 	defer rabbitmq.Close()
 	log.Println("Starting RabbitMQ connection")
 
+	pricingCfg, err := loadPricingConfig(env.GetString("PRICING_CONFIG_PATH", ""))
+	if err != nil {
+		log.Fatalf("Failed to load the pricing config: %v", err)
+	}
+	log.Printf("Using pricing config %s", pricingCfg.Version)
+
+	serviceKey, err := auth.LoadServiceKey()
+	if err != nil {
+		log.Fatalf("Failed to load the service key: %v", err)
+	}
+
+	driverSupply, err := grpc.NewDriverSupplyClient(env.GetString("DRIVER_SERVICE_URL", "driver-service:9092"), serviceKey)
+	if err != nil {
+		log.Fatalf("Failed to create the driver service client: %v", err)
+	}
+	defer driverSupply.Close()
+
+	routing, err := newRoutingProvider(ctx, env.GetString("ROUTING_PROVIDER", "osrm"))
+	if err != nil {
+		log.Fatalf("Failed to create the routing provider: %v", err)
+	}
+
 	publisher := events.NewTripEventPublisher(rabbitmq)
+	svc := service.NewService(repo, publisher, pricingCfg, tripTypes.DefaultSurgeConfig(), driverSupply, routing)
+
+	go purgeExpiredFares(ctx, svc, farePurgeInterval())
 
 	// setup driver consumer
-	driverConsumer := events.NewDriverConsumer(rabbitmq, svc)
+	driverConsumer := events.NewDriverConsumer(rabbitmq, svc, driverSupply)
 	go driverConsumer.Listen()
 
+	// setup payment consumer
+	paymentConsumer := events.NewPaymentConsumer(rabbitmq, svc)
+	go paymentConsumer.Listen()
+
 	// Starting the gRPC service
-	grpcServer := grpcserver.NewServer()
-	grpc.NewGRPCHandler(grpcServer, svc, publisher)
+	grpcServer := grpcserver.NewServer(
+		// the identity of the user the api-gateway calls on behalf of
+		grpcserver.ChainUnaryInterceptor(auth.UnaryServerInterceptor(serviceKey)),
+		grpcserver.ChainStreamInterceptor(auth.StreamServerInterceptor(serviceKey)),
+	)
+	grpc.NewGRPCHandler(grpcServer, svc)
 
 	log.Printf("Starting gRPC Trip Service on port %s", lis.Addr().String())
 	go func() {
@@ -74,3 +117,141 @@ func main() {
 	log.Println("Shutting down gRPC Trip Service")
 	grpcServer.GracefulStop()
 }
+
+// loadPricingConfig reads the pricing config at path, or the default one when path is empty
+func loadPricingConfig(path string) (*tripTypes.PricingConfig, error) {
+	if path == "" {
+		return tripTypes.DefaultPricingConfig(), nil
+	}
+
+	return tripTypes.LoadPricingConfig(path)
+}
+
+// farePurgeInterval reads FARE_PURGE_INTERVAL_SECONDS, falling back to the default
+// when it isn't a positive number of seconds
+func farePurgeInterval() time.Duration {
+	const defaultSeconds = 60
+
+	seconds := env.GetInt("FARE_PURGE_INTERVAL_SECONDS", defaultSeconds)
+	if seconds <= 0 {
+		log.Printf("Invalid FARE_PURGE_INTERVAL_SECONDS %d, purging expired fares every %ds", seconds, defaultSeconds)
+		seconds = defaultSeconds
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// purgeExpiredFares regularly drops the fares riders can no longer book
+func purgeExpiredFares(ctx context.Context, svc domain.TripService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := svc.PurgeExpiredFares(ctx)
+			if err != nil {
+				log.Printf("Failed to purge expired fares: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Purged %d expired fares", deleted)
+			}
+		}
+	}
+}
+
+// newRoutingProvider builds the routing provider selected by kind: "osrm" (falling back to
+// straight lines when OSRM is unavailable), "straight_line" or "fixture" (recorded routes).
+// Routes are cached unless ROUTE_CACHE_SIZE is 0, and recorded as fixtures when
+// ROUTING_RECORD_DIR is set.
+func newRoutingProvider(ctx context.Context, kind string) (domain.RoutingProvider, error) {
+	var provider domain.RoutingProvider
+
+	switch kind {
+	case "osrm":
+		// or use our self hosted API (check the course lesson: "Preparing for External API Failures")
+		provider = routing.NewOSRMProvider(
+			env.GetString("OSRM_API", "http://router.project-osrm.org"),
+			time.Duration(env.GetInt("OSRM_TIMEOUT_SECONDS", 5))*time.Second,
+		)
+	case "straight_line":
+		provider = routing.NewStraightLineProvider()
+	case "fixture":
+		provider = routing.NewFixtureProvider(env.GetString("ROUTING_FIXTURES_DIR", "fixtures/routes"))
+	default:
+		return nil, fmt.Errorf("unknown routing provider %q", kind)
+	}
+
+	if size := env.GetInt("ROUTE_CACHE_SIZE", 1000); size > 0 {
+		cache := routing.NewCachingProvider(provider, routing.CacheConfig{
+			Precision: uint(env.GetInt("ROUTE_CACHE_GEOHASH_PRECISION", 8)),
+			Size:      size,
+			TTL:       time.Duration(env.GetInt("ROUTE_CACHE_TTL_SECONDS", 300)) * time.Second,
+		})
+		go logRouteCacheStats(ctx, cache, time.Minute)
+		provider = cache
+	}
+
+	// the fallback goes around the cache, estimated routes must not be served once OSRM is back
+	if kind == "osrm" {
+		provider = routing.NewFallbackProvider(provider, routing.NewStraightLineProvider())
+	}
+
+	if dir := env.GetString("ROUTING_RECORD_DIR", ""); dir != "" {
+		provider = routing.NewRecordingProvider(provider, dir)
+	}
+	log.Printf("Using %s routing provider", kind)
+
+	return provider, nil
+}
+
+func logRouteCacheStats(ctx context.Context, cache interface{ Stats() routing.CacheStats }, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := cache.Stats()
+			log.Printf("route cache: %d hits, %d misses, %d coalesced, %d evictions, %d routes",
+				stats.Hits, stats.Misses, stats.Coalesced, stats.Evictions, stats.Size)
+		}
+	}
+}
+
+// newRepository builds the trip repository selected by kind ("inmem" or "mongo").
+// The returned function releases the resources held by the repository.
+func newRepository(ctx context.Context, kind string) (domain.TripRepository, func(), error) {
+	switch kind {
+	case "inmem":
+		return repository.NewInmemRepository(), func() {}, nil
+	case "mongo":
+		mongoCfg := db.NewMongoDefaultConfig()
+
+		mongoClient, err := db.NewMongoClient(ctx, mongoCfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		closeClient := func() {
+			if err := mongoClient.Disconnect(context.Background()); err != nil {
+				log.Printf("Failed to disconnect from MongoDB: %v", err)
+			}
+		}
+
+		mongoRepo, err := repository.NewMongoRepository(ctx, db.GetDatabase(mongoClient, mongoCfg))
+		if err != nil {
+			closeClient()
+			return nil, nil, err
+		}
+		log.Printf("Using MongoDB trip repository (database %s)", mongoCfg.Database)
+
+		return mongoRepo, closeClient, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown trip repository %q", kind)
+	}
+}
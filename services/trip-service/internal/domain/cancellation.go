@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	pb "ride-sharing/shared/proto/trip"
+)
+
+type CancellationParty string
+
+const (
+	CancelledByRider  CancellationParty = "rider"
+	CancelledByDriver CancellationParty = "driver"
+)
+
+const (
+	// Riders can cancel for free for a short while after a driver was assigned
+	CancellationGracePeriod = 2 * time.Minute
+
+	cancellationBaseFeeInCents      = 300
+	cancellationFeePerMinuteInCents = 50
+	cancellationMaxFeeInCents       = 1000
+)
+
+var ErrNotTripParticipant = errors.New("user is neither the rider nor the driver of the trip")
+
+type TripCancellation struct {
+	CancelledBy CancellationParty `bson:"cancelledBy"`
+	Reason      string            `bson:"reason"`
+	FeeInCents  float64           `bson:"feeInCents"`
+	CancelledAt time.Time         `bson:"cancelledAt"`
+}
+
+func (c *TripCancellation) ToProto() *pb.TripCancellation {
+	if c == nil {
+		return nil
+	}
+
+	return &pb.TripCancellation{
+		CancelledBy: string(c.CancelledBy),
+		Reason:      c.Reason,
+		FeeInCents:  c.FeeInCents,
+	}
+}
+
+// CancellingParty tells whether userID cancels the trip as its rider or its driver
+func (t *TripModel) CancellingParty(userID string) (CancellationParty, error) {
+	switch {
+	case userID == "":
+		return "", ErrNotTripParticipant
+	case t.UserID == userID:
+		return CancelledByRider, nil
+	case t.Driver != nil && t.Driver.Id == userID:
+		return CancelledByDriver, nil
+	}
+
+	return "", ErrNotTripParticipant
+}
+
+// CancellationFee returns what the rider owes when the trip is cancelled at the given time.
+// Only riders pay, and only once the grace period after the driver assignment is over:
+// a base fee plus a per-minute fee, capped by a maximum and by the fare itself.
+func (t *TripModel) CancellationFee(by CancellationParty, at time.Time) float64 {
+	if by != CancelledByRider || t.DriverAssignedAt.IsZero() {
+		return 0
+	}
+
+	elapsed := at.Sub(t.DriverAssignedAt)
+	if elapsed <= CancellationGracePeriod {
+		return 0
+	}
+
+	minutesLate := math.Ceil((elapsed - CancellationGracePeriod).Minutes())
+	fee := cancellationBaseFeeInCents + minutesLate*cancellationFeePerMinuteInCents
+	fee = math.Min(fee, cancellationMaxFeeInCents)
+
+	if t.RideFare != nil {
+		fee = math.Min(fee, t.RideFare.TotalPriceInCents)
+	}
+
+	return math.Round(fee)
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	pb "ride-sharing/shared/proto/trip"
+)
+
+// FareBreakdown details how the total price of a fare was computed.
+// The components add up to the total price.
+type FareBreakdown struct {
+	BaseFareInCents              float64 `bson:"baseFareInCents"`
+	DistanceFareInCents          float64 `bson:"distanceFareInCents"`
+	TimeFareInCents              float64 `bson:"timeFareInCents"`
+	MinimumFareAdjustmentInCents float64 `bson:"minimumFareAdjustmentInCents"`
+	BookingFeeInCents            float64 `bson:"bookingFeeInCents"`
+	RoundingAdjustmentInCents    float64 `bson:"roundingAdjustmentInCents"`
+	DistanceKm                   float64 `bson:"distanceKm"`
+	DurationMinutes              float64 `bson:"durationMinutes"`
+	SurgeMultiplier              float64 `bson:"surgeMultiplier"`
+	SurgeFareInCents             float64 `bson:"surgeFareInCents"` // what the surge adds to the ride
+}
+
+// Total adds the components up to the price of the fare
+func (b *FareBreakdown) Total() float64 {
+	return b.BaseFareInCents + b.DistanceFareInCents + b.TimeFareInCents +
+		b.MinimumFareAdjustmentInCents + b.SurgeFareInCents + b.BookingFeeInCents + b.RoundingAdjustmentInCents
+}
+
+func (b *FareBreakdown) ToProto() *pb.FareBreakdown {
+	if b == nil {
+		return nil
+	}
+
+	return &pb.FareBreakdown{
+		BaseFareInCents:              b.BaseFareInCents,
+		DistanceFareInCents:          b.DistanceFareInCents,
+		TimeFareInCents:              b.TimeFareInCents,
+		MinimumFareAdjustmentInCents: b.MinimumFareAdjustmentInCents,
+		BookingFeeInCents:            b.BookingFeeInCents,
+		RoundingAdjustmentInCents:    b.RoundingAdjustmentInCents,
+		DistanceKm:                   b.DistanceKm,
+		DurationMinutes:              b.DurationMinutes,
+		SurgeMultiplier:              b.SurgeMultiplier,
+		SurgeFareInCents:             b.SurgeFareInCents,
+	}
+}
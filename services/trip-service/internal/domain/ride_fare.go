@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"errors"
+	"time"
+
 	tripTypes "ride-sharing/services/trip-service/pkg/types"
 	pb "ride-sharing/shared/proto/trip"
 
@@ -8,11 +11,39 @@ import (
 )
 
 type RideFareModel struct {
-	ID                primitive.ObjectID
-	UserID            string
-	PackageSlug       string // ex: van, luxury, sedan
-	TotalPriceInCents float64
-	Route             *tripTypes.OsrmApiResponse
+	ID                primitive.ObjectID         `bson:"_id,omitempty"`
+	UserID            string                     `bson:"userID"`
+	PackageSlug       string                     `bson:"packageSlug"` // ex: van, luxury, sedan
+	TotalPriceInCents float64                    `bson:"totalPriceInCents"`
+	Breakdown         *FareBreakdown             `bson:"breakdown,omitempty"`
+	Currency          string                     `bson:"currency"`
+	PricingVersion    string                     `bson:"pricingVersion"` // version of the pricing config the fare was computed with
+	Route             *tripTypes.OsrmApiResponse `bson:"route"`
+	CreatedAt         time.Time                  `bson:"createdAt"`
+	ExpiresAt         time.Time                  `bson:"expiresAt"`
+	ConsumedAt        time.Time                  `bson:"consumedAt,omitempty"` // when a trip was booked with the fare
+}
+
+var (
+	ErrRideFareNotFound = errors.New("ride fare not found")
+	ErrRideFareNotOwned = errors.New("ride fare belongs to another user")
+	ErrRideFareExpired  = errors.New("ride fare expired")
+	ErrRideFareConsumed = errors.New("ride fare was already used to book a trip")
+)
+
+// CheckUsable tells whether userID can book a trip with the fare at the given time.
+// A fare can only be booked once, by the rider it was quoted to, before it expires.
+func (f *RideFareModel) CheckUsable(userID string, at time.Time) error {
+	switch {
+	case f.UserID != userID:
+		return ErrRideFareNotOwned
+	case !f.ConsumedAt.IsZero():
+		return ErrRideFareConsumed
+	case !at.Before(f.ExpiresAt):
+		return ErrRideFareExpired
+	}
+
+	return nil
 }
 
 func (f *RideFareModel) ToProto() *pb.RideFare {
@@ -21,6 +52,10 @@ func (f *RideFareModel) ToProto() *pb.RideFare {
 		UserID:            f.UserID,
 		PackageSlug:       f.PackageSlug,
 		TotalPriceInCents: f.TotalPriceInCents,
+		Breakdown:         f.Breakdown.ToProto(),
+		Currency:          f.Currency,
+		PricingVersion:    f.PricingVersion,
+		ExpiresAt:         f.ExpiresAt.Format(time.RFC3339),
 	}
 }
 
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+
+	pb "ride-sharing/shared/proto/trip"
+)
+
+// Surge is the multiplier applied to fares in a geohash cell where riders
+// request more trips than there are drivers available
+type Surge struct {
+	Multiplier       float64
+	Geohash          string
+	AvailableDrivers int
+	RecentTrips      int
+}
+
+// DriverSupply tells how many drivers are available to take trips
+type DriverSupply interface {
+	CountAvailableDrivers(ctx context.Context, geohash string) (int, error)
+}
+
+func (s *Surge) IsActive() bool {
+	return s != nil && s.Multiplier > 1
+}
+
+func (s *Surge) ToProto() *pb.Surge {
+	if s == nil {
+		return nil
+	}
+
+	surge := &pb.Surge{
+		Multiplier:       s.Multiplier,
+		Geohash:          s.Geohash,
+		AvailableDrivers: int32(s.AvailableDrivers),
+		RecentTrips:      int32(s.RecentTrips),
+	}
+	if s.IsActive() {
+		surge.Reason = "Fares are higher due to increased demand in your area"
+	}
+
+	return surge
+}
@@ -2,7 +2,9 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"ride-sharing/shared/types"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
@@ -12,11 +14,14 @@ import (
 )
 
 type TripModel struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty"`
-	UserID   string             `bson:"userID"`
-	Status   string             `bson:"status"`
-	RideFare *RideFareModel     `bson:"rideFare"`
-	Driver   *pb.TripDriver     `bson:"driver"`
+	ID               primitive.ObjectID `bson:"_id,omitempty"`
+	UserID           string             `bson:"userID"`
+	Status           TripStatus         `bson:"status"`
+	RideFare         *RideFareModel     `bson:"rideFare"`
+	Driver           *pb.TripDriver     `bson:"driver"`
+	DriverAssignedAt time.Time          `bson:"driverAssignedAt,omitempty"`
+	Cancellation     *TripCancellation  `bson:"cancellation,omitempty"`
+	CreatedAt        time.Time          `bson:"createdAt"`
 }
 
 func (t *TripModel) ToProto() *pb.Trip {
@@ -24,24 +29,107 @@ func (t *TripModel) ToProto() *pb.Trip {
 		Id:           t.ID.Hex(),
 		UserID:       t.UserID,
 		SelectedFare: t.RideFare.ToProto(),
-		Status:       t.Status,
+		Status:       string(t.Status),
 		Driver:       t.Driver,
 		Route:        t.RideFare.Route.ToProto(),
+		Cancellation: t.Cancellation.ToProto(),
+		CreatedAt:    formatTime(t.CreatedAt),
 	}
 }
 
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// HasParticipant tells whether userID is the rider or the driver of the trip
+func (t *TripModel) HasParticipant(userID string) bool {
+	if userID == "" {
+		return false
+	}
+
+	return t.UserID == userID || (t.Driver != nil && t.Driver.Id == userID)
+}
+
+const (
+	DefaultTripPageSize = 20
+	MaxTripPageSize     = 100
+)
+
+// ErrInvalidPageToken is returned when a listing is continued with a token it didn't hand out
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// TripQuery selects the trips of a rider or of a driver, newest first
+type TripQuery struct {
+	UserID        string
+	DriverID      string
+	Statuses      []TripStatus // any status when empty
+	CreatedAfter  time.Time    // no lower bound when zero
+	CreatedBefore time.Time    // no upper bound when zero
+	// AfterID continues the listing after the trip with this id
+	AfterID string
+	Limit   int
+}
+
+// TripPage is a page of trips and the token to fetch the next one, empty on the last page
+type TripPage struct {
+	Trips         []*TripModel
+	NextPageToken string
+}
+
 type TripRepository interface {
 	CreateTrip(ctx context.Context, trip *TripModel) (*TripModel, error)
 	SaveRideFare(ctx context.Context, fare *RideFareModel) error
 	GetRiderFareByID(ctx context.Context, fareID string) (*RideFareModel, error)
+	// ConsumeRideFare marks the fare as booked, failing with ErrRideFareExpired or
+	// ErrRideFareConsumed if it can't be booked anymore
+	ConsumeRideFare(ctx context.Context, fareID string, at time.Time) error
+	// ReleaseRideFare makes the fare bookable again if it was consumed at the given time,
+	// by a booking that couldn't be completed
+	ReleaseRideFare(ctx context.Context, fareID string, consumedAt time.Time) error
+	// DeleteExpiredRideFares removes the fares that expired before the given time
+	DeleteExpiredRideFares(ctx context.Context, before time.Time) (int64, error)
 	GetTripByID(ctx context.Context, tripID string) (*TripModel, error)
-	UpdateTrip(ctx context.Context, tripID, status string, driver *pbd.Driver) error
+	// DeleteTrip removes a trip that couldn't be booked
+	DeleteTrip(ctx context.Context, tripID string) error
+	// ListTrips returns up to query.Limit trips matching the query, newest first
+	ListTrips(ctx context.Context, query TripQuery) ([]*TripModel, error)
+	// UpdateTrip stores the trip, failing with ErrTripStatusConflict
+	// if the stored status is no longer the one the change was based on.
+	UpdateTrip(ctx context.Context, trip *TripModel, from TripStatus) error
+}
+
+var (
+	// ErrNoRoute is returned when there is no route between the pickup and the destination
+	ErrNoRoute = errors.New("no route found")
+	// ErrRoutingUnavailable is returned when the routing provider can't be reached or failed
+	ErrRoutingUnavailable = errors.New("routing provider unavailable")
+)
+
+// RoutingProvider computes the route of a trip
+type RoutingProvider interface {
+	GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error)
+}
+
+// ErrTripNotOffered is returned when a driver answers a trip that wasn't offered to them
+var ErrTripNotOffered = errors.New("trip was not offered to the driver")
+
+// DriverOffers tells which drivers a trip was offered to
+type DriverOffers interface {
+	// GetTripOffer returns the driver if the trip was offered to them, ErrTripNotOffered otherwise
+	GetTripOffer(ctx context.Context, tripID, driverID string) (*pbd.Driver, error)
 }
 
 type TripService interface {
+	// CreateTrip books a trip with the fare and announces it. The fare stays bookable if that fails.
 	CreateTrip(ctx context.Context, fare *RideFareModel) (*TripModel, error)
 	GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error)
-	EstimatePackagesPriceWithRoute(route *tripTypes.OsrmApiResponse) []*RideFareModel
+	// EstimatePackagesPriceWithRoute prices every package for the route, including the surge at the pickup.
+	// It fails with ErrNoRoute when the response has no route.
+	EstimatePackagesPriceWithRoute(ctx context.Context, route *tripTypes.OsrmApiResponse) ([]*RideFareModel, *Surge, error)
 	GenerateTripFares(
 		ctx context.Context,
 		fares []*RideFareModel,
@@ -49,6 +137,18 @@ type TripService interface {
 		route *tripTypes.OsrmApiResponse,
 	) ([]*RideFareModel, error)
 	GetAndValidateFare(ctx context.Context, fareID, userID string) (*RideFareModel, error)
+	PurgeExpiredFares(ctx context.Context) (int64, error)
 	GetTripByID(ctx context.Context, tripID string) (*TripModel, error)
-	UpdateTrip(ctx context.Context, tripId string, status string, driver *pbd.Driver) error
+	// GetTrip returns the trip if userID is its rider or its driver, ErrNotTripParticipant otherwise
+	GetTrip(ctx context.Context, tripID, userID string) (*TripModel, error)
+	// ListTrips returns a page of the trips matching the query, pageToken continues a previous listing
+	ListTrips(ctx context.Context, query TripQuery, pageToken string) (*TripPage, error)
+	// TransitionTrip applies a lifecycle transition and emits the matching trip event
+	TransitionTrip(ctx context.Context, tripID string, to TripStatus, driver *pbd.Driver) (*TripModel, error)
+	CancelTrip(ctx context.Context, tripID, userID, reason string) (*TripModel, error)
+}
+
+type TripEventPublisher interface {
+	PublishTripCreated(ctx context.Context, trip *TripModel) error
+	PublishTripStatusChanged(ctx context.Context, trip *TripModel) error
 }
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type TripStatus string
+
+const (
+	TripStatusPending        TripStatus = "pending"
+	TripStatusDriverAssigned TripStatus = "driver_assigned"
+	TripStatusDriverArriving TripStatus = "driver_arriving"
+	TripStatusInProgress     TripStatus = "in_progress"
+	TripStatusCompleted      TripStatus = "completed"
+	TripStatusCancelled      TripStatus = "cancelled"
+	TripStatusPaymentFailed  TripStatus = "payment_failed"
+)
+
+// tripTransitions lists, for every status, the statuses a trip is allowed to move to.
+// Statuses without an entry are final.
+var tripTransitions = map[TripStatus][]TripStatus{
+	TripStatusPending: {
+		TripStatusDriverAssigned,
+		TripStatusCancelled,
+	},
+	TripStatusDriverAssigned: {
+		TripStatusDriverArriving,
+		TripStatusInProgress,
+		TripStatusCancelled,
+		TripStatusPaymentFailed,
+	},
+	TripStatusDriverArriving: {
+		TripStatusInProgress,
+		TripStatusCancelled,
+		TripStatusPaymentFailed,
+	},
+	TripStatusInProgress: {
+		TripStatusCompleted,
+	},
+}
+
+var (
+	ErrTripNotFound       = errors.New("trip not found")
+	ErrUnknownTripStatus  = errors.New("unknown trip status")
+	ErrTripStatusConflict = errors.New("trip status was changed by another request")
+)
+
+// InvalidTransitionError is returned when a trip is asked to move to a status
+// that is not reachable from its current one.
+type InvalidTransitionError struct {
+	TripID string
+	From   TripStatus
+	To     TripStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("trip %s cannot transition from %q to %q", e.TripID, e.From, e.To)
+}
+
+func (s TripStatus) IsValid() bool {
+	switch s {
+	case TripStatusPending,
+		TripStatusDriverAssigned,
+		TripStatusDriverArriving,
+		TripStatusInProgress,
+		TripStatusCompleted,
+		TripStatusCancelled,
+		TripStatusPaymentFailed:
+		return true
+	}
+
+	return false
+}
+
+// IsFinal reports whether no further transitions are allowed from s
+func (s TripStatus) IsFinal() bool {
+	return len(tripTransitions[s]) == 0
+}
+
+func (s TripStatus) CanTransitionTo(next TripStatus) bool {
+	for _, allowed := range tripTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransitionTo moves the trip to the next status if the transition table allows it
+func (t *TripModel) TransitionTo(next TripStatus) error {
+	if !next.IsValid() {
+		return fmt.Errorf("%w: %q", ErrUnknownTripStatus, next)
+	}
+
+	if !t.Status.CanTransitionTo(next) {
+		return &InvalidTransitionError{
+			TripID: t.ID.Hex(),
+			From:   t.Status,
+			To:     next,
+		}
+	}
+
+	t.Status = next
+	if next == TripStatusDriverAssigned {
+		t.DriverAssignedAt = time.Now()
+	}
+
+	return nil
+}
@@ -3,12 +3,12 @@ package events
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
+	"math"
 	"ride-sharing/services/trip-service/internal/domain"
 	"ride-sharing/shared/contracts"
 	"ride-sharing/shared/messaging"
-	pbd "ride-sharing/shared/proto/driver"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -16,12 +16,14 @@ import (
 type driverConsumer struct {
 	rabbitmq *messaging.RabbitMQ
 	service  domain.TripService
+	offers   domain.DriverOffers
 }
 
-func NewDriverConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService) *driverConsumer {
+func NewDriverConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService, offers domain.DriverOffers) *driverConsumer {
 	return &driverConsumer{
 		rabbitmq: rabbitmq,
 		service:  service,
+		offers:   offers,
 	}
 }
 
@@ -43,12 +45,28 @@ func (c *driverConsumer) Listen() error {
 
 		switch msg.RoutingKey {
 		case contracts.DriverCmdTripAccept:
-			if err := c.handleTripAccept(ctx, payload.TripID, payload.Driver); err != nil {
+			if err := c.handleTripAccept(ctx, payload.TripID, message.OwnerID); err != nil {
 				log.Printf("failed to handle trip accept: %v", err)
 				return err
 			}
+			return nil
 		case contracts.DriverCmdTripDecline:
-			log.Println("handle trip decline")
+			// the gateway sets the owner to the driver connected on the websocket
+			if err := c.handleTripDecline(ctx, payload.TripID, message.OwnerID); err != nil {
+				log.Printf("failed to handle trip decline: %v", err)
+				return err
+			}
+			return nil
+		case contracts.DriverCmdTripStart, contracts.DriverCmdTripComplete:
+			to := domain.TripStatusInProgress
+			if msg.RoutingKey == contracts.DriverCmdTripComplete {
+				to = domain.TripStatusCompleted
+			}
+
+			if err := c.handleTripProgress(ctx, payload.TripID, message.OwnerID, to); err != nil {
+				log.Printf("failed to move trip %s to %s: %v", payload.TripID, to, err)
+				return err
+			}
 			return nil
 		}
 		log.Printf("unknown trip event key: %+v", payload)
@@ -57,46 +75,104 @@ func (c *driverConsumer) Listen() error {
 	})
 }
 
-func (c *driverConsumer) handleTripAccept(
-	ctx context.Context,
-	tripID string,
-	driver *pbd.Driver,
-) error {
-	// 1. Fetch the trip
-	trip, err := c.service.GetTripByID(ctx, tripID)
+// handleTripAccept assigns the driver to the trip, as long as it was offered to them.
+// The gateway sets the owner to the driver connected on the websocket.
+func (c *driverConsumer) handleTripAccept(ctx context.Context, tripID, driverID string) error {
+	// the driver details come from the driver service rather than from the driver app
+	driver, err := c.offers.GetTripOffer(ctx, tripID, driverID)
+	if errors.Is(err, domain.ErrTripNotOffered) {
+		log.Printf("ignoring trip accept from driver %s: trip %s wasn't offered to them", driverID, tripID)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	if trip == nil {
-		return fmt.Errorf("Trip was not found for ID: %s", tripID)
+	// Assigning the driver publishes trip.event.driver_assigned to notify the rider
+	trip, err := c.service.TransitionTrip(ctx, tripID, domain.TripStatusDriverAssigned, driver)
+
+	var invalidTransition *domain.InvalidTransitionError
+	if errors.As(err, &invalidTransition) || errors.Is(err, domain.ErrTripStatusConflict) {
+		// e.g. the trip was cancelled or another driver was faster, retrying won't help
+		log.Printf("ignoring trip accept from driver %s: %v", driverID, err)
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	// 2. Update the trip
-	if err := c.service.UpdateTrip(ctx, tripID, "accepted", driver); err != nil {
-		log.Printf("failed to update trip: %v", err)
+	return c.requestPaymentSession(ctx, trip)
+}
+
+// requestPaymentSession asks the payment service to charge the rider for the trip
+func (c *driverConsumer) requestPaymentSession(ctx context.Context, trip *domain.TripModel) error {
+	marshalledEvent, err := json.Marshal(messaging.PaymentTripResponseData{
+		TripID:   trip.ID.Hex(),
+		UserID:   trip.UserID,
+		DriverID: trip.Driver.GetId(),
+		Amount:   int64(math.Round(trip.RideFare.TotalPriceInCents)),
+		Currency: trip.RideFare.Currency,
+	})
+	if err != nil {
 		return err
 	}
 
-	trip, err = c.service.GetTripByID(ctx, tripID)
+	return c.rabbitmq.PublishMessage(ctx, contracts.PaymentCmdCreateSession, contracts.AmqpMessage{
+		OwnerID: trip.UserID,
+		Data:    marshalledEvent,
+	})
+}
+
+// handleTripDecline hands the trip back to the driver dispatch so the next driver gets asked
+func (c *driverConsumer) handleTripDecline(ctx context.Context, tripID, driverID string) error {
+	trip, err := c.service.GetTripByID(ctx, tripID)
 	if err != nil {
 		return err
 	}
 
-	// 3. Driver has been assigned -> publish this event to RabbitMQ
-	marshalledTrip, err := json.Marshal(trip)
+	if trip.Status != domain.TripStatusPending {
+		log.Printf("ignoring trip decline from driver %s: trip %s is %s", driverID, tripID, trip.Status)
+		return nil
+	}
+
+	marshalledEvent, err := json.Marshal(messaging.TripEventData{
+		Trip:     trip.ToProto(),
+		DriverID: driverID,
+	})
 	if err != nil {
 		return err
 	}
 
-	// notify the rider that a driver has been assigned
-	if err = c.rabbitmq.PublishMessage(ctx, contracts.TripEventDriverAssigned, contracts.AmqpMessage{
+	return c.rabbitmq.PublishMessage(ctx, contracts.TripEventDriverNotInterested, contracts.AmqpMessage{
 		OwnerID: trip.UserID,
-		Data:    marshalledTrip,
-	}); err != nil {
+		Data:    marshalledEvent,
+	})
+}
+
+// handleTripProgress moves the trip along once its driver picked the rider up or dropped them off
+func (c *driverConsumer) handleTripProgress(ctx context.Context, tripID, driverID string, to domain.TripStatus) error {
+	trip, err := c.service.GetTripByID(ctx, tripID)
+	if errors.Is(err, domain.ErrTripNotFound) {
+		log.Printf("ignoring %s of unknown trip %s", to, tripID)
+		return nil
+	}
+	if err != nil {
 		return err
 	}
 
-	// TODO: notify the payment service to start a payment link
-	return nil
+	if trip.Driver.GetId() == "" || trip.Driver.GetId() != driverID {
+		log.Printf("ignoring %s of trip %s from driver %s, who isn't driving it", to, tripID, driverID)
+		return nil
+	}
+
+	// Moving the trip publishes trip.event.started or trip.event.completed
+	_, err = c.service.TransitionTrip(ctx, tripID, to, nil)
+
+	var invalidTransition *domain.InvalidTransitionError
+	if errors.As(err, &invalidTransition) || errors.Is(err, domain.ErrTripStatusConflict) {
+		log.Printf("ignoring %s of trip %s: %v", to, tripID, err)
+		return nil
+	}
+
+	return err
 }
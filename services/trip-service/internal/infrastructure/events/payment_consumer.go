@@ -0,0 +1,70 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/messaging"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// paymentOutcomes maps every payment event onto the status the trip moves to
+var paymentOutcomes = map[string]domain.TripStatus{
+	// the driver only heads to the pickup once the ride is paid for
+	contracts.PaymentEventSuccess:   domain.TripStatusDriverArriving,
+	contracts.PaymentEventFailed:    domain.TripStatusPaymentFailed,
+	contracts.PaymentEventCancelled: domain.TripStatusPaymentFailed,
+}
+
+type paymentConsumer struct {
+	rabbitmq *messaging.RabbitMQ
+	service  domain.TripService
+}
+
+func NewPaymentConsumer(rabbitmq *messaging.RabbitMQ, service domain.TripService) *paymentConsumer {
+	return &paymentConsumer{
+		rabbitmq: rabbitmq,
+		service:  service,
+	}
+}
+
+func (c *paymentConsumer) Listen() error {
+	return c.rabbitmq.ConsumeMessages(messaging.TripPaymentEventsQueue, func(ctx context.Context, msg amqp.Delivery) error {
+		var message contracts.AmqpMessage
+		if err := json.Unmarshal(msg.Body, &message); err != nil {
+			log.Printf("failed to unmarshal message: %v", err)
+			return nil
+		}
+
+		var payload messaging.PaymentStatusUpdateData
+		if err := json.Unmarshal(message.Data, &payload); err != nil {
+			log.Printf("failed to unmarshal message: %v", err)
+			return nil
+		}
+
+		to, ok := paymentOutcomes[msg.RoutingKey]
+		if !ok {
+			log.Printf("unknown payment event key: %s", msg.RoutingKey)
+			return nil
+		}
+
+		_, err := c.service.TransitionTrip(ctx, payload.TripID, to, nil)
+
+		var invalidTransition *domain.InvalidTransitionError
+		if errors.As(err, &invalidTransition) || errors.Is(err, domain.ErrTripStatusConflict) {
+			// e.g. the trip was cancelled while the rider was paying
+			log.Printf("ignoring payment event %s for trip %s: %v", msg.RoutingKey, payload.TripID, err)
+			return nil
+		}
+		if err != nil {
+			log.Printf("failed to handle payment event: %v", err)
+			return err
+		}
+
+		return nil
+	})
+}
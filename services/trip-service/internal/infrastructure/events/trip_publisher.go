@@ -3,11 +3,22 @@ package events
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"ride-sharing/services/trip-service/internal/domain"
 	"ride-sharing/shared/contracts"
 	"ride-sharing/shared/messaging"
 )
 
+// tripStatusEvents maps every status a trip can transition to onto the event announcing it
+var tripStatusEvents = map[domain.TripStatus]string{
+	domain.TripStatusDriverAssigned: contracts.TripEventDriverAssigned,
+	domain.TripStatusDriverArriving: contracts.TripEventDriverArriving,
+	domain.TripStatusInProgress:     contracts.TripEventStarted,
+	domain.TripStatusCompleted:      contracts.TripEventCompleted,
+	domain.TripStatusCancelled:      contracts.TripEventCancelled,
+	domain.TripStatusPaymentFailed:  contracts.TripEventPaymentFailed,
+}
+
 type TripEventPublisher struct {
 	rabbitmq *messaging.RabbitMQ
 }
@@ -35,3 +46,24 @@ func (p *TripEventPublisher) PublishTripCreated(ctx context.Context, trip *domai
 	})
 
 }
+
+func (p *TripEventPublisher) PublishTripStatusChanged(ctx context.Context, trip *domain.TripModel) error {
+	routingKey, ok := tripStatusEvents[trip.Status]
+	if !ok {
+		return fmt.Errorf("no event registered for trip status %q", trip.Status)
+	}
+
+	payload := messaging.TripEventData{
+		Trip: trip.ToProto(),
+	}
+
+	tripEventJSON, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return p.rabbitmq.PublishMessage(ctx, routingKey, contracts.AmqpMessage{
+		OwnerID: trip.UserID,
+		Data:    tripEventJSON,
+	})
+}
@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"context"
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/auth"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/grpcerr"
+	pbd "ride-sharing/shared/proto/driver"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// the driver service only answers these calls to the other services
+var tripServiceIdentity = auth.ServiceIdentity("trip-service")
+
+// driverSupplyClient asks the driver service how many drivers are available and who the trips were offered to
+type driverSupplyClient struct {
+	client pbd.DriverServiceClient
+	conn   *grpc.ClientConn
+}
+
+func NewDriverSupplyClient(driverServiceURL string, serviceKey []byte) (*driverSupplyClient, error) {
+	conn, err := grpc.NewClient(driverServiceURL,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor(serviceKey)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &driverSupplyClient{
+		client: pbd.NewDriverServiceClient(conn),
+		conn:   conn,
+	}, nil
+}
+
+func (c *driverSupplyClient) CountAvailableDrivers(ctx context.Context, geohash string) (int, error) {
+	res, err := c.client.CountAvailableDrivers(auth.NewContext(ctx, tripServiceIdentity), &pbd.CountAvailableDriversRequest{
+		Geohash: geohash,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.GetCount()), nil
+}
+
+func (c *driverSupplyClient) GetTripOffer(ctx context.Context, tripID, driverID string) (*pbd.Driver, error) {
+	res, err := c.client.GetTripOffer(auth.NewContext(ctx, tripServiceIdentity), &pbd.GetTripOfferRequest{
+		TripID:   tripID,
+		DriverID: driverID,
+	})
+	if err != nil {
+		if grpcerr.Parse(err).Reason == contracts.ErrCodeTripNotOffered {
+			return nil, domain.ErrTripNotOffered
+		}
+		return nil, err
+	}
+
+	return res.GetDriver(), nil
+}
+
+func (c *driverSupplyClient) Close() {
+	if c.conn != nil {
+		if err := c.conn.Close(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/auth"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/grpcerr"
+	pb "ride-sharing/shared/proto/trip"
+
+	"google.golang.org/grpc/codes"
+)
+
+type domainError struct {
+	err    error
+	code   codes.Code
+	reason string
+}
+
+// domainErrors maps the domain errors onto the gRPC code and error code they are returned with
+var domainErrors = []domainError{
+	{domain.ErrTripNotFound, codes.NotFound, contracts.ErrCodeTripNotFound},
+	{domain.ErrNotTripParticipant, codes.PermissionDenied, contracts.ErrCodeNotTripParticipant},
+	{domain.ErrTripStatusConflict, codes.FailedPrecondition, contracts.ErrCodeInvalidTripTransition},
+	{domain.ErrRideFareNotFound, codes.NotFound, contracts.ErrCodeRideFareNotFound},
+	{domain.ErrRideFareNotOwned, codes.PermissionDenied, contracts.ErrCodeRideFareNotOwned},
+	{domain.ErrRideFareExpired, codes.FailedPrecondition, contracts.ErrCodeRideFareExpired},
+	{domain.ErrRideFareConsumed, codes.FailedPrecondition, contracts.ErrCodeRideFareConsumed},
+	{domain.ErrNoRoute, codes.NotFound, contracts.ErrCodeNoRoute},
+	{domain.ErrRoutingUnavailable, codes.Unavailable, contracts.ErrCodeRoutingUnavailable},
+	{domain.ErrInvalidPageToken, codes.InvalidArgument, contracts.ErrCodeInvalidArgument},
+	{auth.ErrUnauthenticated, codes.Unauthenticated, contracts.ErrCodeUnauthenticated},
+	{auth.ErrForbidden, codes.PermissionDenied, contracts.ErrCodePermissionDenied},
+}
+
+// toStatus returns the gRPC error for err, with the message built from format and args
+func toStatus(err error, format string, args ...any) error {
+	var invalidTransition *domain.InvalidTransitionError
+	if errors.As(err, &invalidTransition) {
+		return grpcerr.New(codes.FailedPrecondition, contracts.ErrCodeInvalidTripTransition, format, args...)
+	}
+
+	for _, d := range domainErrors {
+		if errors.Is(err, d.err) {
+			return grpcerr.New(d.code, d.reason, format, args...)
+		}
+	}
+
+	return grpcerr.New(codes.Internal, contracts.ErrCodeInternal, format, args...)
+}
+
+type requiredField struct {
+	name  string
+	value string
+}
+
+// validateRequired returns an InvalidArgument error listing the fields left empty
+func validateRequired(fields ...requiredField) error {
+	var violations []grpcerr.FieldViolation
+	for _, f := range fields {
+		if f.value == "" {
+			violations = append(violations, grpcerr.FieldViolation{
+				Field:       f.name,
+				Description: fmt.Sprintf("%s is required", f.name),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return grpcerr.InvalidArgument(contracts.ErrCodeInvalidArgument, violations...)
+	}
+
+	return nil
+}
+
+func validatePreviewTrip(req *pb.PreviewTripRequest) error {
+	var violations []grpcerr.FieldViolation
+
+	if req.GetUserId() == "" {
+		violations = append(violations, grpcerr.FieldViolation{Field: "userID", Description: "userID is required"})
+	}
+
+	locations := []struct {
+		field    string
+		location *pb.Coordinate
+	}{
+		{"pickup", req.GetStartLocation()},
+		{"destination", req.GetEndLocation()},
+	}
+
+	for _, l := range locations {
+		field, location := l.field, l.location
+		switch {
+		case location == nil:
+			violations = append(violations, grpcerr.FieldViolation{Field: field, Description: field + " is required"})
+		case location.Latitude < -90 || location.Latitude > 90 || location.Longitude < -180 || location.Longitude > 180:
+			violations = append(violations, grpcerr.FieldViolation{Field: field, Description: field + " is not a valid coordinate"})
+		}
+	}
+
+	if len(violations) > 0 {
+		return grpcerr.InvalidArgument(contracts.ErrCodeInvalidArgument, violations...)
+	}
+
+	return nil
+}
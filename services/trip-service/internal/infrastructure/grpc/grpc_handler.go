@@ -4,25 +4,21 @@ import (
 	"context"
 	"log"
 	"ride-sharing/services/trip-service/internal/domain"
-	"ride-sharing/services/trip-service/internal/infrastructure/events"
+	"ride-sharing/shared/auth"
 	pb "ride-sharing/shared/proto/trip"
 	"ride-sharing/shared/types"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type gRPCHandler struct {
 	pb.UnimplementedTripServiceServer
-	service   domain.TripService
-	publisher *events.TripEventPublisher
+	service domain.TripService
 }
 
 func NewGRPCHandler(
 	server *grpc.Server,
 	service domain.TripService,
-	publisher *events.TripEventPublisher,
 ) {
 	// handler := &gRPCHandler{
 	// 	service: service,
@@ -30,26 +26,32 @@ func NewGRPCHandler(
 
 	// pb.RegisterTripServiceServer(server, handler)
 	pb.RegisterTripServiceServer(server, &gRPCHandler{
-		service:   service,
-		publisher: publisher,
+		service: service,
 	})
 }
 
 func (h *gRPCHandler) CreateTrip(ctx context.Context, req *pb.CreateTripRequest) (*pb.CreateTripResponse, error) {
+	if err := validateRequired(
+		requiredField{"rideFareID", req.GetRideFareID()},
+		requiredField{"userID", req.GetUserID()},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, req.GetUserID(), auth.RoleRider); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
 	rideFare, err := h.service.GetAndValidateFare(ctx, req.GetRideFareID(), req.GetUserID())
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to validate the fare %s: %v", req.GetRideFareID(), err)
+		return nil, toStatus(err, "failed to validate the fare %s: %v", req.GetRideFareID(), err)
 	}
 
 	trip, err := h.service.CreateTrip(ctx, rideFare)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create trip: %v", err)
+		return nil, toStatus(err, "failed to create trip: %v", err)
 	}
-
-	if err := h.publisher.PublishTripCreated(ctx, trip); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to publish trip created event: %v", err)
-	}
-	log.Println("trip created event published")
+	log.Printf("trip %s created", trip.ID.Hex())
 
 	return &pb.CreateTripResponse{
 		TripID: trip.ID.Hex(),
@@ -60,6 +62,14 @@ func (h *gRPCHandler) PreviewTrip(ctx context.Context, req *pb.PreviewTripReques
 	pickup := req.GetStartLocation()
 	destination := req.GetEndLocation()
 
+	if err := validatePreviewTrip(req); err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, req.GetUserId(), auth.RoleRider); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
 	pickupCoord := &types.Coordinate{
 		Latitude:  pickup.Latitude,
 		Longitude: pickup.Longitude,
@@ -71,22 +81,117 @@ func (h *gRPCHandler) PreviewTrip(ctx context.Context, req *pb.PreviewTripReques
 	}
 
 	t, err := h.service.GetRoute(ctx, pickupCoord, destinationCoord)
-
 	if err != nil {
 		log.Println(err)
-		return nil, status.Errorf(codes.Internal, "failed to get route: %v", err)
+		return nil, toStatus(err, "failed to get route: %v", err)
+	}
+
+	estimatedFares, surge, err := h.service.EstimatePackagesPriceWithRoute(ctx, t)
+	if err != nil {
+		return nil, toStatus(err, "failed to estimate trip fares: %v", err)
 	}
 
-	estimatedFares := h.service.EstimatePackagesPriceWithRoute(t)
 	fares, err := h.service.GenerateTripFares(ctx, estimatedFares, req.GetUserId(), t)
 
 	if err != nil {
 		log.Println(err)
-		return nil, status.Errorf(codes.Internal, "failed to generate trip fares: %v", err)
+		return nil, toStatus(err, "failed to generate trip fares: %v", err)
 	}
 
 	return &pb.PreviewTripResponse{
 		Route:     t.ToProto(),
 		RideFares: domain.ToRideFaresProto(fares),
+		Surge:     surge.ToProto(),
+	}, nil
+}
+
+func (h *gRPCHandler) CancelTrip(ctx context.Context, req *pb.CancelTripRequest) (*pb.CancelTripResponse, error) {
+	if err := validateRequired(
+		requiredField{"tripID", req.GetTripID()},
+		requiredField{"userID", req.GetUserID()},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, req.GetUserID()); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
+	trip, err := h.service.CancelTrip(ctx, req.GetTripID(), req.GetUserID(), req.GetReason())
+	if err != nil {
+		return nil, toStatus(err, "failed to cancel trip %s: %v", req.GetTripID(), err)
+	}
+	log.Printf("trip %s cancelled by %s", trip.ID.Hex(), trip.Cancellation.CancelledBy)
+
+	return &pb.CancelTripResponse{
+		Trip: trip.ToProto(),
+	}, nil
+}
+
+func (h *gRPCHandler) GetTrip(ctx context.Context, req *pb.GetTripRequest) (*pb.GetTripResponse, error) {
+	if err := validateRequired(
+		requiredField{"tripID", req.GetTripID()},
+		requiredField{"userID", req.GetUserID()},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, req.GetUserID()); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
+	t, err := h.service.GetTrip(ctx, req.GetTripID(), req.GetUserID())
+	if err != nil {
+		return nil, toStatus(err, "failed to get trip %s: %v", req.GetTripID(), err)
+	}
+
+	return &pb.GetTripResponse{
+		Trip: t.ToProto(),
 	}, nil
 }
+
+func (h *gRPCHandler) ListTripsByUser(ctx context.Context, req *pb.ListTripsByUserRequest) (*pb.ListTripsResponse, error) {
+	if err := validateRequired(requiredField{"userID", req.GetUserID()}); err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, req.GetUserID(), auth.RoleRider); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
+	query, err := tripQuery(req.GetFilter(), req.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+	query.UserID = req.GetUserID()
+
+	page, err := h.service.ListTrips(ctx, query, req.GetPageToken())
+	if err != nil {
+		return nil, toStatus(err, "failed to list trips of user %s: %v", req.GetUserID(), err)
+	}
+
+	return toListTripsResponse(page), nil
+}
+
+func (h *gRPCHandler) ListTripsByDriver(ctx context.Context, req *pb.ListTripsByDriverRequest) (*pb.ListTripsResponse, error) {
+	if err := validateRequired(requiredField{"driverID", req.GetDriverID()}); err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(ctx, req.GetDriverID(), auth.RoleDriver); err != nil {
+		return nil, toStatus(err, "%v", err)
+	}
+
+	query, err := tripQuery(req.GetFilter(), req.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+	query.DriverID = req.GetDriverID()
+
+	page, err := h.service.ListTrips(ctx, query, req.GetPageToken())
+	if err != nil {
+		return nil, toStatus(err, "failed to list trips of driver %s: %v", req.GetDriverID(), err)
+	}
+
+	return toListTripsResponse(page), nil
+}
@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"fmt"
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/grpcerr"
+	pb "ride-sharing/shared/proto/trip"
+	"time"
+)
+
+// tripQuery builds the query of a trip listing, returning an InvalidArgument error if the filter is invalid
+func tripQuery(filter *pb.TripFilter, pageSize int32) (domain.TripQuery, error) {
+	var violations []grpcerr.FieldViolation
+	query := domain.TripQuery{Limit: int(pageSize)}
+
+	if pageSize < 0 {
+		violations = append(violations, grpcerr.FieldViolation{Field: "pageSize", Description: "pageSize can't be negative"})
+	}
+
+	for _, s := range filter.GetStatuses() {
+		status := domain.TripStatus(s)
+		if !status.IsValid() {
+			violations = append(violations, grpcerr.FieldViolation{
+				Field:       "statuses",
+				Description: fmt.Sprintf("unknown trip status %q", s),
+			})
+			continue
+		}
+		query.Statuses = append(query.Statuses, status)
+	}
+
+	times := []struct {
+		field string
+		value string
+		dest  *time.Time
+	}{
+		{"createdAfter", filter.GetCreatedAfter(), &query.CreatedAfter},
+		{"createdBefore", filter.GetCreatedBefore(), &query.CreatedBefore},
+	}
+
+	for _, t := range times {
+		if t.value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, t.value)
+		if err != nil {
+			violations = append(violations, grpcerr.FieldViolation{
+				Field:       t.field,
+				Description: fmt.Sprintf("%s must be an RFC 3339 timestamp", t.field),
+			})
+			continue
+		}
+		*t.dest = parsed
+	}
+
+	if len(violations) > 0 {
+		return query, grpcerr.InvalidArgument(contracts.ErrCodeInvalidArgument, violations...)
+	}
+
+	return query, nil
+}
+
+func toListTripsResponse(page *domain.TripPage) *pb.ListTripsResponse {
+	trips := make([]*pb.Trip, len(page.Trips))
+	for i, t := range page.Trips {
+		trips[i] = t.ToProto()
+	}
+
+	return &pb.ListTripsResponse{
+		Trips:         trips,
+		NextPageToken: page.NextPageToken,
+	}
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"ride-sharing/services/trip-service/internal/domain"
-	pbd "ride-sharing/shared/proto/driver"
-	pb "ride-sharing/shared/proto/trip"
+	"slices"
+	"sort"
+	"sync"
+	"time"
 )
 
 type inmemRepository struct {
 	trips     map[string]*domain.TripModel
 	rideFares map[string]*domain.RideFareModel
+	mu        sync.RWMutex
 }
 
 func NewInmemRepository() *inmemRepository {
@@ -21,46 +24,173 @@ func NewInmemRepository() *inmemRepository {
 }
 
 func (r *inmemRepository) CreateTrip(ctx context.Context, trip *domain.TripModel) (*domain.TripModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.trips[trip.ID.Hex()] = trip
 	return trip, nil
 }
 
 func (r *inmemRepository) SaveRideFare(ctx context.Context, fare *domain.RideFareModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.rideFares[fare.ID.Hex()] = fare
 	return nil
 }
 
 func (r *inmemRepository) GetRiderFareByID(ctx context.Context, fareID string) (*domain.RideFareModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	rideFare, ok := r.rideFares[fareID]
 	if !ok {
-		return nil, fmt.Errorf("ride fare with id %s not found", fareID)
+		return nil, fmt.Errorf("ride fare with id %s: %w", fareID, domain.ErrRideFareNotFound)
 	}
-	return rideFare, nil
+
+	// return a copy so callers can't book the stored fare without ConsumeRideFare
+	fareCopy := *rideFare
+	return &fareCopy, nil
 }
 
-func (r *inmemRepository) GetTripByID(ctx context.Context, tripID string) (*domain.TripModel, error) {
-	trip, ok := r.trips[tripID]
+func (r *inmemRepository) ConsumeRideFare(ctx context.Context, fareID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rideFare, ok := r.rideFares[fareID]
 	if !ok {
-		return nil, fmt.Errorf("trip with id %s not found", tripID)
+		return fmt.Errorf("ride fare with id %s: %w", fareID, domain.ErrRideFareNotFound)
 	}
-	return trip, nil
+
+	if err := rideFare.CheckUsable(rideFare.UserID, at); err != nil {
+		return err
+	}
+
+	rideFare.ConsumedAt = at
+	return nil
 }
 
-func (r *inmemRepository) UpdateTrip(ctx context.Context, tripID, status string, driver *pbd.Driver) error {
+func (r *inmemRepository) ReleaseRideFare(ctx context.Context, fareID string, consumedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rideFare, ok := r.rideFares[fareID]
+	if !ok {
+		return fmt.Errorf("ride fare with id %s: %w", fareID, domain.ErrRideFareNotFound)
+	}
+
+	if rideFare.ConsumedAt.Equal(consumedAt) {
+		rideFare.ConsumedAt = time.Time{}
+	}
+
+	return nil
+}
+
+func (r *inmemRepository) DeleteExpiredRideFares(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, rideFare := range r.rideFares {
+		if rideFare.ExpiresAt.Before(before) {
+			delete(r.rideFares, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *inmemRepository) GetTripByID(ctx context.Context, tripID string) (*domain.TripModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	trip, ok := r.trips[tripID]
 	if !ok {
-		return fmt.Errorf("trip with id %s not found", tripID)
+		return nil, fmt.Errorf("trip with id %s: %w", tripID, domain.ErrTripNotFound)
 	}
 
-	trip.Status = status
-	if trip.Driver != nil {
-		trip.Driver = &pb.TripDriver{
-			Id:             driver.Id,
-			Name:           driver.Name,
-			CarPlate:       driver.CarPlate,
-			ProfilePicture: driver.ProfilePicture,
+	// return a copy so callers can't change the stored trip without UpdateTrip
+	tripCopy := *trip
+	return &tripCopy, nil
+}
+
+func (r *inmemRepository) DeleteTrip(ctx context.Context, tripID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.trips[tripID]; !ok {
+		return fmt.Errorf("trip with id %s: %w", tripID, domain.ErrTripNotFound)
+	}
+
+	delete(r.trips, tripID)
+	return nil
+}
+
+func (r *inmemRepository) ListTrips(ctx context.Context, query domain.TripQuery) ([]*domain.TripModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var trips []*domain.TripModel
+	for id, trip := range r.trips {
+		if query.AfterID != "" && id >= query.AfterID {
+			continue
+		}
+		if !matchesTripQuery(trip, query) {
+			continue
 		}
+
+		tripCopy := *trip
+		trips = append(trips, &tripCopy)
+	}
+
+	// object ids grow with their creation time, so the newest trips have the greatest ones
+	sort.Slice(trips, func(i, j int) bool {
+		return trips[i].ID.Hex() > trips[j].ID.Hex()
+	})
+
+	if len(trips) > query.Limit {
+		trips = trips[:query.Limit]
 	}
 
+	return trips, nil
+}
+
+func matchesTripQuery(trip *domain.TripModel, query domain.TripQuery) bool {
+	if query.UserID != "" && trip.UserID != query.UserID {
+		return false
+	}
+	if query.DriverID != "" && (trip.Driver == nil || trip.Driver.Id != query.DriverID) {
+		return false
+	}
+	if len(query.Statuses) > 0 && !slices.Contains(query.Statuses, trip.Status) {
+		return false
+	}
+	if !query.CreatedAfter.IsZero() && !trip.CreatedAt.After(query.CreatedAfter) {
+		return false
+	}
+	if !query.CreatedBefore.IsZero() && !trip.CreatedAt.Before(query.CreatedBefore) {
+		return false
+	}
+
+	return true
+}
+
+func (r *inmemRepository) UpdateTrip(ctx context.Context, trip *domain.TripModel, from domain.TripStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.trips[trip.ID.Hex()]
+	if !ok {
+		return fmt.Errorf("trip with id %s: %w", trip.ID.Hex(), domain.ErrTripNotFound)
+	}
+
+	if stored.Status != from {
+		return domain.ErrTripStatusConflict
+	}
+
+	tripCopy := *trip
+	r.trips[trip.ID.Hex()] = &tripCopy
+
 	return nil
 }
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ride-sharing/services/trip-service/internal/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	TripsCollection     = "trips"
+	RideFaresCollection = "ride_fares"
+
+	// Fares are only useful while the rider decides which package to book.
+	// Booked fares are embedded in the trip document, so dropping them is safe.
+	// Expired fares are purged long before, this only catches the ones that never got an expiry.
+	rideFareTTL = 24 * time.Hour
+)
+
+type mongoRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoRepository(ctx context.Context, db *mongo.Database) (*mongoRepository, error) {
+	r := &mongoRepository{
+		db: db,
+	}
+
+	if err := r.createIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *mongoRepository) createIndexes(ctx context.Context) error {
+	if _, err := r.db.Collection(TripsCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "driver.id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+	}); err != nil {
+		return fmt.Errorf("failed to create %s indexes: %v", TripsCollection, err)
+	}
+
+	if _, err := r.db.Collection(RideFaresCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userID", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(rideFareTTL.Seconds())),
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to create %s indexes: %v", RideFaresCollection, err)
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) CreateTrip(ctx context.Context, trip *domain.TripModel) (*domain.TripModel, error) {
+	if _, err := r.db.Collection(TripsCollection).InsertOne(ctx, trip); err != nil {
+		return nil, fmt.Errorf("failed to insert trip: %v", err)
+	}
+
+	return trip, nil
+}
+
+func (r *mongoRepository) SaveRideFare(ctx context.Context, fare *domain.RideFareModel) error {
+	if _, err := r.db.Collection(RideFaresCollection).InsertOne(ctx, fare); err != nil {
+		return fmt.Errorf("failed to insert ride fare: %v", err)
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) GetRiderFareByID(ctx context.Context, fareID string) (*domain.RideFareModel, error) {
+	id, err := primitive.ObjectIDFromHex(fareID)
+	if err != nil {
+		return nil, fmt.Errorf("ride fare with id %s: %w", fareID, domain.ErrRideFareNotFound)
+	}
+
+	var fare domain.RideFareModel
+	err = r.db.Collection(RideFaresCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&fare)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("ride fare with id %s: %w", fareID, domain.ErrRideFareNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ride fare: %v", err)
+	}
+
+	return &fare, nil
+}
+
+func (r *mongoRepository) ConsumeRideFare(ctx context.Context, fareID string, at time.Time) error {
+	id, err := primitive.ObjectIDFromHex(fareID)
+	if err != nil {
+		return fmt.Errorf("ride fare with id %s: %w", fareID, domain.ErrRideFareNotFound)
+	}
+
+	// only book the fare if nobody booked it yet and it didn't expire
+	result, err := r.db.Collection(RideFaresCollection).UpdateOne(ctx,
+		bson.M{
+			"_id":        id,
+			"consumedAt": bson.M{"$exists": false},
+			"expiresAt":  bson.M{"$gt": at},
+		},
+		bson.M{"$set": bson.M{"consumedAt": at}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to consume ride fare: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		fare, err := r.GetRiderFareByID(ctx, fareID)
+		if err != nil {
+			return err
+		}
+
+		if err := fare.CheckUsable(fare.UserID, at); err != nil {
+			return err
+		}
+
+		return domain.ErrRideFareConsumed
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) ReleaseRideFare(ctx context.Context, fareID string, consumedAt time.Time) error {
+	id, err := primitive.ObjectIDFromHex(fareID)
+	if err != nil {
+		return fmt.Errorf("ride fare with id %s: %w", fareID, domain.ErrRideFareNotFound)
+	}
+
+	// only undo the booking that failed, not a later one
+	if _, err := r.db.Collection(RideFaresCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "consumedAt": consumedAt},
+		bson.M{"$unset": bson.M{"consumedAt": ""}},
+	); err != nil {
+		return fmt.Errorf("failed to release ride fare: %v", err)
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) DeleteExpiredRideFares(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Collection(RideFaresCollection).DeleteMany(ctx, bson.M{"expiresAt": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired ride fares: %v", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+func (r *mongoRepository) GetTripByID(ctx context.Context, tripID string) (*domain.TripModel, error) {
+	id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return nil, fmt.Errorf("trip with id %s: %w", tripID, domain.ErrTripNotFound)
+	}
+
+	var trip domain.TripModel
+	err = r.db.Collection(TripsCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&trip)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("trip with id %s: %w", tripID, domain.ErrTripNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find trip: %v", err)
+	}
+
+	return &trip, nil
+}
+
+func (r *mongoRepository) DeleteTrip(ctx context.Context, tripID string) error {
+	id, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		return fmt.Errorf("trip with id %s: %w", tripID, domain.ErrTripNotFound)
+	}
+
+	result, err := r.db.Collection(TripsCollection).DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete trip: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("trip with id %s: %w", tripID, domain.ErrTripNotFound)
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) ListTrips(ctx context.Context, query domain.TripQuery) ([]*domain.TripModel, error) {
+	filter := bson.M{}
+	if query.UserID != "" {
+		filter["userID"] = query.UserID
+	}
+	if query.DriverID != "" {
+		filter["driver.id"] = query.DriverID
+	}
+	if len(query.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": query.Statuses}
+	}
+
+	createdAt := bson.M{}
+	if !query.CreatedAfter.IsZero() {
+		createdAt["$gt"] = query.CreatedAfter
+	}
+	if !query.CreatedBefore.IsZero() {
+		createdAt["$lt"] = query.CreatedBefore
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	if query.AfterID != "" {
+		afterID, err := primitive.ObjectIDFromHex(query.AfterID)
+		if err != nil {
+			return nil, domain.ErrInvalidPageToken
+		}
+		filter["_id"] = bson.M{"$lt": afterID}
+	}
+
+	// object ids grow with their creation time, so the newest trips have the greatest ones
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(query.Limit))
+
+	cursor, err := r.db.Collection(TripsCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find trips: %v", err)
+	}
+
+	var trips []*domain.TripModel
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, fmt.Errorf("failed to decode trips: %v", err)
+	}
+
+	return trips, nil
+}
+
+func (r *mongoRepository) UpdateTrip(ctx context.Context, trip *domain.TripModel, from domain.TripStatus) error {
+	collection := r.db.Collection(TripsCollection)
+
+	// only replace the trip if nobody moved it away from the status the change was based on
+	result, err := collection.ReplaceOne(ctx, bson.M{"_id": trip.ID, "status": from}, trip)
+	if err != nil {
+		return fmt.Errorf("failed to update trip: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		count, err := collection.CountDocuments(ctx, bson.M{"_id": trip.ID})
+		if err != nil {
+			return fmt.Errorf("failed to update trip: %v", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("trip with id %s: %w", trip.ID.Hex(), domain.ErrTripNotFound)
+		}
+
+		return domain.ErrTripStatusConflict
+	}
+
+	return nil
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/db"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the same contract is checked against every TripRepository implementation
+
+func TestInmemRepository(t *testing.T) {
+	testTripRepository(t, func(t *testing.T) domain.TripRepository {
+		return NewInmemRepository()
+	})
+}
+
+func TestMongoRepository(t *testing.T) {
+	if os.Getenv("MONGODB_URI") == "" {
+		t.Skip("MONGODB_URI is not set")
+	}
+
+	testTripRepository(t, func(t *testing.T) domain.TripRepository {
+		ctx := context.Background()
+
+		cfg := db.NewMongoDefaultConfig()
+		client, err := db.NewMongoClient(ctx, cfg)
+		if err != nil {
+			t.Fatalf("failed to connect to MongoDB: %v", err)
+		}
+
+		// every test gets its own database, dropped once it's done
+		database := client.Database(fmt.Sprintf("trip-repository-test-%s", primitive.NewObjectID().Hex()))
+		t.Cleanup(func() {
+			database.Drop(context.Background())
+			client.Disconnect(context.Background())
+		})
+
+		repo, err := NewMongoRepository(ctx, database)
+		if err != nil {
+			t.Fatalf("failed to create the repository: %v", err)
+		}
+
+		return repo
+	})
+}
+
+func testTripRepository(t *testing.T, newRepo func(t *testing.T) domain.TripRepository) {
+	t.Run("create and get trip", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+
+		trip := newTestTrip("rider-1", time.Now())
+		if _, err := repo.CreateTrip(ctx, trip); err != nil {
+			t.Fatalf("CreateTrip: %v", err)
+		}
+
+		got, err := repo.GetTripByID(ctx, trip.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetTripByID: %v", err)
+		}
+		if got.ID != trip.ID || got.UserID != "rider-1" || got.Status != domain.TripStatusPending {
+			t.Errorf("got trip %+v, want %+v", got, trip)
+		}
+		if got.RideFare == nil || got.RideFare.PackageSlug != "sedan" {
+			t.Errorf("got ride fare %+v, want the one of the trip", got.RideFare)
+		}
+
+		_, err = repo.GetTripByID(ctx, primitive.NewObjectID().Hex())
+		if !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("GetTripByID of an unknown trip: got %v, want %v", err, domain.ErrTripNotFound)
+		}
+	})
+
+	t.Run("update trip checks the stored status", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+
+		trip := newTestTrip("rider-1", time.Now())
+		if _, err := repo.CreateTrip(ctx, trip); err != nil {
+			t.Fatalf("CreateTrip: %v", err)
+		}
+
+		assigned := *trip
+		assigned.Status = domain.TripStatusDriverAssigned
+		if err := repo.UpdateTrip(ctx, &assigned, domain.TripStatusPending); err != nil {
+			t.Fatalf("UpdateTrip: %v", err)
+		}
+
+		// a concurrent change based on the old status must not win
+		cancelled := *trip
+		cancelled.Status = domain.TripStatusCancelled
+		if err := repo.UpdateTrip(ctx, &cancelled, domain.TripStatusPending); !errors.Is(err, domain.ErrTripStatusConflict) {
+			t.Errorf("UpdateTrip from a stale status: got %v, want %v", err, domain.ErrTripStatusConflict)
+		}
+
+		got, err := repo.GetTripByID(ctx, trip.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetTripByID: %v", err)
+		}
+		if got.Status != domain.TripStatusDriverAssigned {
+			t.Errorf("got status %s, want %s", got.Status, domain.TripStatusDriverAssigned)
+		}
+
+		unknown := newTestTrip("rider-1", time.Now())
+		if err := repo.UpdateTrip(ctx, unknown, domain.TripStatusPending); !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("UpdateTrip of an unknown trip: got %v, want %v", err, domain.ErrTripNotFound)
+		}
+	})
+
+	t.Run("ride fare can only be consumed once", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+
+		now := time.Now()
+		fare := newTestRideFare("rider-1", now)
+		if err := repo.SaveRideFare(ctx, fare); err != nil {
+			t.Fatalf("SaveRideFare: %v", err)
+		}
+
+		if err := repo.ConsumeRideFare(ctx, fare.ID.Hex(), now); err != nil {
+			t.Fatalf("ConsumeRideFare: %v", err)
+		}
+		if err := repo.ConsumeRideFare(ctx, fare.ID.Hex(), now); !errors.Is(err, domain.ErrRideFareConsumed) {
+			t.Errorf("second ConsumeRideFare: got %v, want %v", err, domain.ErrRideFareConsumed)
+		}
+
+		got, err := repo.GetRiderFareByID(ctx, fare.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetRiderFareByID: %v", err)
+		}
+		if got.ConsumedAt.IsZero() {
+			t.Errorf("consumed fare has no ConsumedAt")
+		}
+	})
+
+	t.Run("released ride fare can be booked again", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+
+		now := time.Now().Truncate(time.Millisecond)
+		fare := newTestRideFare("rider-1", now)
+		if err := repo.SaveRideFare(ctx, fare); err != nil {
+			t.Fatalf("SaveRideFare: %v", err)
+		}
+
+		if err := repo.ConsumeRideFare(ctx, fare.ID.Hex(), now); err != nil {
+			t.Fatalf("ConsumeRideFare: %v", err)
+		}
+
+		// releasing another booking leaves this one alone
+		if err := repo.ReleaseRideFare(ctx, fare.ID.Hex(), now.Add(-time.Second)); err != nil {
+			t.Fatalf("ReleaseRideFare: %v", err)
+		}
+		if err := repo.ConsumeRideFare(ctx, fare.ID.Hex(), now); !errors.Is(err, domain.ErrRideFareConsumed) {
+			t.Fatalf("ConsumeRideFare after releasing another booking: got %v, want %v", err, domain.ErrRideFareConsumed)
+		}
+
+		if err := repo.ReleaseRideFare(ctx, fare.ID.Hex(), now); err != nil {
+			t.Fatalf("ReleaseRideFare: %v", err)
+		}
+		if err := repo.ConsumeRideFare(ctx, fare.ID.Hex(), now.Add(time.Second)); err != nil {
+			t.Errorf("ConsumeRideFare after release: %v", err)
+		}
+	})
+
+	t.Run("delete trip", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+
+		trip := newTestTrip("rider-1", time.Now())
+		if _, err := repo.CreateTrip(ctx, trip); err != nil {
+			t.Fatalf("CreateTrip: %v", err)
+		}
+
+		if err := repo.DeleteTrip(ctx, trip.ID.Hex()); err != nil {
+			t.Fatalf("DeleteTrip: %v", err)
+		}
+		if _, err := repo.GetTripByID(ctx, trip.ID.Hex()); !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("GetTripByID after delete: got %v, want %v", err, domain.ErrTripNotFound)
+		}
+		if err := repo.DeleteTrip(ctx, trip.ID.Hex()); !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("second DeleteTrip: got %v, want %v", err, domain.ErrTripNotFound)
+		}
+	})
+
+	t.Run("expired ride fare can't be consumed", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+
+		now := time.Now()
+		fare := newTestRideFare("rider-1", now)
+		if err := repo.SaveRideFare(ctx, fare); err != nil {
+			t.Fatalf("SaveRideFare: %v", err)
+		}
+
+		if err := repo.ConsumeRideFare(ctx, fare.ID.Hex(), fare.ExpiresAt.Add(time.Second)); !errors.Is(err, domain.ErrRideFareExpired) {
+			t.Errorf("ConsumeRideFare after expiry: got %v, want %v", err, domain.ErrRideFareExpired)
+		}
+	})
+
+	t.Run("list trips by pages", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+
+		start := time.Now().Add(-time.Hour)
+		var trips []*domain.TripModel
+		for i := range 5 {
+			trip := newTestTrip("rider-1", start.Add(time.Duration(i)*time.Minute))
+			if _, err := repo.CreateTrip(ctx, trip); err != nil {
+				t.Fatalf("CreateTrip: %v", err)
+			}
+			trips = append(trips, trip)
+		}
+		if _, err := repo.CreateTrip(ctx, newTestTrip("rider-2", start)); err != nil {
+			t.Fatalf("CreateTrip: %v", err)
+		}
+
+		// newest first, continuing after the last trip of the previous page
+		want := [][]*domain.TripModel{
+			{trips[4], trips[3]},
+			{trips[2], trips[1]},
+			{trips[0]},
+		}
+
+		query := domain.TripQuery{UserID: "rider-1", Limit: 2}
+		for page, wantTrips := range want {
+			got, err := repo.ListTrips(ctx, query)
+			if err != nil {
+				t.Fatalf("ListTrips page %d: %v", page, err)
+			}
+
+			if len(got) != len(wantTrips) {
+				t.Fatalf("page %d: got %d trips, want %d", page, len(got), len(wantTrips))
+			}
+			for i := range got {
+				if got[i].ID != wantTrips[i].ID {
+					t.Errorf("page %d trip %d: got %s, want %s", page, i, got[i].ID.Hex(), wantTrips[i].ID.Hex())
+				}
+			}
+
+			query.AfterID = got[len(got)-1].ID.Hex()
+		}
+
+		got, err := repo.ListTrips(ctx, query)
+		if err != nil {
+			t.Fatalf("ListTrips past the last page: %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("past the last page: got %d trips, want none", len(got))
+		}
+	})
+}
+
+func newTestRideFare(userID string, now time.Time) *domain.RideFareModel {
+	return &domain.RideFareModel{
+		ID:                primitive.NewObjectIDFromTimestamp(now),
+		UserID:            userID,
+		PackageSlug:       "sedan",
+		TotalPriceInCents: 1250,
+		Currency:          "usd",
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(10 * time.Minute),
+	}
+}
+
+func newTestTrip(userID string, createdAt time.Time) *domain.TripModel {
+	return &domain.TripModel{
+		ID:        primitive.NewObjectIDFromTimestamp(createdAt),
+		UserID:    userID,
+		Status:    domain.TripStatusPending,
+		RideFare:  newTestRideFare(userID, createdAt),
+		CreatedAt: createdAt.Truncate(time.Millisecond), // the precision MongoDB keeps
+	}
+}
@@ -0,0 +1,157 @@
+package routing
+
+import (
+	"container/list"
+	"context"
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/types"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmcloughlin/geohash"
+	"golang.org/x/sync/singleflight"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+type CacheConfig struct {
+	Precision uint // geohash precision the pickup and destination are rounded to
+	Size      int  // maximum number of routes kept, the least recently used are evicted
+	TTL       time.Duration
+}
+
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Coalesced uint64 // requests that waited for an identical request in flight
+	Evictions uint64
+	Size      int
+}
+
+type cacheEntry struct {
+	key       string
+	route     *tripTypes.OsrmApiResponse
+	expiresAt time.Time
+}
+
+// cachingProvider keeps the routes found by the wrapped provider, so riders previewing
+// the same trip again don't cost an upstream call. Concurrent requests for the same
+// route share a single upstream call. Cached routes are shared, callers must not modify them.
+type cachingProvider struct {
+	next    domain.RoutingProvider
+	cfg     CacheConfig
+	entries map[string]*list.Element
+	lru     *list.List // most recently used first
+	group   singleflight.Group
+	mu      sync.Mutex
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	coalesced atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func NewCachingProvider(next domain.RoutingProvider, cfg CacheConfig) *cachingProvider {
+	return &cachingProvider{
+		next:    next,
+		cfg:     cfg,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (p *cachingProvider) GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	key := geohash.EncodeWithPrecision(pickup.Latitude, pickup.Longitude, p.cfg.Precision) + ":" +
+		geohash.EncodeWithPrecision(destination.Latitude, destination.Longitude, p.cfg.Precision)
+
+	if route, ok := p.get(key); ok {
+		p.hits.Add(1)
+		return route, nil
+	}
+	p.misses.Add(1)
+
+	leader := false
+	result := p.group.DoChan(key, func() (interface{}, error) {
+		leader = true
+
+		// the call is shared, it must not be cancelled along with the request that started it
+		route, err := p.next.GetRoute(context.WithoutCancel(ctx), pickup, destination)
+		if err != nil {
+			return nil, err
+		}
+
+		p.put(key, route)
+		return route, nil
+	})
+
+	select {
+	case res := <-result:
+		if !leader {
+			p.coalesced.Add(1)
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*tripTypes.OsrmApiResponse), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *cachingProvider) Stats() CacheStats {
+	p.mu.Lock()
+	size := p.lru.Len()
+	p.mu.Unlock()
+
+	return CacheStats{
+		Hits:      p.hits.Load(),
+		Misses:    p.misses.Load(),
+		Coalesced: p.coalesced.Load(),
+		Evictions: p.evictions.Load(),
+		Size:      size,
+	}
+}
+
+func (p *cachingProvider) get(key string) (*tripTypes.OsrmApiResponse, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	element, ok := p.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		p.lru.Remove(element)
+		delete(p.entries, key)
+		return nil, false
+	}
+
+	p.lru.MoveToFront(element)
+	return entry.route, true
+}
+
+func (p *cachingProvider) put(key string, route *tripTypes.OsrmApiResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	expiresAt := time.Now().Add(p.cfg.TTL)
+	if element, ok := p.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.route = route
+		entry.expiresAt = expiresAt
+		p.lru.MoveToFront(element)
+		return
+	}
+
+	p.entries[key] = p.lru.PushFront(&cacheEntry{key: key, route: route, expiresAt: expiresAt})
+
+	for p.lru.Len() > p.cfg.Size {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		delete(p.entries, oldest.Value.(*cacheEntry).key)
+		p.evictions.Add(1)
+	}
+}
@@ -0,0 +1,266 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ride-sharing/shared/types"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+// blockingProvider holds every call until released, telling when each one started
+type blockingProvider struct {
+	route   *tripTypes.OsrmApiResponse
+	started chan struct{}
+	release chan struct{}
+	calls   atomic.Int32
+	// ctxErrs are the errors of the contexts the calls were made with, once released
+	ctxErrs chan error
+}
+
+func newBlockingProvider(route *tripTypes.OsrmApiResponse) *blockingProvider {
+	return &blockingProvider{
+		route:   route,
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+		ctxErrs: make(chan error, 10),
+	}
+}
+
+func (p *blockingProvider) GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	p.calls.Add(1)
+	p.started <- struct{}{}
+	<-p.release
+
+	p.ctxErrs <- ctx.Err()
+	return p.route, nil
+}
+
+func testCacheConfig() CacheConfig {
+	return CacheConfig{Precision: 8, Size: 10, TTL: time.Minute}
+}
+
+// destinations far enough apart to be cached under different keys
+func testDestinations(n int) []*types.Coordinate {
+	destinations := make([]*types.Coordinate, n)
+	for i := range destinations {
+		destinations[i] = &types.Coordinate{Latitude: testDestination.Latitude + float64(i)*0.01, Longitude: testDestination.Longitude}
+	}
+	return destinations
+}
+
+func TestCachingProviderHit(t *testing.T) {
+	ctx := context.Background()
+	next := &fakeProvider{route: testRoute(t)}
+	cache := NewCachingProvider(next, testCacheConfig())
+
+	for range 3 {
+		if _, err := cache.GetRoute(ctx, testPickup, testDestination); err != nil {
+			t.Fatalf("GetRoute: %v", err)
+		}
+	}
+
+	// nearby points round to the same cell
+	nearby := &types.Coordinate{Latitude: testDestination.Latitude + 0.000001, Longitude: testDestination.Longitude}
+	if _, err := cache.GetRoute(ctx, testPickup, nearby); err != nil {
+		t.Fatalf("GetRoute: %v", err)
+	}
+
+	if next.Calls() != 1 {
+		t.Errorf("upstream called %d times, want 1", next.Calls())
+	}
+	if stats := cache.Stats(); stats.Hits != 3 || stats.Misses != 1 || stats.Size != 1 {
+		t.Errorf("got stats %+v, want 3 hits, 1 miss and 1 route", stats)
+	}
+}
+
+func TestCachingProviderDoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	next := &fakeProvider{err: errors.New("upstream failed")}
+	cache := NewCachingProvider(next, testCacheConfig())
+
+	for range 2 {
+		if _, err := cache.GetRoute(ctx, testPickup, testDestination); err == nil {
+			t.Fatalf("GetRoute succeeded, want the upstream error")
+		}
+	}
+
+	if next.Calls() != 2 {
+		t.Errorf("upstream called %d times, want 2", next.Calls())
+	}
+}
+
+func TestCachingProviderEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	next := &fakeProvider{route: testRoute(t)}
+	cfg := testCacheConfig()
+	cfg.Size = 2
+	cache := NewCachingProvider(next, cfg)
+
+	d := testDestinations(3)
+	get := func(destination *types.Coordinate) {
+		t.Helper()
+		if _, err := cache.GetRoute(ctx, testPickup, destination); err != nil {
+			t.Fatalf("GetRoute: %v", err)
+		}
+	}
+
+	get(d[0])
+	get(d[1])
+	get(d[0]) // d[1] is now the least recently used
+	get(d[2]) // evicts d[1]
+
+	if next.Calls() != 3 {
+		t.Fatalf("upstream called %d times, want 3", next.Calls())
+	}
+
+	get(d[0])
+	if next.Calls() != 3 {
+		t.Errorf("d[0] was evicted, want d[1] evicted")
+	}
+
+	get(d[1])
+	if next.Calls() != 4 {
+		t.Errorf("d[1] is still cached, want it evicted")
+	}
+
+	if stats := cache.Stats(); stats.Size != 2 || stats.Evictions != 2 {
+		t.Errorf("got stats %+v, want 2 routes and 2 evictions", stats)
+	}
+}
+
+func TestCachingProviderExpiresRoutes(t *testing.T) {
+	ctx := context.Background()
+	next := &fakeProvider{route: testRoute(t)}
+	cfg := testCacheConfig()
+	cfg.TTL = 50 * time.Millisecond
+	cache := NewCachingProvider(next, cfg)
+
+	if _, err := cache.GetRoute(ctx, testPickup, testDestination); err != nil {
+		t.Fatalf("GetRoute: %v", err)
+	}
+	if _, err := cache.GetRoute(ctx, testPickup, testDestination); err != nil {
+		t.Fatalf("GetRoute: %v", err)
+	}
+	if next.Calls() != 1 {
+		t.Fatalf("upstream called %d times before expiry, want 1", next.Calls())
+	}
+
+	time.Sleep(2 * cfg.TTL)
+
+	if _, err := cache.GetRoute(ctx, testPickup, testDestination); err != nil {
+		t.Fatalf("GetRoute: %v", err)
+	}
+	if next.Calls() != 2 {
+		t.Errorf("upstream called %d times after expiry, want 2", next.Calls())
+	}
+}
+
+func TestCachingProviderMergesConcurrentRequests(t *testing.T) {
+	ctx := context.Background()
+	route := testRoute(t)
+	next := newBlockingProvider(route)
+	cache := NewCachingProvider(next, testCacheConfig())
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make(chan *tripTypes.OsrmApiResponse, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := cache.GetRoute(ctx, testPickup, testDestination)
+			if err != nil {
+				t.Errorf("GetRoute: %v", err)
+			}
+			results <- got
+		}()
+	}
+
+	// let every caller reach the cache before the upstream call returns
+	<-next.started
+	waitFor(t, func() bool { return cache.Stats().Misses == callers })
+	// misses are counted right before joining the upstream call
+	time.Sleep(10 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+	close(results)
+
+	for got := range results {
+		if got != route {
+			t.Errorf("got route %+v, want the upstream one", got)
+		}
+	}
+	if calls := next.calls.Load(); calls != 1 {
+		t.Errorf("upstream called %d times, want 1", calls)
+	}
+	if stats := cache.Stats(); stats.Coalesced != callers-1 {
+		t.Errorf("got %d coalesced requests, want %d", stats.Coalesced, callers-1)
+	}
+}
+
+func TestCachingProviderCancelledCallerDoesNotPoisonOthers(t *testing.T) {
+	route := testRoute(t)
+	next := newBlockingProvider(route)
+	cache := NewCachingProvider(next, testCacheConfig())
+
+	// the first caller starts the upstream call, then gives up
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := cache.GetRoute(leaderCtx, testPickup, testDestination)
+		leaderErr <- err
+	}()
+	<-next.started
+
+	followerResult := make(chan *tripTypes.OsrmApiResponse, 1)
+	go func() {
+		got, err := cache.GetRoute(context.Background(), testPickup, testDestination)
+		if err != nil {
+			t.Errorf("follower GetRoute: %v", err)
+		}
+		followerResult <- got
+	}()
+	waitFor(t, func() bool { return cache.Stats().Misses == 2 })
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("leader got error %v, want %v", err, context.Canceled)
+	}
+
+	close(next.release)
+
+	if err := <-next.ctxErrs; err != nil {
+		t.Errorf("upstream call was made with a cancelled context: %v", err)
+	}
+	if got := <-followerResult; got != route {
+		t.Errorf("follower got route %+v, want the upstream one", got)
+	}
+
+	// the route was cached for later callers too
+	got, err := cache.GetRoute(context.Background(), testPickup, testDestination)
+	if err != nil || got != route {
+		t.Errorf("got route %+v (%v), want the cached one", got, err)
+	}
+	if calls := next.calls.Load(); calls != 1 {
+		t.Errorf("upstream called %d times, want 1", calls)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
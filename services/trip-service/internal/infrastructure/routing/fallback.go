@@ -0,0 +1,35 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"log"
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/types"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+// fallbackProvider asks the fallback provider when the primary one is unavailable.
+// Other errors, like the absence of a route, are returned as is.
+type fallbackProvider struct {
+	primary  domain.RoutingProvider
+	fallback domain.RoutingProvider
+}
+
+func NewFallbackProvider(primary, fallback domain.RoutingProvider) *fallbackProvider {
+	return &fallbackProvider{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (p *fallbackProvider) GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	route, err := p.primary.GetRoute(ctx, pickup, destination)
+	if errors.Is(err, domain.ErrRoutingUnavailable) {
+		log.Printf("routing provider unavailable, using the fallback: %v", err)
+		return p.fallback.GetRoute(ctx, pickup, destination)
+	}
+
+	return route, err
+}
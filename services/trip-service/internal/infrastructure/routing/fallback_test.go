@@ -0,0 +1,56 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ride-sharing/services/trip-service/internal/domain"
+)
+
+func TestFallbackProvider(t *testing.T) {
+	route := testRoute(t)
+
+	t.Run("primary unavailable", func(t *testing.T) {
+		primary := &fakeProvider{err: domain.ErrRoutingUnavailable}
+		fallback := &fakeProvider{route: route}
+
+		got, err := NewFallbackProvider(primary, fallback).GetRoute(context.Background(), testPickup, testDestination)
+		if err != nil {
+			t.Fatalf("GetRoute: %v", err)
+		}
+		if got != route {
+			t.Errorf("got route %+v, want the one of the fallback", got)
+		}
+		if fallback.Calls() != 1 {
+			t.Errorf("fallback called %d times, want 1", fallback.Calls())
+		}
+	})
+
+	t.Run("no route", func(t *testing.T) {
+		// the fallback would make up a route that doesn't exist
+		primary := &fakeProvider{err: domain.ErrNoRoute}
+		fallback := &fakeProvider{route: route}
+
+		_, err := NewFallbackProvider(primary, fallback).GetRoute(context.Background(), testPickup, testDestination)
+		if !errors.Is(err, domain.ErrNoRoute) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrNoRoute)
+		}
+		if fallback.Calls() != 0 {
+			t.Errorf("fallback called %d times, want none", fallback.Calls())
+		}
+	})
+
+	t.Run("primary available", func(t *testing.T) {
+		primary := &fakeProvider{route: route}
+		fallback := &fakeProvider{}
+
+		got, err := NewFallbackProvider(primary, fallback).GetRoute(context.Background(), testPickup, testDestination)
+		if err != nil {
+			t.Fatalf("GetRoute: %v", err)
+		}
+		if got != route || fallback.Calls() != 0 {
+			t.Errorf("got route %+v after %d fallback calls, want the primary one", got, fallback.Calls())
+		}
+	})
+}
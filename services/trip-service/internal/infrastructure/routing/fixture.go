@@ -0,0 +1,89 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/types"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+// fixtureProvider replays routes recorded by the recordingProvider, so trips can be
+// previewed without network access. Routes that weren't recorded are not found.
+type fixtureProvider struct {
+	dir string
+}
+
+func NewFixtureProvider(dir string) *fixtureProvider {
+	return &fixtureProvider{
+		dir: dir,
+	}
+}
+
+func (p *fixtureProvider) GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	data, err := os.ReadFile(fixturePath(p.dir, pickup, destination))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: no fixture recorded for this route", domain.ErrNoRoute)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read route fixture: %v", err)
+	}
+
+	var route tripTypes.OsrmApiResponse
+	if err := json.Unmarshal(data, &route); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal route fixture: %v", err)
+	}
+
+	// fixtures are recorded OSRM responses, checked the same way
+	if err := checkOsrmResponse(&route); err != nil {
+		return nil, err
+	}
+
+	return &route, nil
+}
+
+// recordingProvider stores every route found by the wrapped provider as a fixture
+type recordingProvider struct {
+	next domain.RoutingProvider
+	dir  string
+}
+
+func NewRecordingProvider(next domain.RoutingProvider, dir string) *recordingProvider {
+	return &recordingProvider{
+		next: next,
+		dir:  dir,
+	}
+}
+
+func (p *recordingProvider) GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	route, err := p.next.GetRoute(ctx, pickup, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(route, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal route fixture: %v", err)
+	}
+
+	if err := os.WriteFile(fixturePath(p.dir, pickup, destination), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write route fixture: %v", err)
+	}
+
+	return route, nil
+}
+
+// fixturePath names fixtures after their coordinates, rounded to about a meter
+func fixturePath(dir string, pickup, destination *types.Coordinate) string {
+	name := fmt.Sprintf("%.5f_%.5f_%.5f_%.5f.json",
+		pickup.Latitude, pickup.Longitude,
+		destination.Latitude, destination.Longitude,
+	)
+
+	return filepath.Join(dir, name)
+}
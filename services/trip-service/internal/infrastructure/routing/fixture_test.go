@@ -0,0 +1,68 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/types"
+)
+
+func TestRecordedFixtureRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	route := testRoute(t)
+
+	recorded, err := NewRecordingProvider(&fakeProvider{route: route}, dir).GetRoute(ctx, testPickup, testDestination)
+	if err != nil {
+		t.Fatalf("recording GetRoute: %v", err)
+	}
+	if recorded != route {
+		t.Errorf("recording provider changed the route")
+	}
+
+	replayed, err := NewFixtureProvider(dir).GetRoute(ctx, testPickup, testDestination)
+	if err != nil {
+		t.Fatalf("fixture GetRoute: %v", err)
+	}
+	if !reflect.DeepEqual(replayed, route) {
+		t.Errorf("replayed %+v, want %+v", replayed, route)
+	}
+}
+
+func TestRecordingProviderDoesNotRecordErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewRecordingProvider(&fakeProvider{err: domain.ErrNoRoute}, dir).GetRoute(context.Background(), testPickup, testDestination)
+	if !errors.Is(err, domain.ErrNoRoute) {
+		t.Fatalf("got error %v, want %v", err, domain.ErrNoRoute)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("recorded %d fixtures, want none", len(entries))
+	}
+}
+
+func TestFixtureProviderMissingRoute(t *testing.T) {
+	elsewhere := &types.Coordinate{Latitude: 48.8566, Longitude: 2.3522}
+
+	_, err := NewFixtureProvider(testFixturesDir).GetRoute(context.Background(), testPickup, elsewhere)
+	if !errors.Is(err, domain.ErrNoRoute) {
+		t.Fatalf("got error %v, want %v", err, domain.ErrNoRoute)
+	}
+}
+
+func TestFixtureProviderEmptyRoute(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(fixturePath(dir, testPickup, testDestination), []byte(`{"code": "Ok", "routes": []}`), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	_, err := NewFixtureProvider(dir).GetRoute(context.Background(), testPickup, testDestination)
+	if !errors.Is(err, domain.ErrNoRoute) {
+		t.Fatalf("got error %v, want %v", err, domain.ErrNoRoute)
+	}
+}
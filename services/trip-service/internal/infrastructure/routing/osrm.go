@@ -0,0 +1,90 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/types"
+	"time"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+// maxOsrmResponseBytes caps the size of the routes we read, full geometries of long trips included
+const maxOsrmResponseBytes = 8 << 20
+
+type osrmProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewOSRMProvider(baseURL string, timeout time.Duration) *osrmProvider {
+	return &osrmProvider{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *osrmProvider) GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	url := fmt.Sprintf(
+		"%s/route/v1/driving/%f,%f;%f,%f?overview=full&geometries=geojson",
+		p.baseURL,
+		pickup.Longitude, pickup.Latitude,
+		destination.Longitude, destination.Latitude,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create osrm request: %v", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch route from osrm: %v", domain.ErrRoutingUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	// OSRM answers invalid queries and missing routes with a 400 and a JSON error code
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("%w: osrm responded with status %d", domain.ErrRoutingUnavailable, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOsrmResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read response: %v", domain.ErrRoutingUnavailable, err)
+	}
+	if len(body) > maxOsrmResponseBytes {
+		return nil, fmt.Errorf("osrm response is larger than %d bytes", maxOsrmResponseBytes)
+	}
+
+	var routeResp tripTypes.OsrmApiResponse
+	if err := json.Unmarshal(body, &routeResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response (status %d): %v", resp.StatusCode, err)
+	}
+
+	if err := checkOsrmResponse(&routeResp); err != nil {
+		return nil, err
+	}
+
+	return &routeResp, nil
+}
+
+// checkOsrmResponse tells whether the response holds a route, whichever provider it comes from
+func checkOsrmResponse(routeResp *tripTypes.OsrmApiResponse) error {
+	switch routeResp.Code {
+	case "Ok":
+	case "NoRoute", "NoSegment":
+		return fmt.Errorf("%w: %s", domain.ErrNoRoute, routeResp.Message)
+	default:
+		return fmt.Errorf("osrm responded with code %q: %s", routeResp.Code, routeResp.Message)
+	}
+
+	if len(routeResp.Routes) == 0 {
+		return domain.ErrNoRoute
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ride-sharing/services/trip-service/internal/domain"
+)
+
+func TestOSRMProvider(t *testing.T) {
+	recorded, err := os.ReadFile(filepath.Join(testFixturesDir, "37.76873_-122.41346_37.77164_-122.41125.json"))
+	if err != nil {
+		t.Fatalf("failed to read the recorded route: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{name: "route found", status: http.StatusOK, body: string(recorded)},
+		{name: "no route", status: http.StatusBadRequest, body: `{"code": "NoRoute", "message": "Impossible route between points"}`, wantErr: domain.ErrNoRoute},
+		{name: "no routes in an ok response", status: http.StatusOK, body: `{"code": "Ok", "routes": []}`, wantErr: domain.ErrNoRoute},
+		{name: "server error", status: http.StatusInternalServerError, body: `oops`, wantErr: domain.ErrRoutingUnavailable},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: `{"code": "TooBig"}`, wantErr: domain.ErrRoutingUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			route, err := NewOSRMProvider(server.URL, time.Second).GetRoute(context.Background(), testPickup, testDestination)
+
+			// OSRM takes longitude,latitude pairs
+			if !strings.HasPrefix(gotPath, "/route/v1/driving/-122.413456,37.768728;-122.411250,37.771636") {
+				t.Errorf("requested %s", gotPath)
+			}
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRoute: %v", err)
+			}
+			if len(route.Routes) != 1 || route.Routes[0].Distance != 412.6 {
+				t.Errorf("got route %+v, want the recorded one", route)
+			}
+		})
+	}
+}
+
+func TestOSRMProviderUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	_, err := NewOSRMProvider(server.URL, time.Second).GetRoute(context.Background(), testPickup, testDestination)
+	if !errors.Is(err, domain.ErrRoutingUnavailable) {
+		t.Fatalf("got error %v, want %v", err, domain.ErrRoutingUnavailable)
+	}
+}
@@ -0,0 +1,53 @@
+package routing
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"ride-sharing/shared/types"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+// the route recorded in testdata/routes
+var (
+	testPickup      = &types.Coordinate{Latitude: 37.768727753110106, Longitude: -122.41345597077878}
+	testDestination = &types.Coordinate{Latitude: 37.77163599059948, Longitude: -122.41125013468515}
+)
+
+const testFixturesDir = "testdata/routes"
+
+// fakeProvider answers every route with the same response and counts the calls
+type fakeProvider struct {
+	route *tripTypes.OsrmApiResponse
+	err   error
+	calls int
+	mu    sync.Mutex
+}
+
+func (p *fakeProvider) GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls++
+	return p.route, p.err
+}
+
+func (p *fakeProvider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.calls
+}
+
+func testRoute(t *testing.T) *tripTypes.OsrmApiResponse {
+	t.Helper()
+
+	route, err := NewFixtureProvider(testFixturesDir).GetRoute(context.Background(), testPickup, testDestination)
+	if err != nil {
+		t.Fatalf("failed to load the recorded route: %v", err)
+	}
+
+	return route
+}
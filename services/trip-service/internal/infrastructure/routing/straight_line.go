@@ -0,0 +1,44 @@
+package routing
+
+import (
+	"context"
+	"ride-sharing/shared/types"
+	"ride-sharing/shared/util"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+const (
+	// roads are rarely straight, the straight line is stretched to estimate their length
+	straightLineDetourFactor = 1.3
+	straightLineSpeedKmh     = 30
+)
+
+// straightLineProvider estimates routes without any map data, from the distance
+// as the crow flies. The geometry is the straight line between the two points.
+type straightLineProvider struct{}
+
+func NewStraightLineProvider() *straightLineProvider {
+	return &straightLineProvider{}
+}
+
+func (p *straightLineProvider) GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	distanceKm := util.HaversineDistanceKm(pickup.Latitude, pickup.Longitude, destination.Latitude, destination.Longitude)
+	distanceKm *= straightLineDetourFactor
+
+	return &tripTypes.OsrmApiResponse{
+		Code: "Ok",
+		Routes: []tripTypes.OsrmRoute{
+			{
+				Distance: distanceKm * 1000,
+				Duration: distanceKm / straightLineSpeedKmh * 3600,
+				Geometry: tripTypes.OsrmGeometry{
+					Coordinates: [][]float64{
+						{pickup.Longitude, pickup.Latitude},
+						{destination.Longitude, destination.Latitude},
+					},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"math"
+	"ride-sharing/services/trip-service/internal/domain"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+// pricingEngine prices every package of the pricing config for a route
+type pricingEngine struct {
+	cfg *tripTypes.PricingConfig
+}
+
+func newPricingEngine(cfg *tripTypes.PricingConfig) *pricingEngine {
+	return &pricingEngine{
+		cfg: cfg,
+	}
+}
+
+// Estimate returns the fare of every package, in the order of the config.
+// OSRM reports distances in meters and durations in seconds.
+func (e *pricingEngine) Estimate(distanceMeters, durationSeconds, surgeMultiplier float64) []*domain.RideFareModel {
+	fares := make([]*domain.RideFareModel, len(e.cfg.Packages))
+	for i, p := range e.cfg.Packages {
+		breakdown, total := e.price(p, distanceMeters/1000, durationSeconds/60, surgeMultiplier)
+
+		fares[i] = &domain.RideFareModel{
+			PackageSlug:       p.Slug,
+			TotalPriceInCents: total,
+			Breakdown:         breakdown,
+			Currency:          e.cfg.Currency,
+			PricingVersion:    e.cfg.Version,
+		}
+	}
+
+	return fares
+}
+
+// price returns the breakdown of the fare of the package and its rounded total.
+// The surge applies to the ride itself, not to the booking fee.
+func (e *pricingEngine) price(p tripTypes.PackagePricing, distanceKm, durationMinutes, surgeMultiplier float64) (*domain.FareBreakdown, float64) {
+	b := &domain.FareBreakdown{
+		BaseFareInCents:     p.BaseFareInCents,
+		DistanceFareInCents: distanceKm * p.PerKmInCents,
+		TimeFareInCents:     durationMinutes * p.PerMinuteInCents,
+		BookingFeeInCents:   p.BookingFeeInCents,
+		DistanceKm:          distanceKm,
+		DurationMinutes:     durationMinutes,
+		SurgeMultiplier:     math.Max(surgeMultiplier, 1),
+	}
+
+	ride := b.BaseFareInCents + b.DistanceFareInCents + b.TimeFareInCents
+	if ride < p.MinimumFareInCents {
+		b.MinimumFareAdjustmentInCents = p.MinimumFareInCents - ride
+		ride = p.MinimumFareInCents
+	}
+	b.SurgeFareInCents = ride * (b.SurgeMultiplier - 1)
+
+	unrounded := b.Total()
+	total := e.round(unrounded)
+	b.RoundingAdjustmentInCents = total - unrounded
+
+	return b, total
+}
+
+func (e *pricingEngine) round(cents float64) float64 {
+	increment := e.cfg.Rounding.IncrementInCents
+	steps := cents / increment
+
+	switch e.cfg.Rounding.Mode {
+	case tripTypes.RoundingUp:
+		steps = math.Ceil(steps)
+	case tripTypes.RoundingDown:
+		steps = math.Floor(steps)
+	default:
+		steps = math.Round(steps)
+	}
+
+	return steps * increment
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+func testPricingConfig(mode tripTypes.RoundingMode, increment float64) *tripTypes.PricingConfig {
+	return &tripTypes.PricingConfig{
+		Version:              "test-v1",
+		Currency:             "usd",
+		QuoteValiditySeconds: 600,
+		Rounding:             tripTypes.RoundingRule{Mode: mode, IncrementInCents: increment},
+		Packages: []tripTypes.PackagePricing{{
+			Slug:               "sedan",
+			BaseFareInCents:    300,
+			PerKmInCents:       100,
+			PerMinuteInCents:   20,
+			MinimumFareInCents: 500,
+			BookingFeeInCents:  150,
+		}},
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestPricingRounding(t *testing.T) {
+	tests := []struct {
+		mode      tripTypes.RoundingMode
+		increment float64
+		cents     float64
+		want      float64
+	}{
+		{tripTypes.RoundingNearest, 1, 1234.4, 1234},
+		{tripTypes.RoundingNearest, 1, 1234.5, 1235},
+		{tripTypes.RoundingNearest, 50, 1224, 1200},
+		{tripTypes.RoundingNearest, 50, 1225, 1250},
+		{tripTypes.RoundingUp, 50, 1201, 1250},
+		{tripTypes.RoundingUp, 50, 1200, 1200},
+		{tripTypes.RoundingDown, 50, 1249, 1200},
+		{tripTypes.RoundingDown, 50, 1250, 1250},
+		{tripTypes.RoundingUp, 25, 0.1, 25},
+	}
+
+	for _, tt := range tests {
+		engine := newPricingEngine(testPricingConfig(tt.mode, tt.increment))
+		if got := engine.round(tt.cents); got != tt.want {
+			t.Errorf("round(%v) %s to %v = %v, want %v", tt.cents, tt.mode, tt.increment, got, tt.want)
+		}
+	}
+}
+
+func TestPricingBreakdown(t *testing.T) {
+	tests := []struct {
+		name            string
+		mode            tripTypes.RoundingMode
+		increment       float64
+		distanceMeters  float64
+		durationSeconds float64
+		surge           float64
+		want            float64 // total price
+		wantMinimum     float64 // minimum fare adjustment
+		wantSurge       float64 // surge fare
+	}{
+		// 300 + 10km * 100 + 15min * 20 = 1600, + 150 booking fee
+		{"per km and per minute", tripTypes.RoundingNearest, 1, 10000, 900, 1, 1750, 0, 0},
+		// 300 + 0.5km * 100 + 1min * 20 = 370, raised to the 500 minimum
+		{"minimum fare", tripTypes.RoundingNearest, 1, 500, 60, 1, 650, 130, 0},
+		// the surge applies to the ride, not to the booking fee
+		{"surge", tripTypes.RoundingNearest, 1, 10000, 900, 1.5, 2550, 0, 800},
+		{"surge on the minimum fare", tripTypes.RoundingNearest, 1, 500, 60, 2, 1150, 130, 500},
+		// multipliers below 1 don't discount the fare
+		{"surge below 1", tripTypes.RoundingNearest, 1, 10000, 900, 0.5, 1750, 0, 0},
+		// 300 + 1.234km * 100 + 0.5min * 20 = 433.4, raised to 500, + 150 = 650
+		{"rounded down", tripTypes.RoundingDown, 100, 1234, 30, 1, 600, 66.6, 0},
+		{"rounded up", tripTypes.RoundingUp, 100, 1234, 30, 1, 700, 66.6, 0},
+		// 300 + 2.345km * 100 + 3min * 20 = 594.5, + 150 = 744.5
+		{"rounded to the nearest", tripTypes.RoundingNearest, 5, 2345, 180, 1, 745, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newPricingEngine(testPricingConfig(tt.mode, tt.increment))
+			fares := engine.Estimate(tt.distanceMeters, tt.durationSeconds, tt.surge)
+			if len(fares) != 1 {
+				t.Fatalf("got %d fares, want 1", len(fares))
+			}
+
+			fare := fares[0]
+			b := fare.Breakdown
+			if fare.TotalPriceInCents != tt.want {
+				t.Errorf("total = %v, want %v", fare.TotalPriceInCents, tt.want)
+			}
+			if !almostEqual(b.Total(), fare.TotalPriceInCents) {
+				t.Errorf("breakdown adds up to %v, want the total %v", b.Total(), fare.TotalPriceInCents)
+			}
+			if !almostEqual(b.MinimumFareAdjustmentInCents, tt.wantMinimum) {
+				t.Errorf("minimum fare adjustment = %v, want %v", b.MinimumFareAdjustmentInCents, tt.wantMinimum)
+			}
+			if !almostEqual(b.SurgeFareInCents, tt.wantSurge) {
+				t.Errorf("surge fare = %v, want %v", b.SurgeFareInCents, tt.wantSurge)
+			}
+			if !almostEqual(b.DistanceKm, tt.distanceMeters/1000) || !almostEqual(b.DurationMinutes, tt.durationSeconds/60) {
+				t.Errorf("got %vkm in %vmin, want %vm in %vs converted", b.DistanceKm, b.DurationMinutes, tt.distanceMeters, tt.durationSeconds)
+			}
+			if b.BookingFeeInCents != 150 {
+				t.Errorf("booking fee = %v, want 150", b.BookingFeeInCents)
+			}
+			if fare.PackageSlug != "sedan" || fare.Currency != "usd" || fare.PricingVersion != "test-v1" {
+				t.Errorf("got fare %s in %s priced with %s", fare.PackageSlug, fare.Currency, fare.PricingVersion)
+			}
+		})
+	}
+}
+
+func TestPricingEstimatesEveryPackageInOrder(t *testing.T) {
+	cfg := tripTypes.DefaultPricingConfig()
+	fares := newPricingEngine(cfg).Estimate(5000, 600, 1)
+
+	if len(fares) != len(cfg.Packages) {
+		t.Fatalf("got %d fares, want %d", len(fares), len(cfg.Packages))
+	}
+	for i, fare := range fares {
+		if fare.PackageSlug != cfg.Packages[i].Slug {
+			t.Errorf("fare %d is for %s, want %s", i, fare.PackageSlug, cfg.Packages[i].Slug)
+		}
+	}
+}
+
+func TestDefaultPricingConfigIsValid(t *testing.T) {
+	cfg := tripTypes.DefaultPricingConfig()
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default pricing config: %v", err)
+	}
+	if cfg.QuoteValidity() <= 0 {
+		t.Errorf("default quotes are valid for %v", cfg.QuoteValidity())
+	}
+}
+
+func TestPricingConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *tripTypes.PricingConfig)
+		wantErr string
+	}{
+		{"valid", func(cfg *tripTypes.PricingConfig) {}, ""},
+		{"no version", func(cfg *tripTypes.PricingConfig) { cfg.Version = "" }, "no version"},
+		{"no currency", func(cfg *tripTypes.PricingConfig) { cfg.Currency = "" }, "no currency"},
+		{"no quote validity", func(cfg *tripTypes.PricingConfig) { cfg.QuoteValiditySeconds = 0 }, "keep quotes valid"},
+		{"unknown rounding mode", func(cfg *tripTypes.PricingConfig) { cfg.Rounding.Mode = "banker" }, "unknown rounding mode"},
+		{"rounding below a cent", func(cfg *tripTypes.PricingConfig) { cfg.Rounding.IncrementInCents = 0.5 }, "at least 1 cent"},
+		{"no packages", func(cfg *tripTypes.PricingConfig) { cfg.Packages = nil }, "no packages"},
+		{"package without slug", func(cfg *tripTypes.PricingConfig) { cfg.Packages[0].Slug = "" }, "without slug"},
+		{"duplicate package", func(cfg *tripTypes.PricingConfig) {
+			cfg.Packages = append(cfg.Packages, cfg.Packages[0])
+		}, "more than once"},
+		{"negative rate", func(cfg *tripTypes.PricingConfig) { cfg.Packages[0].PerKmInCents = -1 }, "negative rates"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testPricingConfig(tripTypes.RoundingNearest, 5)
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want an error about %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := tripTypes.ParsePricingConfig([]byte(`{"version": `)); err == nil {
+		t.Errorf("ParsePricingConfig of malformed JSON: got nil error")
+	}
+}
@@ -2,14 +2,12 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"log"
 	"ride-sharing/services/trip-service/internal/domain"
-	"ride-sharing/shared/env"
 	"ride-sharing/shared/proto/trip"
 	"ride-sharing/shared/types"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
@@ -18,68 +16,103 @@ import (
 )
 
 type service struct {
-	repo domain.TripRepository
+	repo      domain.TripRepository
+	publisher domain.TripEventPublisher
+	pricing   *pricingEngine
+	surge     *surgeEngine
+	routing   domain.RoutingProvider
 }
 
-func NewService(repo domain.TripRepository) *service {
+func NewService(
+	repo domain.TripRepository,
+	publisher domain.TripEventPublisher,
+	pricingCfg *tripTypes.PricingConfig,
+	surgeCfg tripTypes.SurgeConfig,
+	supply domain.DriverSupply,
+	routing domain.RoutingProvider,
+) *service {
 
 	return &service{
-		repo: repo,
+		repo:      repo,
+		publisher: publisher,
+		pricing:   newPricingEngine(pricingCfg),
+		surge:     newSurgeEngine(surgeCfg, supply),
+		routing:   routing,
 	}
 
 }
 
 func (s *service) CreateTrip(ctx context.Context, fare *domain.RideFareModel) (*domain.TripModel, error) {
 	t := &domain.TripModel{
-		ID:       primitive.NewObjectID(),
-		UserID:   fare.UserID,
-		Status:   "pending",
-		RideFare: fare,
-		Driver:   &trip.TripDriver{},
+		ID:        primitive.NewObjectID(),
+		UserID:    fare.UserID,
+		Status:    domain.TripStatusPending,
+		RideFare:  fare,
+		Driver:    &trip.TripDriver{},
+		CreatedAt: time.Now(),
 	}
 
-	return s.repo.CreateTrip(ctx, t)
-}
+	// a fare books a single trip, whoever consumes it first wins
+	bookedAt := t.CreatedAt
+	if err := s.repo.ConsumeRideFare(ctx, fare.ID.Hex(), bookedAt); err != nil {
+		return nil, fmt.Errorf("failed to book ride fare %s: %w", fare.ID.Hex(), err)
+	}
 
-func (s *service) GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
-	// or use our self hosted API (check the course lesson: "Preparing for External API Failures")
-	baseURL := env.GetString("OSRM_API", "http://router.project-osrm.org")
+	// the rider retries with the same fare when the booking fails, it must still be usable
+	t, err := s.repo.CreateTrip(ctx, t)
+	if err != nil {
+		s.releaseRideFare(ctx, fare, bookedAt)
+		return nil, err
+	}
 
-	url := fmt.Sprintf(
-		"%s/route/v1/driving/%f,%f;%f,%f?overview=full&geometries=geojson",
-		baseURL,
-		pickup.Longitude, pickup.Latitude,
-		destination.Longitude, destination.Latitude,
-	)
+	if err := s.publisher.PublishTripCreated(ctx, t); err != nil {
+		// no driver would ever be looked for, the trip is dropped
+		if err := s.repo.DeleteTrip(context.WithoutCancel(ctx), t.ID.Hex()); err != nil {
+			log.Printf("Failed to delete trip %s after failing to announce it: %v", t.ID.Hex(), err)
+		} else {
+			s.releaseRideFare(ctx, fare, bookedAt)
+		}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch route from osrm: %v", err)
+		return nil, fmt.Errorf("failed to publish trip created event: %v", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
+	if pickup, ok := fare.Route.Pickup(); ok {
+		s.surge.RecordTripRequest(pickup)
 	}
 
-	var routeResp tripTypes.OsrmApiResponse
-	if err := json.Unmarshal(body, &routeResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	return t, nil
+}
+
+// releaseRideFare undoes the booking of the fare made at consumedAt, even if the request is gone
+func (s *service) releaseRideFare(ctx context.Context, fare *domain.RideFareModel, consumedAt time.Time) {
+	if err := s.repo.ReleaseRideFare(context.WithoutCancel(ctx), fare.ID.Hex(), consumedAt); err != nil {
+		log.Printf("Failed to release ride fare %s: %v", fare.ID.Hex(), err)
 	}
+}
 
-	return &routeResp, nil
+func (s *service) GetRoute(ctx context.Context, pickup, destination *types.Coordinate) (*tripTypes.OsrmApiResponse, error) {
+	return s.routing.GetRoute(ctx, pickup, destination)
 }
 
-func (s *service) EstimatePackagesPriceWithRoute(route *tripTypes.OsrmApiResponse) []*domain.RideFareModel {
-	baseFare := getBaseFares()
+func (s *service) EstimatePackagesPriceWithRoute(
+	ctx context.Context,
+	route *tripTypes.OsrmApiResponse,
+) ([]*domain.RideFareModel, *domain.Surge, error) {
+	if route == nil || len(route.Routes) == 0 {
+		return nil, nil, domain.ErrNoRoute
+	}
+
+	var surge *domain.Surge
+	if pickup, ok := route.Pickup(); ok {
+		surge = s.surge.Surge(ctx, pickup)
+	}
 
-	estimatedFares := make([]*domain.RideFareModel, len(baseFare))
-	for i, fare := range baseFare {
-		estimatedFares[i] = estimateFareRoute(fare, route)
+	multiplier := 1.0
+	if surge.IsActive() {
+		multiplier = surge.Multiplier
 	}
 
-	return estimatedFares
+	return s.pricing.Estimate(route.Routes[0].Distance, route.Routes[0].Duration, multiplier), surge, nil
 }
 
 func (s *service) GenerateTripFares(
@@ -89,6 +122,7 @@ func (s *service) GenerateTripFares(
 	route *tripTypes.OsrmApiResponse,
 ) ([]*domain.RideFareModel, error) {
 	fares := make([]*domain.RideFareModel, len(rideFares))
+	now := time.Now()
 
 	for i, f := range rideFares {
 		id := primitive.NewObjectID()
@@ -97,10 +131,14 @@ func (s *service) GenerateTripFares(
 			UserID:            userID,
 			PackageSlug:       f.PackageSlug,
 			TotalPriceInCents: f.TotalPriceInCents,
+			Breakdown:         f.Breakdown,
+			Currency:          f.Currency,
+			PricingVersion:    f.PricingVersion,
 			Route:             route,
+			CreatedAt:         now,
+			ExpiresAt:         now.Add(s.pricing.cfg.QuoteValidity()),
 		}
 
-		// TODO: Save fares to DB
 		if err := s.repo.SaveRideFare(ctx, fare); err != nil {
 			return nil, fmt.Errorf("failed to save trip fare: %v", err)
 		}
@@ -111,66 +149,138 @@ func (s *service) GenerateTripFares(
 	return fares, nil
 }
 
-func estimateFareRoute(f *domain.RideFareModel, route *tripTypes.OsrmApiResponse) *domain.RideFareModel {
-	pricingCfg := tripTypes.DefaultPricingConfig()
-	carPackagePrice := f.TotalPriceInCents
+func (s *service) GetAndValidateFare(ctx context.Context, fareID, userID string) (*domain.RideFareModel, error) {
+	fare, err := s.repo.GetRiderFareByID(ctx, fareID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ride fare with id %s: %w", fareID, err)
+	}
+
+	if err := fare.CheckUsable(userID, time.Now()); err != nil {
+		return nil, fmt.Errorf("user %s cannot book ride fare with id %s: %w", userID, fareID, err)
+	}
 
-	distanceKm := route.Routes[0].Distance
-	durationInMinutes := route.Routes[0].Duration
+	return fare, nil
+}
 
-	distanceFare := distanceKm * pricingCfg.PricePerUnitOfDistance
-	timeFare := durationInMinutes * pricingCfg.PricingPerMinute
+func (s *service) PurgeExpiredFares(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpiredRideFares(ctx, time.Now())
+}
 
-	totalPrice := carPackagePrice + distanceFare + timeFare
+func (s *service) GetTripByID(ctx context.Context, tripID string) (*domain.TripModel, error) {
+	return s.repo.GetTripByID(ctx, tripID)
+}
 
-	return &domain.RideFareModel{
-		PackageSlug:       f.PackageSlug,
-		TotalPriceInCents: totalPrice,
+func (s *service) GetTrip(ctx context.Context, tripID, userID string) (*domain.TripModel, error) {
+	t, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func getBaseFares() []*domain.RideFareModel {
-	return []*domain.RideFareModel{
-		{
-			PackageSlug:       "suv",
-			TotalPriceInCents: 200,
-		},
-		{
-			PackageSlug:       "sedan",
-			TotalPriceInCents: 350,
-		},
-		{
-			PackageSlug:       "van",
-			TotalPriceInCents: 400,
-		},
-		{
-			PackageSlug:       "luxury",
-			TotalPriceInCents: 1000,
-		},
+	if !t.HasParticipant(userID) {
+		return nil, domain.ErrNotTripParticipant
 	}
+
+	return t, nil
 }
 
-func (s *service) GetAndValidateFare(ctx context.Context, fareID, userID string) (*domain.RideFareModel, error) {
-	fare, err := s.repo.GetRiderFareByID(ctx, fareID)
+func (s *service) ListTrips(ctx context.Context, query domain.TripQuery, pageToken string) (*domain.TripPage, error) {
+	if pageToken != "" {
+		// the token is the id of the last trip of the previous page
+		if !primitive.IsValidObjectID(pageToken) {
+			return nil, domain.ErrInvalidPageToken
+		}
+		query.AfterID = pageToken
+	}
+
+	switch {
+	case query.Limit <= 0:
+		query.Limit = domain.DefaultTripPageSize
+	case query.Limit > domain.MaxTripPageSize:
+		query.Limit = domain.MaxTripPageSize
+	}
+
+	// fetch one more trip than asked to know if there is a next page
+	pageSize := query.Limit
+	query.Limit++
+
+	trips, err := s.repo.ListTrips(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ride fare with id %s: %v", fareID, err)
+		return nil, err
 	}
 
-	if fare == nil {
-		return nil, fmt.Errorf("ride fare with id %s not found", fareID)
+	page := &domain.TripPage{Trips: trips}
+	if len(trips) > pageSize {
+		page.Trips = trips[:pageSize]
+		page.NextPageToken = page.Trips[pageSize-1].ID.Hex()
 	}
 
-	if fare.UserID != userID {
-		return nil, fmt.Errorf("user %s is not the owner of ride fare with id %s", userID, fareID)
+	return page, nil
+}
+
+func (s *service) TransitionTrip(ctx context.Context, tripID string, to domain.TripStatus, driver *pbd.Driver) (*domain.TripModel, error) {
+	t, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, err
 	}
 
-	return fare, nil
+	return s.transition(ctx, t, to, func(t *domain.TripModel) {
+		if driver != nil {
+			t.Driver = &trip.TripDriver{
+				Id:             driver.Id,
+				Name:           driver.Name,
+				CarPlate:       driver.CarPlate,
+				ProfilePicture: driver.ProfilePicture,
+			}
+		}
+	})
 }
 
-func (s *service) UpdateTrip(ctx context.Context, tripId string, status string, driver *pbd.Driver) error {
-	return s.repo.UpdateTrip(ctx, tripId, status, driver)
+func (s *service) CancelTrip(ctx context.Context, tripID, userID, reason string) (*domain.TripModel, error) {
+	t, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	party, err := t.CancellingParty(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user %s cannot cancel trip %s: %w", userID, tripID, err)
+	}
+
+	return s.transition(ctx, t, domain.TripStatusCancelled, func(t *domain.TripModel) {
+		now := time.Now()
+		t.Cancellation = &domain.TripCancellation{
+			CancelledBy: party,
+			Reason:      reason,
+			FeeInCents:  t.CancellationFee(party, now),
+			CancelledAt: now,
+		}
+	})
 }
 
-func (s *service) GetTripByID(ctx context.Context, tripID string) (*domain.TripModel, error) {
-	return s.repo.GetTripByID(ctx, tripID)
+// transition moves the trip to the given status, lets apply record the details of the
+// change, stores it and emits the event announcing the new status.
+func (s *service) transition(
+	ctx context.Context,
+	t *domain.TripModel,
+	to domain.TripStatus,
+	apply func(t *domain.TripModel),
+) (*domain.TripModel, error) {
+	from := t.Status
+	if err := t.TransitionTo(to); err != nil {
+		return nil, err
+	}
+
+	if apply != nil {
+		apply(t)
+	}
+
+	if err := s.repo.UpdateTrip(ctx, t, from); err != nil {
+		return nil, fmt.Errorf("failed to move trip %s from %s to %s: %w", t.ID.Hex(), from, to, err)
+	}
+
+	if err := s.publisher.PublishTripStatusChanged(ctx, t); err != nil {
+		return nil, fmt.Errorf("failed to publish trip %s status change: %v", t.ID.Hex(), err)
+	}
+
+	return t, nil
 }
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/services/trip-service/internal/infrastructure/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+// failingPublisher fails to publish the created trips until told otherwise
+type failingPublisher struct {
+	fail    bool
+	created []*domain.TripModel
+}
+
+func (p *failingPublisher) PublishTripCreated(ctx context.Context, trip *domain.TripModel) error {
+	if p.fail {
+		return errors.New("broker unavailable")
+	}
+
+	p.created = append(p.created, trip)
+	return nil
+}
+
+func (p *failingPublisher) PublishTripStatusChanged(ctx context.Context, trip *domain.TripModel) error {
+	return nil
+}
+
+func TestCreateTripKeepsFareWhenAnnouncingFails(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInmemRepository()
+	publisher := &failingPublisher{fail: true}
+	svc := NewService(repo, publisher, tripTypes.DefaultPricingConfig(), tripTypes.DefaultSurgeConfig(), nil, nil)
+
+	now := time.Now()
+	fare := &domain.RideFareModel{
+		ID:          primitive.NewObjectID(),
+		UserID:      "rider-1",
+		PackageSlug: "sedan",
+		Route: &tripTypes.OsrmApiResponse{
+			Code: "Ok",
+			Routes: []tripTypes.OsrmRoute{{
+				Distance: 1000,
+				Duration: 120,
+				Geometry: tripTypes.OsrmGeometry{Coordinates: [][]float64{{-122.4134, 37.7687}, {-122.4112, 37.7716}}},
+			}},
+		},
+		CreatedAt: now,
+		ExpiresAt: now.Add(10 * time.Minute),
+	}
+	if err := repo.SaveRideFare(ctx, fare); err != nil {
+		t.Fatalf("SaveRideFare: %v", err)
+	}
+
+	if _, err := svc.CreateTrip(ctx, fare); err == nil {
+		t.Fatalf("CreateTrip succeeded, want the publishing error")
+	}
+
+	trips, err := repo.ListTrips(ctx, domain.TripQuery{UserID: "rider-1", Limit: 10})
+	if err != nil {
+		t.Fatalf("ListTrips: %v", err)
+	}
+	if len(trips) != 0 {
+		t.Errorf("got %d trips after the failed booking, want none", len(trips))
+	}
+
+	// the rider retries with the same fare
+	publisher.fail = false
+	trip, err := svc.CreateTrip(ctx, fare)
+	if err != nil {
+		t.Fatalf("CreateTrip retry: %v", err)
+	}
+	if len(publisher.created) != 1 || publisher.created[0].ID != trip.ID {
+		t.Errorf("published %d created trips, want the retried one", len(publisher.created))
+	}
+
+	if _, err := svc.CreateTrip(ctx, fare); !errors.Is(err, domain.ErrRideFareConsumed) {
+		t.Errorf("CreateTrip with a booked fare: got %v, want %v", err, domain.ErrRideFareConsumed)
+	}
+}
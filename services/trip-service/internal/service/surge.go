@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"ride-sharing/services/trip-service/internal/domain"
+	"ride-sharing/shared/types"
+	"sync"
+	"time"
+
+	"github.com/mmcloughlin/geohash"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+// supplyTimeout bounds how long a preview waits for the driver supply, without it there's no surge
+const supplyTimeout = 500 * time.Millisecond
+
+type surgeLevel struct {
+	multiplier float64
+	at         time.Time
+}
+
+// surgeEngine compares, per geohash cell, the trips recently requested with the drivers
+// available. The multiplier follows that ratio smoothly, so fares don't jump between previews.
+type surgeEngine struct {
+	cfg    tripTypes.SurgeConfig
+	supply domain.DriverSupply
+	demand map[string][]time.Time // cell -> when trips were requested, oldest first
+	levels map[string]surgeLevel  // cell -> last smoothed multiplier
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+func newSurgeEngine(cfg tripTypes.SurgeConfig, supply domain.DriverSupply) *surgeEngine {
+	return &surgeEngine{
+		cfg:    cfg,
+		supply: supply,
+		demand: make(map[string][]time.Time),
+		levels: make(map[string]surgeLevel),
+		now:    time.Now,
+	}
+}
+
+// RecordTripRequest counts a trip created at the pickup as demand
+func (e *surgeEngine) RecordTripRequest(pickup *types.Coordinate) {
+	cell := geohash.EncodeWithPrecision(pickup.Latitude, pickup.Longitude, e.cfg.Precision)
+	now := e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.demand[cell] = append(e.recentDemand(cell, now), now)
+	if _, ok := e.levels[cell]; !ok {
+		// the multiplier starts rising from the first request on
+		e.levels[cell] = surgeLevel{multiplier: 1, at: now}
+	}
+}
+
+// Surge returns the surge in the cell of the pickup
+func (e *surgeEngine) Surge(ctx context.Context, pickup *types.Coordinate) *domain.Surge {
+	cell := geohash.EncodeWithPrecision(pickup.Latitude, pickup.Longitude, e.cfg.Precision)
+
+	ctx, cancel := context.WithTimeout(ctx, supplyTimeout)
+	defer cancel()
+
+	supply, err := e.supply.CountAvailableDrivers(ctx, cell)
+
+	now := e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	demand := e.recentDemand(cell, now)
+	surge := &domain.Surge{
+		Geohash:          cell,
+		AvailableDrivers: supply,
+		RecentTrips:      len(demand),
+	}
+
+	if err != nil {
+		// keep the fares where they were rather than guessing the supply
+		log.Printf("failed to get the driver supply of cell %s: %v", cell, err)
+		surge.AvailableDrivers = 0
+		surge.Multiplier = roundMultiplier(e.levels[cell].multiplier)
+		return surge
+	}
+
+	level := e.smooth(cell, e.target(len(demand), supply), now)
+	surge.Multiplier = roundMultiplier(level)
+
+	if len(demand) == 0 && surge.Multiplier <= 1 {
+		delete(e.demand, cell)
+		delete(e.levels, cell)
+	}
+
+	return surge
+}
+
+// target is the multiplier the current supply and demand call for
+func (e *surgeEngine) target(demand, supply int) float64 {
+	ratio := float64(demand) / math.Max(float64(supply), 1)
+	multiplier := 1 + math.Max(ratio-1, 0)*e.cfg.Sensitivity
+
+	return math.Min(multiplier, e.cfg.MaxMultiplier)
+}
+
+// smooth moves the multiplier of the cell towards target, further the longer it was
+// since the last update. Must be called with e.mu held.
+func (e *surgeEngine) smooth(cell string, target float64, now time.Time) float64 {
+	previous, ok := e.levels[cell]
+	if !ok {
+		previous = surgeLevel{multiplier: 1, at: now}
+	}
+
+	weight := 1.0
+	if e.cfg.HalfLife > 0 {
+		weight = 1 - math.Pow(0.5, now.Sub(previous.at).Seconds()/e.cfg.HalfLife.Seconds())
+	}
+
+	multiplier := previous.multiplier + (target-previous.multiplier)*weight
+	e.levels[cell] = surgeLevel{multiplier: multiplier, at: now}
+
+	return multiplier
+}
+
+// recentDemand drops the requests older than the demand window. Must be called with e.mu held.
+func (e *surgeEngine) recentDemand(cell string, now time.Time) []time.Time {
+	requests := e.demand[cell]
+
+	oldest := 0
+	for oldest < len(requests) && now.Sub(requests[oldest]) > e.cfg.DemandWindow {
+		oldest++
+	}
+
+	requests = requests[oldest:]
+	e.demand[cell] = requests
+
+	return requests
+}
+
+// roundMultiplier rounds to a tenth, riders are shown multipliers like 1.3x
+func roundMultiplier(multiplier float64) float64 {
+	return math.Max(math.Round(multiplier*10)/10, 1)
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"ride-sharing/shared/types"
+
+	tripTypes "ride-sharing/services/trip-service/pkg/types"
+)
+
+// fakeClock only moves when told to
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// fakeSupply reports a fixed count of available drivers, or hangs until the call times out
+type fakeSupply struct {
+	mu      sync.Mutex
+	drivers int
+	hang    bool
+}
+
+func (s *fakeSupply) CountAvailableDrivers(ctx context.Context, geohash string) (int, error) {
+	s.mu.Lock()
+	drivers, hang := s.drivers, s.hang
+	s.mu.Unlock()
+
+	if hang {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	return drivers, nil
+}
+
+func (s *fakeSupply) Hang() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hang = true
+}
+
+var testSurgePickup = &types.Coordinate{Latitude: 37.7749, Longitude: -122.4194}
+
+func testSurgeConfig() tripTypes.SurgeConfig {
+	return tripTypes.SurgeConfig{
+		Precision:     5,
+		DemandWindow:  10 * time.Minute,
+		Sensitivity:   0.5,
+		MaxMultiplier: 3,
+		HalfLife:      2 * time.Minute,
+	}
+}
+
+func newTestSurgeEngine(cfg tripTypes.SurgeConfig, drivers int) (*surgeEngine, *fakeClock, *fakeSupply) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	supply := &fakeSupply{drivers: drivers}
+
+	engine := newSurgeEngine(cfg, supply)
+	engine.now = clock.Now
+
+	return engine, clock, supply
+}
+
+func recordTripRequests(engine *surgeEngine, n int) {
+	for range n {
+		engine.RecordTripRequest(testSurgePickup)
+	}
+}
+
+func TestSurgeDemandWindow(t *testing.T) {
+	engine, clock, _ := newTestSurgeEngine(testSurgeConfig(), 1)
+	ctx := context.Background()
+
+	recordTripRequests(engine, 3)
+	clock.Advance(5 * time.Minute)
+	recordTripRequests(engine, 1)
+
+	if got := engine.Surge(ctx, testSurgePickup).RecentTrips; got != 4 {
+		t.Errorf("got %d recent trips, want 4", got)
+	}
+
+	// the first three requests leave the window
+	clock.Advance(6 * time.Minute)
+	if got := engine.Surge(ctx, testSurgePickup).RecentTrips; got != 1 {
+		t.Errorf("got %d recent trips, want 1", got)
+	}
+
+	clock.Advance(10 * time.Minute)
+	if got := engine.Surge(ctx, testSurgePickup).RecentTrips; got != 0 {
+		t.Errorf("got %d recent trips, want none", got)
+	}
+
+	// requests elsewhere don't count
+	recordTripRequests(engine, 1)
+	far := &types.Coordinate{Latitude: testSurgePickup.Latitude + 1, Longitude: testSurgePickup.Longitude}
+	if got := engine.Surge(ctx, far).RecentTrips; got != 0 {
+		t.Errorf("got %d recent trips in another cell, want none", got)
+	}
+}
+
+func TestSurgeHalfLifeSmoothing(t *testing.T) {
+	engine, clock, _ := newTestSurgeEngine(testSurgeConfig(), 1)
+	ctx := context.Background()
+
+	// 5 trips for 1 driver call for 1 + (5 - 1) * 0.5 = 3x
+	recordTripRequests(engine, 5)
+
+	steps := []struct {
+		after time.Duration
+		want  float64
+	}{
+		// no time has passed since the first request
+		{0, 1},
+		// each half-life covers half the way left to 3x
+		{2 * time.Minute, 2},
+		{2 * time.Minute, 2.5},
+		{4 * time.Minute, 2.9},
+	}
+
+	for _, step := range steps {
+		clock.Advance(step.after)
+		if got := engine.Surge(ctx, testSurgePickup).Multiplier; got != step.want {
+			t.Errorf("after %v more: got %vx, want %vx", step.after, got, step.want)
+		}
+	}
+
+	// without a half-life the multiplier follows the target right away
+	cfg := testSurgeConfig()
+	cfg.HalfLife = 0
+	engine, _, _ = newTestSurgeEngine(cfg, 1)
+	recordTripRequests(engine, 5)
+	if got := engine.Surge(ctx, testSurgePickup).Multiplier; got != 3 {
+		t.Errorf("without smoothing: got %vx, want 3x", got)
+	}
+}
+
+func TestSurgeMultiplier(t *testing.T) {
+	tests := []struct {
+		name    string
+		trips   int
+		drivers int
+		want    float64
+	}{
+		{"no demand", 0, 5, 1},
+		{"demand within supply", 5, 5, 1},
+		{"demand above supply", 4, 2, 1.5},
+		// no drivers count as one
+		{"no drivers", 3, 0, 2},
+		// 1 + (20 - 1) * 0.5 = 10.5x, capped
+		{"capped", 20, 1, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testSurgeConfig()
+			cfg.HalfLife = 0
+			engine, _, _ := newTestSurgeEngine(cfg, tt.drivers)
+
+			recordTripRequests(engine, tt.trips)
+			surge := engine.Surge(context.Background(), testSurgePickup)
+
+			if surge.Multiplier != tt.want {
+				t.Errorf("got %vx, want %vx", surge.Multiplier, tt.want)
+			}
+			if surge.AvailableDrivers != tt.drivers || surge.RecentTrips != tt.trips {
+				t.Errorf("got %d trips for %d drivers, want %d for %d", surge.RecentTrips, surge.AvailableDrivers, tt.trips, tt.drivers)
+			}
+		})
+	}
+}
+
+func TestSurgeKeepsMultiplierWhenSupplyTimesOut(t *testing.T) {
+	engine, clock, supply := newTestSurgeEngine(testSurgeConfig(), 1)
+	ctx := context.Background()
+
+	recordTripRequests(engine, 5)
+	clock.Advance(2 * time.Minute)
+	if got := engine.Surge(ctx, testSurgePickup).Multiplier; got != 2 {
+		t.Fatalf("got %vx, want 2x", got)
+	}
+
+	supply.Hang()
+	clock.Advance(2 * time.Minute)
+
+	surge := engine.Surge(ctx, testSurgePickup)
+	if surge.Multiplier != 2 {
+		t.Errorf("got %vx when the supply timed out, want the last 2x", surge.Multiplier)
+	}
+	if surge.AvailableDrivers != 0 || surge.RecentTrips != 5 {
+		t.Errorf("got %d trips for %d drivers, want 5 for an unknown supply", surge.RecentTrips, surge.AvailableDrivers)
+	}
+
+	// a cell without a multiplier yet has no surge
+	far := &types.Coordinate{Latitude: testSurgePickup.Latitude + 1, Longitude: testSurgePickup.Longitude}
+	if got := engine.Surge(ctx, far).Multiplier; got != 1 {
+		t.Errorf("got %vx in a new cell when the supply timed out, want 1x", got)
+	}
+}
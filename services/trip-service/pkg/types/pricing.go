@@ -0,0 +1,130 @@
+package types
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+//go:embed pricing.default.json
+var defaultPricingConfig []byte
+
+type RoundingMode string
+
+const (
+	RoundingNearest RoundingMode = "nearest"
+	RoundingUp      RoundingMode = "up"
+	RoundingDown    RoundingMode = "down"
+)
+
+// PricingConfig holds the rates fares are computed with. Every fare records the version
+// of the config it was priced with, so bump it whenever the rates change.
+type PricingConfig struct {
+	Version  string `json:"version"`
+	Currency string `json:"currency"`
+	// how long riders have to book a trip with the fares they were quoted
+	QuoteValiditySeconds int              `json:"quoteValiditySeconds"`
+	Rounding             RoundingRule     `json:"rounding"`
+	Packages             []PackagePricing `json:"packages"`
+}
+
+// RoundingRule rounds the total price to a multiple of IncrementInCents
+type RoundingRule struct {
+	Mode             RoundingMode `json:"mode"`
+	IncrementInCents float64      `json:"incrementInCents"`
+}
+
+type PackagePricing struct {
+	Slug               string  `json:"slug"`
+	BaseFareInCents    float64 `json:"baseFareInCents"`
+	PerKmInCents       float64 `json:"perKmInCents"`
+	PerMinuteInCents   float64 `json:"perMinuteInCents"`
+	MinimumFareInCents float64 `json:"minimumFareInCents"` // applies to the ride, before the booking fee
+	BookingFeeInCents  float64 `json:"bookingFeeInCents"`
+}
+
+// DefaultPricingConfig returns the pricing config shipped with the service
+func DefaultPricingConfig() *PricingConfig {
+	cfg, err := ParsePricingConfig(defaultPricingConfig)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default pricing config: %v", err))
+	}
+
+	return cfg
+}
+
+// LoadPricingConfig reads the pricing config from a JSON file
+func LoadPricingConfig(path string) (*PricingConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing config: %v", err)
+	}
+
+	return ParsePricingConfig(data)
+}
+
+func ParsePricingConfig(data []byte) (*PricingConfig, error) {
+	var cfg PricingConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse pricing config: %v", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (c *PricingConfig) QuoteValidity() time.Duration {
+	return time.Duration(c.QuoteValiditySeconds) * time.Second
+}
+
+func (c *PricingConfig) Validate() error {
+	if c.Version == "" {
+		return fmt.Errorf("pricing config has no version")
+	}
+
+	if c.Currency == "" {
+		return fmt.Errorf("pricing config %s has no currency", c.Version)
+	}
+
+	if c.QuoteValiditySeconds <= 0 {
+		return fmt.Errorf("pricing config %s must keep quotes valid for some time", c.Version)
+	}
+
+	switch c.Rounding.Mode {
+	case RoundingNearest, RoundingUp, RoundingDown:
+	default:
+		return fmt.Errorf("pricing config %s has unknown rounding mode %q", c.Version, c.Rounding.Mode)
+	}
+
+	if c.Rounding.IncrementInCents < 1 {
+		return fmt.Errorf("pricing config %s must round to at least 1 cent", c.Version)
+	}
+
+	if len(c.Packages) == 0 {
+		return fmt.Errorf("pricing config %s has no packages", c.Version)
+	}
+
+	slugs := make(map[string]struct{}, len(c.Packages))
+	for _, p := range c.Packages {
+		if p.Slug == "" {
+			return fmt.Errorf("pricing config %s has a package without slug", c.Version)
+		}
+
+		if _, duplicate := slugs[p.Slug]; duplicate {
+			return fmt.Errorf("pricing config %s has package %s more than once", c.Version, p.Slug)
+		}
+		slugs[p.Slug] = struct{}{}
+
+		if p.BaseFareInCents < 0 || p.PerKmInCents < 0 || p.PerMinuteInCents < 0 ||
+			p.MinimumFareInCents < 0 || p.BookingFeeInCents < 0 {
+			return fmt.Errorf("pricing config %s has negative rates for package %s", c.Version, p.Slug)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package types
+
+import (
+	"ride-sharing/shared/env"
+	"time"
+)
+
+type SurgeConfig struct {
+	Precision     uint          // geohash precision of the cells supply and demand are compared in
+	DemandWindow  time.Duration // trips requested longer ago than this don't count as demand
+	Sensitivity   float64       // how much the multiplier grows per trip requested above the supply
+	MaxMultiplier float64
+	HalfLife      time.Duration // time it takes the multiplier to cover half the way to a new value
+}
+
+// DefaultSurgeConfig returns the surge settings, overridable through the environment
+func DefaultSurgeConfig() SurgeConfig {
+	return SurgeConfig{
+		Precision:     uint(env.GetInt("SURGE_GEOHASH_PRECISION", 5)),
+		DemandWindow:  time.Duration(env.GetInt("SURGE_DEMAND_WINDOW_SECONDS", 600)) * time.Second,
+		Sensitivity:   env.GetFloat("SURGE_SENSITIVITY", 0.5),
+		MaxMultiplier: env.GetFloat("SURGE_MAX_MULTIPLIER", 3),
+		HalfLife:      time.Duration(env.GetInt("SURGE_HALF_LIFE_SECONDS", 120)) * time.Second,
+	}
+}
@@ -2,16 +2,23 @@ package types
 
 import (
 	pb "ride-sharing/shared/proto/trip"
+	"ride-sharing/shared/types"
 )
 
 type OsrmApiResponse struct {
-	Routes []struct {
-		Distance float64 `json:"distance"`
-		Duration float64 `json:"duration"`
-		Geometry struct {
-			Coordinates [][]float64 `json:"coordinates"`
-		} `json:"geometry"`
-	} `json:"routes"`
+	Code    string      `json:"code"` // "Ok" when a route was found
+	Message string      `json:"message,omitempty"`
+	Routes  []OsrmRoute `json:"routes"`
+}
+
+type OsrmRoute struct {
+	Distance float64      `json:"distance"` // in meters
+	Duration float64      `json:"duration"` // in seconds
+	Geometry OsrmGeometry `json:"geometry"`
+}
+
+type OsrmGeometry struct {
+	Coordinates [][]float64 `json:"coordinates"` // [longitude, latitude] pairs
 }
 
 func (o *OsrmApiResponse) ToProto() *pb.Route {
@@ -38,14 +45,19 @@ func (o *OsrmApiResponse) ToProto() *pb.Route {
 	return r
 }
 
-type PricingConfig struct {
-	PricePerUnitOfDistance float64
-	PricingPerMinute       float64
-}
+// Pickup returns where the route starts. OSRM lists coordinates as [longitude, latitude].
+func (o *OsrmApiResponse) Pickup() (*types.Coordinate, bool) {
+	if len(o.Routes) == 0 || len(o.Routes[0].Geometry.Coordinates) == 0 {
+		return nil, false
+	}
 
-func DefaultPricingConfig() *PricingConfig {
-	return &PricingConfig{
-		PricePerUnitOfDistance: 1.5,
-		PricingPerMinute:       0.25,
+	start := o.Routes[0].Geometry.Coordinates[0]
+	if len(start) < 2 {
+		return nil, false
 	}
+
+	return &types.Coordinate{
+		Latitude:  start[1],
+		Longitude: start[0],
+	}, true
 }
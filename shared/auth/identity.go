@@ -0,0 +1,88 @@
+// Package auth carries the identity of the authenticated user from the api-gateway
+// to the services, through the context and the gRPC metadata.
+package auth
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type Role string
+
+const (
+	RoleRider  Role = "rider"
+	RoleDriver Role = "driver"
+	// RoleService is the role the services call each other with, users never have it
+	RoleService Role = "service"
+)
+
+// IsValid reports whether r is the role of a user
+func (r Role) IsValid() bool {
+	return r == RoleRider || r == RoleDriver
+}
+
+// gRPC metadata keys the identity is sent with
+const (
+	MetadataUserID = "x-user-id"
+	MetadataRole   = "x-user-role"
+)
+
+// Identity is who the request is made by
+type Identity struct {
+	UserID string
+	Role   Role
+}
+
+type identityKey struct{}
+
+// ServiceIdentity is the identity a service calls the other services with
+func ServiceIdentity(name string) Identity {
+	return Identity{UserID: name, Role: RoleService}
+}
+
+// NewContext returns a copy of ctx carrying the identity
+func NewContext(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the identity carried by ctx, if any
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// outgoingContext adds the identity carried by ctx to the metadata of the outgoing gRPC call
+// to method, signed with the service key
+func outgoingContext(ctx context.Context, key []byte, method string) context.Context {
+	identity, ok := FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	issuedAt := time.Now().Unix()
+
+	return metadata.AppendToOutgoingContext(ctx,
+		MetadataUserID, identity.UserID,
+		MetadataRole, string(identity.Role),
+		MetadataIssuedAt, strconv.FormatInt(issuedAt, 10),
+		MetadataSignature, sign(key, identity, method, issuedAt),
+	)
+}
+
+// UnaryClientInterceptor propagates the identity of the context to the called service
+func UnaryClientInterceptor(key []byte) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingContext(ctx, key, method), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor propagates the identity of the context to the called service
+func StreamClientInterceptor(key []byte) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingContext(ctx, key, method), desc, cc, method, opts...)
+	}
+}
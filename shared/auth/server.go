@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrUnauthenticated is returned when the call carries no identity
+	ErrUnauthenticated = errors.New("caller identity is required")
+	// ErrForbidden is returned when the caller acts on behalf of another user, or in a role it doesn't have
+	ErrForbidden = errors.New("caller is not allowed to act on behalf of this user")
+)
+
+// FromIncomingContext reads the identity the api-gateway authenticated from the metadata of
+// an incoming gRPC call to method. The identity must be signed with the service key, anyone
+// else in the cluster could claim to be any user, or a service.
+func FromIncomingContext(ctx context.Context, key []byte, method string) (Identity, bool, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return Identity{}, false, nil
+	}
+
+	userIDs := md.Get(MetadataUserID)
+	if len(userIDs) == 0 || userIDs[0] == "" {
+		return Identity{}, false, nil
+	}
+
+	roles := md.Get(MetadataRole)
+	if len(roles) == 0 || roles[0] == "" {
+		return Identity{}, false, fmt.Errorf("user %s has no role", userIDs[0])
+	}
+
+	identity := Identity{UserID: userIDs[0], Role: Role(roles[0])}
+	if !identity.Role.IsValid() && identity.Role != RoleService {
+		return Identity{}, false, fmt.Errorf("invalid role %q", roles[0])
+	}
+
+	issuedAt, signatures := md.Get(MetadataIssuedAt), md.Get(MetadataSignature)
+	if len(issuedAt) == 0 || len(signatures) == 0 {
+		return Identity{}, false, fmt.Errorf("%w: user %s is unsigned", ErrInvalidSignature, identity.UserID)
+	}
+	if err := verify(key, identity, method, issuedAt[0], signatures[0], time.Now()); err != nil {
+		return Identity{}, false, err
+	}
+
+	return identity, true, nil
+}
+
+// incomingContext puts the identity of the caller in the context of the call
+func incomingContext(ctx context.Context, key []byte, method string) (context.Context, error) {
+	identity, ok, err := FromIncomingContext(ctx, key, method)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid caller identity: %v", err)
+	}
+	if !ok {
+		return ctx, nil
+	}
+
+	return NewContext(ctx, identity), nil
+}
+
+// UnaryServerInterceptor makes the identity of the caller, signed with key, available through FromContext
+func UnaryServerInterceptor(key []byte) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := incomingContext(ctx, key, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor makes the identity of the caller, signed with key, available through FromContext
+func StreamServerInterceptor(key []byte) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := incomingContext(ss.Context(), key, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &identityStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
+
+// Authorize checks the caller is the user, in one of the roles if any are given.
+// Calls without identity are rejected, the services call each other as a service.
+func Authorize(ctx context.Context, userID string, roles ...Role) error {
+	identity, err := authorizeRole(ctx, roles)
+	if err != nil {
+		return err
+	}
+
+	if identity.UserID != userID {
+		return fmt.Errorf("%w: %s can't act on behalf of %s", ErrForbidden, identity.UserID, userID)
+	}
+
+	return nil
+}
+
+// AuthorizeRole checks the caller has one of the roles, whoever it is
+func AuthorizeRole(ctx context.Context, roles ...Role) error {
+	_, err := authorizeRole(ctx, roles)
+	return err
+}
+
+func authorizeRole(ctx context.Context, roles []Role) (Identity, error) {
+	identity, ok := FromContext(ctx)
+	if !ok {
+		return Identity{}, ErrUnauthenticated
+	}
+
+	if len(roles) > 0 && !slices.Contains(roles, identity.Role) {
+		return Identity{}, fmt.Errorf("%w: %s users can't do this", ErrForbidden, identity.Role)
+	}
+
+	return identity, nil
+}
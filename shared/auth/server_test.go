@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthorize(t *testing.T) {
+	rider := NewContext(context.Background(), Identity{UserID: "rider-1", Role: RoleRider})
+	service := NewContext(context.Background(), ServiceIdentity("trip-service"))
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		userID  string
+		roles   []Role
+		wantErr error
+	}{
+		{"the user in their role", rider, "rider-1", []Role{RoleRider}, nil},
+		{"the user in any role", rider, "rider-1", nil, nil},
+		{"no identity", context.Background(), "rider-1", []Role{RoleRider}, ErrUnauthenticated},
+		{"no identity in any role", context.Background(), "rider-1", nil, ErrUnauthenticated},
+		{"another user", rider, "rider-2", []Role{RoleRider}, ErrForbidden},
+		{"another role", rider, "rider-1", []Role{RoleDriver}, ErrForbidden},
+		{"a service on behalf of a user", service, "rider-1", nil, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Authorize(tt.ctx, tt.userID, tt.roles...); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeRole(t *testing.T) {
+	service := NewContext(context.Background(), ServiceIdentity("trip-service"))
+	driver := NewContext(context.Background(), Identity{UserID: "driver-1", Role: RoleDriver})
+
+	if err := AuthorizeRole(service, RoleService); err != nil {
+		t.Errorf("service: got %v, want nil", err)
+	}
+	if err := AuthorizeRole(driver, RoleService); !errors.Is(err, ErrForbidden) {
+		t.Errorf("driver: got %v, want %v", err, ErrForbidden)
+	}
+	if err := AuthorizeRole(context.Background(), RoleService); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("no identity: got %v, want %v", err, ErrUnauthenticated)
+	}
+}
+
+var testServiceKey = []byte("test-service-key-of-at-least-32-bytes")
+
+const testMethod = "/driver.DriverService/CountAvailableDrivers"
+
+// signedMD is the metadata a client signing with key sends for the identity
+func signedMD(key []byte, identity Identity, method string, issuedAt time.Time) metadata.MD {
+	return metadata.Pairs(
+		MetadataUserID, identity.UserID,
+		MetadataRole, string(identity.Role),
+		MetadataIssuedAt, strconv.FormatInt(issuedAt.Unix(), 10),
+		MetadataSignature, sign(key, identity, method, issuedAt.Unix()),
+	)
+}
+
+func TestFromIncomingContextRequiresRole(t *testing.T) {
+	rider := Identity{UserID: "rider-1", Role: RoleRider}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		md      metadata.MD
+		want    Identity
+		wantOK  bool
+		wantErr bool
+	}{
+		{"rider", signedMD(testServiceKey, rider, testMethod, now), rider, true, false},
+		{"service", signedMD(testServiceKey, ServiceIdentity("trip-service"), testMethod, now), ServiceIdentity("trip-service"), true, false},
+		{"no identity", metadata.Pairs(), Identity{}, false, false},
+		{"no role", signedMD(testServiceKey, Identity{UserID: "rider-1"}, testMethod, now), Identity{}, false, true},
+		{"unknown role", signedMD(testServiceKey, Identity{UserID: "rider-1", Role: "admin"}, testMethod, now), Identity{}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := FromIncomingContext(metadata.NewIncomingContext(context.Background(), tt.md), testServiceKey, testMethod)
+			if (err != nil) != tt.wantErr || ok != tt.wantOK || got != tt.want {
+				t.Errorf("got %+v, %v, %v; want %+v, %v, error %v", got, ok, err, tt.want, tt.wantOK, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFromIncomingContextVerifiesSignature(t *testing.T) {
+	service := ServiceIdentity("trip-service")
+	now := time.Now()
+
+	tampered := signedMD(testServiceKey, Identity{UserID: "driver-1", Role: RoleDriver}, testMethod, now)
+	tampered.Set(MetadataRole, string(RoleService))
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+	}{
+		{"unsigned", metadata.Pairs(MetadataUserID, "trip-service", MetadataRole, "service")},
+		{"no issue time", metadata.Pairs(MetadataUserID, "trip-service", MetadataRole, "service", MetadataSignature, sign(testServiceKey, service, testMethod, now.Unix()))},
+		{"invalid issue time", metadata.Pairs(MetadataUserID, "trip-service", MetadataRole, "service", MetadataIssuedAt, "yesterday", MetadataSignature, "x")},
+		{"another key", signedMD([]byte("another-service-key-of-at-least-32-bytes"), service, testMethod, now)},
+		{"another method", signedMD(testServiceKey, service, "/driver.DriverService/GetTripOffer", now)},
+		{"role swapped", tampered},
+		{"stale", signedMD(testServiceKey, service, testMethod, now.Add(-2*maxSignatureAge))},
+		{"from the future", signedMD(testServiceKey, service, testMethod, now.Add(2*maxSignatureAge))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := FromIncomingContext(metadata.NewIncomingContext(context.Background(), tt.md), testServiceKey, testMethod)
+			if !errors.Is(err, ErrInvalidSignature) || ok {
+				t.Errorf("got %+v, %v, %v; want %v", got, ok, err, ErrInvalidSignature)
+			}
+		})
+	}
+}
+
+func TestSignedIdentityRoundTrip(t *testing.T) {
+	identity := Identity{UserID: "driver-1", Role: RoleDriver}
+
+	// what the client interceptor sends is what the server interceptor reads
+	ctx := outgoingContext(NewContext(context.Background(), identity), testServiceKey, testMethod)
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	ctx, err := incomingContext(metadata.NewIncomingContext(context.Background(), md), testServiceKey, testMethod)
+	if err != nil {
+		t.Fatalf("incomingContext: %v", err)
+	}
+	if got, ok := FromContext(ctx); !ok || got != identity {
+		t.Errorf("got %+v, %v; want %+v", got, ok, identity)
+	}
+
+	if _, err := incomingContext(metadata.NewIncomingContext(context.Background(), md), []byte("another-service-key-of-at-least-32-bytes"), testMethod); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("another key: got %v, want %v", err, codes.Unauthenticated)
+	}
+}
+
+func TestLoadServiceKey(t *testing.T) {
+	t.Setenv(ServiceKeyEnv, "too-short")
+	if _, err := LoadServiceKey(); err == nil {
+		t.Errorf("short key: got nil error")
+	}
+
+	t.Setenv(ServiceKeyEnv, string(testServiceKey))
+	if key, err := LoadServiceKey(); err != nil || string(key) != string(testServiceKey) {
+		t.Errorf("got %q, %v; want the key", key, err)
+	}
+}
@@ -13,13 +13,24 @@ const (
 	TripEventDriverAssigned      = "trip.event.driver_assigned"
 	TripEventNoDriversFound      = "trip.event.no_drivers_found"
 	TripEventDriverNotInterested = "trip.event.driver_not_interested"
+	TripEventDriverArriving      = "trip.event.driver_arriving"
+	TripEventStarted             = "trip.event.started"
+	TripEventCompleted           = "trip.event.completed"
+	TripEventCancelled           = "trip.event.cancelled"
+	TripEventPaymentFailed       = "trip.event.payment_failed"
 
 	// Driver commands (driver.cmd.*)
-	DriverCmdTripRequest = "driver.cmd.trip_request"
-	DriverCmdTripAccept  = "driver.cmd.trip_accept"
-	DriverCmdTripDecline = "driver.cmd.trip_decline"
-	DriverCmdLocation    = "driver.cmd.location"
-	DriverCmdRegister    = "driver.cmd.register"
+	DriverCmdTripRequest  = "driver.cmd.trip_request"
+	DriverCmdTripAccept   = "driver.cmd.trip_accept"
+	DriverCmdTripDecline  = "driver.cmd.trip_decline"
+	DriverCmdTripCancel   = "driver.cmd.trip_cancel"
+	DriverCmdTripStart    = "driver.cmd.trip_start"
+	DriverCmdTripComplete = "driver.cmd.trip_complete"
+	DriverCmdLocation     = "driver.cmd.location"
+	DriverCmdRegister     = "driver.cmd.register"
+
+	// Driver events (driver.event.*)
+	DriverEventLocation = "driver.event.location"
 
 	// Payment events (payment.event.*)
 	PaymentEventSessionCreated = "payment.event.session_created"
@@ -0,0 +1,29 @@
+package contracts
+
+// Error codes returned in APIError.Code. Services put them in the reason of the
+// errdetails.ErrorInfo of their gRPC errors so the gateway can forward them as is.
+const (
+	ErrCodeInvalidArgument  = "INVALID_ARGUMENT"
+	ErrCodeNotFound         = "NOT_FOUND"
+	ErrCodePermissionDenied = "PERMISSION_DENIED"
+	ErrCodeConflict         = "CONFLICT"
+	ErrCodeUnauthenticated  = "UNAUTHENTICATED"
+	ErrCodeUnavailable      = "UNAVAILABLE"
+	ErrCodeInternal         = "INTERNAL"
+
+	// Trips
+	ErrCodeTripNotFound          = "TRIP_NOT_FOUND"
+	ErrCodeInvalidTripTransition = "INVALID_TRIP_TRANSITION"
+	ErrCodeNotTripParticipant    = "NOT_TRIP_PARTICIPANT"
+	ErrCodeRideFareNotFound      = "RIDE_FARE_NOT_FOUND"
+	ErrCodeRideFareNotOwned      = "RIDE_FARE_NOT_OWNED"
+	ErrCodeRideFareExpired       = "RIDE_FARE_EXPIRED"
+	ErrCodeRideFareConsumed      = "RIDE_FARE_CONSUMED"
+	ErrCodeNoRoute               = "NO_ROUTE"
+	ErrCodeRoutingUnavailable    = "ROUTING_UNAVAILABLE"
+
+	// Drivers
+	ErrCodeDriverNotFound    = "DRIVER_NOT_FOUND"
+	ErrCodeDriverUnavailable = "DRIVER_UNAVAILABLE"
+	ErrCodeTripNotOffered    = "TRIP_NOT_OFFERED"
+)
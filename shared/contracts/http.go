@@ -8,6 +8,13 @@ type APIResponse struct {
 
 // APIError is the error structure for the API.
 type APIError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    string           `json:"code"`
+	Message string           `json:"message"`
+	Details []APIErrorDetail `json:"details,omitempty"`
+}
+
+// APIErrorDetail points at the field of the request that caused the error.
+type APIErrorDetail struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
 }
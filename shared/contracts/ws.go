@@ -4,6 +4,9 @@ import "encoding/json"
 
 // WSMessage is the message structure for the WebSocket.
 type WSMessage struct {
+	// ID numbers the messages kept in the outbox of the user, in the order they were sent.
+	// Clients pass the last one they saw as lastEventID when reconnecting to get the ones they missed.
+	ID   uint64 `json:"id,omitempty"`
 	Type string `json:"type"`
 	Data any    `json:"data"`
 }
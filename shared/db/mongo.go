@@ -0,0 +1,58 @@
+/*
+Package db provides helpers to connect to the databases used by the services.
+*/
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ride-sharing/shared/env"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+type MongoConfig struct {
+	URI            string
+	Database       string
+	ConnectTimeout time.Duration
+}
+
+// NewMongoDefaultConfig returns a MongoConfig populated from the environment
+func NewMongoDefaultConfig() *MongoConfig {
+	return &MongoConfig{
+		URI:            env.GetString("MONGODB_URI", "mongodb://localhost:27017"),
+		Database:       env.GetString("MONGODB_DATABASE", "ride-sharing"),
+		ConnectTimeout: 10 * time.Second,
+	}
+}
+
+// NewMongoClient connects to MongoDB and verifies the connection with a ping
+func NewMongoClient(ctx context.Context, cfg *MongoConfig) (*mongo.Client, error) {
+	if cfg.URI == "" {
+		return nil, fmt.Errorf("mongodb URI is required")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.URI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mongodb: %v", err)
+	}
+
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to ping mongodb: %v", err)
+	}
+
+	return client, nil
+}
+
+// GetDatabase returns the database configured in cfg
+func GetDatabase(client *mongo.Client, cfg *MongoConfig) *mongo.Database {
+	return client.Database(cfg.Database)
+}
@@ -0,0 +1,88 @@
+// Package grpcerr builds and reads the gRPC errors services return, which carry
+// an error code from the contracts in an errdetails.ErrorInfo.
+package grpcerr
+
+import (
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const errorDomain = "ride-sharing"
+
+// FieldViolation describes why a field of the request is invalid
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// Details are what the gateway needs to describe a gRPC error to API clients
+type Details struct {
+	Code       codes.Code
+	Reason     string // one of the contracts.ErrCode* codes, empty if the service didn't give one
+	Message    string
+	Violations []FieldViolation
+}
+
+// New returns a gRPC error with the given code, carrying reason in an errdetails.ErrorInfo
+func New(code codes.Code, reason string, format string, args ...any) error {
+	st := status.New(code, fmt.Sprintf(format, args...))
+
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: errorDomain,
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// InvalidArgument returns an InvalidArgument error listing the invalid fields of the request
+func InvalidArgument(reason string, violations ...FieldViolation) error {
+	st := status.New(codes.InvalidArgument, "invalid request")
+
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}, badRequest)
+	if err != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// Parse extracts the details of a gRPC error. Errors that aren't gRPC errors are Unknown.
+func Parse(err error) Details {
+	st := status.Convert(err)
+
+	details := Details{
+		Code:    st.Code(),
+		Message: st.Message(),
+	}
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			details.Reason = d.GetReason()
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				details.Violations = append(details.Violations, FieldViolation{
+					Field:       v.GetField(),
+					Description: v.GetDescription(),
+				})
+			}
+		}
+	}
+
+	return details
+}
@@ -5,26 +5,91 @@ import (
 	"log"
 	"net/http"
 	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/env"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
 var (
 	ErrConnectionNotFound = errors.New("connection not found")
+	ErrAlreadyConnected   = errors.New("user already connected")
 )
 
+// ConnectionPolicy tells what happens when a user connects while already connected
+type ConnectionPolicy string
+
+const (
+	// KickOldConnection closes the previous connections of the user for the new one
+	KickOldConnection ConnectionPolicy = "kick_old"
+	// MultipleConnections keeps every connection of the user, the messages go to all of them
+	MultipleConnections ConnectionPolicy = "multi_device"
+	// RejectDuplicateConnection refuses the new connection while the user has one
+	RejectDuplicateConnection ConnectionPolicy = "reject_duplicate"
+)
+
+func (p ConnectionPolicy) IsValid() bool {
+	switch p {
+	case KickOldConnection, MultipleConnections, RejectDuplicateConnection:
+		return true
+	}
+	return false
+}
+
 // connWrapper is a wrapper around the websocket connection to allow for thread-safe operations
 // This is necessary because the websocket connection is not thread-safe
 
 type connWrapper struct {
+	id    uint64
 	conn  *websocket.Conn
 	mutex sync.Mutex
+	done  chan struct{} // closed once the connection is removed, stops the pings
 }
 
 type ConnectionManager struct {
-	connections map[string]*connWrapper // Local connections storage (userId -> connection)
+	connections map[string]map[uint64]*connWrapper // Local connections storage (userId -> connection ID -> connection)
+	lastID      uint64
 	mutex       sync.RWMutex
+	heartbeat   HeartbeatConfig
+	policy      ConnectionPolicy
+}
+
+// HeartbeatConfig tells how the connections are kept alive: a connection whose client
+// doesn't answer the pings within IdleTimeout fails its reads, so its handler cleans up
+type HeartbeatConfig struct {
+	PingInterval time.Duration
+	IdleTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// DefaultHeartbeatConfig returns the heartbeat settings, overridable through the environment
+func DefaultHeartbeatConfig() HeartbeatConfig {
+	cfg := HeartbeatConfig{
+		PingInterval: time.Duration(env.GetInt("WS_PING_INTERVAL_SECONDS", 25)) * time.Second,
+		IdleTimeout:  time.Duration(env.GetInt("WS_IDLE_TIMEOUT_SECONDS", 60)) * time.Second,
+		WriteTimeout: time.Duration(env.GetInt("WS_WRITE_TIMEOUT_SECONDS", 10)) * time.Second,
+	}
+
+	// the client needs a ping before the connection is considered idle
+	if cfg.PingInterval >= cfg.IdleTimeout {
+		log.Printf("WebSocket ping interval %s isn't shorter than the idle timeout %s, pinging every %s",
+			cfg.PingInterval, cfg.IdleTimeout, cfg.IdleTimeout/2)
+		cfg.PingInterval = cfg.IdleTimeout / 2
+	}
+
+	return cfg
+}
+
+// DefaultConnectionPolicy returns the policy set in the environment, kicking the old connections by default
+func DefaultConnectionPolicy() ConnectionPolicy {
+	policy := ConnectionPolicy(env.GetString("WS_CONNECTION_POLICY", string(KickOldConnection)))
+	if !policy.IsValid() {
+		log.Printf("Unknown websocket connection policy %q, using %s", policy, KickOldConnection)
+		return KickOldConnection
+	}
+
+	return policy
 }
 
 var upgrader = websocket.Upgrader{
@@ -33,13 +98,21 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// Note that on multiple instances of the API gateway, the connection manager needs to store the connections on a separate shared storage.
-func NewConnectionManager() *ConnectionManager {
+// The connection manager only knows the connections of its own gateway instance,
+// the WSRouter takes the messages to the instance the user is connected to.
+func NewConnectionManager(heartbeat HeartbeatConfig, policy ConnectionPolicy) *ConnectionManager {
 	return &ConnectionManager{
-		connections: make(map[string]*connWrapper),
+		connections: make(map[string]map[uint64]*connWrapper),
+		heartbeat:   heartbeat,
+		policy:      policy,
 	}
 }
 
+// Policy returns what happens when a user connects while already connected
+func (cm *ConnectionManager) Policy() ConnectionPolicy {
+	return cm.policy
+}
+
 func (cm *ConnectionManager) Upgrade(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -49,52 +122,192 @@ func (cm *ConnectionManager) Upgrade(w http.ResponseWriter, r *http.Request) (*w
 	return conn, nil
 }
 
-func (cm *ConnectionManager) Add(id string, conn *websocket.Conn) {
+// Add registers the connection of the user and keeps it alive with pings,
+// the reads of a connection whose client stops answering them fail.
+// It returns the ID of the connection, to remove it once it is closed, and whether
+// the user had no other connection, or ErrAlreadyConnected when the policy rejects
+// duplicate connections.
+func (cm *ConnectionManager) Add(id string, conn *websocket.Conn) (uint64, bool, error) {
 
 	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
 
-	cm.connections[id] = &connWrapper{
+	var kicked []*connWrapper
+	userConns := cm.connections[id]
+	first := len(userConns) == 0
+	if !first {
+		switch cm.policy {
+		case RejectDuplicateConnection:
+			cm.mutex.Unlock()
+			return 0, false, ErrAlreadyConnected
+		case KickOldConnection:
+			for connID, old := range userConns {
+				kicked = append(kicked, old)
+				delete(userConns, connID)
+			}
+		}
+	}
+	if userConns == nil {
+		userConns = make(map[uint64]*connWrapper)
+		cm.connections[id] = userConns
+	}
+
+	// every answer to a ping (read along with the messages) gives the client more time
+	conn.SetReadDeadline(time.Now().Add(cm.heartbeat.IdleTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(cm.heartbeat.IdleTimeout))
+	})
+
+	cm.lastID++
+	wrapper := &connWrapper{
+		id:    cm.lastID,
 		conn:  conn,
 		mutex: sync.Mutex{},
+		done:  make(chan struct{}),
+	}
+	userConns[wrapper.id] = wrapper
+	count := len(userConns)
+
+	cm.mutex.Unlock()
+
+	// closing the replaced connections may wait on their writes, the lock isn't held for that
+	for _, old := range kicked {
+		cm.kick(id, old)
+	}
+
+	go cm.ping(id, wrapper)
+
+	log.Printf("Added connection %d for user %s (%d connections)", wrapper.id, id, count)
+
+	return wrapper.id, first, nil
+}
+
+// kick closes the connection replaced by a new one of the user, its handler cleans up
+// once its reads fail
+func (cm *ConnectionManager) kick(id string, wrapper *connWrapper) {
+	close(wrapper.done)
+
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "connected from another device")
+	if err := wrapper.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(cm.heartbeat.WriteTimeout)); err != nil {
+		log.Printf("Failed to tell user %s their connection %d is replaced: %v", id, wrapper.id, err)
+	}
+	wrapper.conn.Close()
+
+	log.Printf("Closed connection %d of user %s, replaced by a new one", wrapper.id, id)
+}
+
+// KickAll closes every connection of the user to this instance, when they connected to another
+// instance. Their handlers clean up once their reads fail, as for replaced connections.
+func (cm *ConnectionManager) KickAll(id string) {
+	cm.mutex.Lock()
+	kicked := make([]*connWrapper, 0, len(cm.connections[id]))
+	for _, wrapper := range cm.connections[id] {
+		kicked = append(kicked, wrapper)
 	}
+	delete(cm.connections, id)
+	cm.mutex.Unlock()
 
-	log.Printf("Added connection for user %s", id)
+	for _, wrapper := range kicked {
+		cm.kick(id, wrapper)
+	}
 }
 
-func (cm *ConnectionManager) Remove(id string) {
+// Remove forgets the connection of the user, the other connections of the user aren't affected.
+// It returns whether it was the last connection of the user.
+func (cm *ConnectionManager) Remove(id string, connID uint64) bool {
 
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
+	wrapper, exists := cm.connections[id][connID]
+	if !exists {
+		// already replaced by a newer connection
+		return false
+	}
+
+	close(wrapper.done)
+	delete(cm.connections[id], connID)
+	if len(cm.connections[id]) > 0 {
+		return false
+	}
+
 	delete(cm.connections, id)
+	return true
 }
 
-func (cm *ConnectionManager) Get(id string) (*websocket.Conn, bool) {
+// Connected tells whether the user has a connection to this gateway instance
+func (cm *ConnectionManager) Connected(id string) bool {
 
 	cm.mutex.RLock()
 	defer cm.mutex.RUnlock()
 
-	wrapper, exists := cm.connections[id]
-	if !exists {
-		return nil, false
-	}
+	return len(cm.connections[id]) > 0
+}
+
+// ping sends the pings of the connection until it is removed or can't be written to
+func (cm *ConnectionManager) ping(id string, wrapper *connWrapper) {
+	ticker := time.NewTicker(cm.heartbeat.PingInterval)
+	defer ticker.Stop()
 
-	return wrapper.conn, true
+	for {
+		select {
+		case <-wrapper.done:
+			return
+		case <-ticker.C:
+			// control messages may be written along with the other messages
+			if err := wrapper.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(cm.heartbeat.WriteTimeout)); err != nil {
+				log.Printf("Failed to ping user %s, closing their connection: %v", id, err)
+				// unblocks the reads, so the handler of the connection cleans up
+				wrapper.conn.Close()
+				return
+			}
+		}
+	}
 }
 
+// SendMessage writes the message to every connection of the user, it only fails when
+// none of them could be written to
 func (cm *ConnectionManager) SendMessage(id string, message contracts.WSMessage) error {
 
 	cm.mutex.RLock()
-	wrapper, exists := cm.connections[id]
+	wrappers := make([]*connWrapper, 0, len(cm.connections[id]))
+	for _, wrapper := range cm.connections[id] {
+		wrappers = append(wrappers, wrapper)
+	}
 	cm.mutex.RUnlock()
 
-	if !exists {
+	if len(wrappers) == 0 {
 		return ErrConnectionNotFound
 	}
 
+	var lastErr error
+	sent := 0
+	for _, wrapper := range wrappers {
+		if err := cm.write(wrapper, message); err != nil {
+			log.Printf("Failed to send message to connection %d of user %s: %v", wrapper.id, id, err)
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+
+	if sent == 0 {
+		return lastErr
+	}
+
+	return nil
+}
+
+func (cm *ConnectionManager) write(wrapper *connWrapper, message contracts.WSMessage) error {
 	wrapper.mutex.Lock()
 	defer wrapper.mutex.Unlock()
 
-	return wrapper.conn.WriteJSON(message)
+	// a client that stopped reading mustn't block the senders
+	wrapper.conn.SetWriteDeadline(time.Now().Add(cm.heartbeat.WriteTimeout))
+	if err := wrapper.conn.WriteJSON(message); err != nil {
+		// the connection is unusable after a failed write, the reads fail once it is closed
+		wrapper.conn.Close()
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,139 @@
+package messaging
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestConns returns the server side of n websocket connections
+func newTestConns(t *testing.T, n int) []*websocket.Conn {
+	t.Helper()
+
+	conns := make(chan *websocket.Conn, n)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	var serverConns []*websocket.Conn
+	for range n {
+		client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { client.Close() })
+
+		conn := <-conns
+		t.Cleanup(func() { conn.Close() })
+		serverConns = append(serverConns, conn)
+	}
+
+	return serverConns
+}
+
+func testHeartbeatConfig() HeartbeatConfig {
+	return HeartbeatConfig{PingInterval: time.Minute, IdleTimeout: 2 * time.Minute, WriteTimeout: time.Second}
+}
+
+func TestConnectionManagerFirstAndLastConnection(t *testing.T) {
+	t.Run("multiple connections", func(t *testing.T) {
+		cm := NewConnectionManager(testHeartbeatConfig(), MultipleConnections)
+		conns := newTestConns(t, 2)
+
+		firstID, first, err := cm.Add("driver-1", conns[0])
+		if err != nil || !first {
+			t.Fatalf("first Add: got first %v, error %v, want the first connection", first, err)
+		}
+		secondID, first, err := cm.Add("driver-1", conns[1])
+		if err != nil || first {
+			t.Fatalf("second Add: got first %v, error %v, want another connection", first, err)
+		}
+
+		if cm.Remove("driver-1", firstID) {
+			t.Errorf("Remove of the first connection was the last, the second is still open")
+		}
+		if !cm.Remove("driver-1", secondID) {
+			t.Errorf("Remove of the second connection wasn't the last")
+		}
+		if cm.Connected("driver-1") {
+			t.Errorf("driver still connected after removing every connection")
+		}
+	})
+
+	t.Run("kick old connection", func(t *testing.T) {
+		cm := NewConnectionManager(testHeartbeatConfig(), KickOldConnection)
+		conns := newTestConns(t, 2)
+
+		oldID, _, err := cm.Add("driver-1", conns[0])
+		if err != nil {
+			t.Fatalf("first Add: %v", err)
+		}
+		newID, first, err := cm.Add("driver-1", conns[1])
+		if err != nil || first {
+			t.Fatalf("second Add: got first %v, error %v, want the replacing connection", first, err)
+		}
+
+		// the kicked connection cleans up after being replaced
+		if cm.Remove("driver-1", oldID) {
+			t.Errorf("Remove of the kicked connection was the last, it was replaced")
+		}
+		if !cm.Connected("driver-1") {
+			t.Errorf("removing the kicked connection removed the new one")
+		}
+		if !cm.Remove("driver-1", newID) {
+			t.Errorf("Remove of the new connection wasn't the last")
+		}
+	})
+
+	t.Run("kicked from another instance", func(t *testing.T) {
+		cm := NewConnectionManager(testHeartbeatConfig(), MultipleConnections)
+		conns := newTestConns(t, 2)
+
+		var connIDs []uint64
+		for _, conn := range conns {
+			connID, _, err := cm.Add("driver-1", conn)
+			if err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+			connIDs = append(connIDs, connID)
+		}
+
+		cm.KickAll("driver-1")
+		if cm.Connected("driver-1") {
+			t.Errorf("driver still connected after being kicked")
+		}
+
+		// the kicked connections are closed, their handlers clean up without it being their last
+		for i, conn := range conns {
+			if _, _, err := conn.ReadMessage(); err == nil {
+				t.Errorf("read on kicked connection %d succeeded", i)
+			}
+			if cm.Remove("driver-1", connIDs[i]) {
+				t.Errorf("Remove of kicked connection %d was the last", i)
+			}
+		}
+	})
+
+	t.Run("reject duplicate connection", func(t *testing.T) {
+		cm := NewConnectionManager(testHeartbeatConfig(), RejectDuplicateConnection)
+		conns := newTestConns(t, 2)
+
+		if _, _, err := cm.Add("driver-1", conns[0]); err != nil {
+			t.Fatalf("first Add: %v", err)
+		}
+		if _, _, err := cm.Add("driver-1", conns[1]); !errors.Is(err, ErrAlreadyConnected) {
+			t.Errorf("second Add: got %v, want %v", err, ErrAlreadyConnected)
+		}
+	})
+}
@@ -11,10 +11,23 @@ const (
 	DriverTripResponseQueue         = "driver_trip_response"
 	NotifyDriverNoDriversFoundQueue = "notify_driver_no_drivers_found"
 	NotifyDriverAssignQueue         = "notify_driver_assign"
+	NotifyTripCancelledQueue        = "notify_trip_cancelled"
+	NotifyDriverArrivingQueue       = "notify_driver_arriving"
+	NotifyTripStartedQueue          = "notify_trip_started"
+	NotifyTripCompletedQueue        = "notify_trip_completed"
+	NotifyTripPaymentFailedQueue    = "notify_trip_payment_failed"
+	DriverTripEventsQueue           = "driver_trip_events"
+	NotifyDriverLocationQueue       = "notify_driver_location"
+
+	PaymentTripResponseQueue         = "payment_trip_response"
+	NotifyPaymentSessionCreatedQueue = "notify_payment_session_created"
+	TripPaymentEventsQueue           = "trip_payment_events"
 )
 
 type TripEventData struct {
 	Trip *pb.Trip `json:"trip"`
+	// DriverID is the driver the event is about, e.g. the one not interested in the trip
+	DriverID string `json:"driverID,omitempty"`
 }
 
 type DriverTripResponseData struct {
@@ -22,3 +35,30 @@ type DriverTripResponseData struct {
 	TripID  string      `json:"tripID"`
 	RiderID string      `json:"riderID"`
 }
+
+type DriverLocationData struct {
+	TripID string      `json:"tripID"`
+	Driver *pbd.Driver `json:"driver"`
+}
+
+type PaymentTripResponseData struct {
+	TripID   string `json:"tripID"`
+	UserID   string `json:"userID"`
+	DriverID string `json:"driverID"`
+	Amount   int64  `json:"amount"` // in cents
+	Currency string `json:"currency,omitempty"`
+}
+
+type PaymentEventSessionCreatedData struct {
+	TripID    string `json:"tripID"`
+	SessionID string `json:"sessionID"`
+	Amount    int64  `json:"amount"` // in cents
+	Currency  string `json:"currency"`
+}
+
+type PaymentStatusUpdateData struct {
+	TripID    string `json:"tripID"`
+	UserID    string `json:"userID"`
+	DriverID  string `json:"driverID"`
+	SessionID string `json:"sessionID"`
+}
@@ -0,0 +1,153 @@
+package messaging
+
+import (
+	"context"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/env"
+	"sync"
+	"time"
+)
+
+// OutboxEvent is a message sent to a user, numbered in the order the user was sent them
+type OutboxEvent struct {
+	Seq       uint64
+	Message   contracts.WSMessage
+	CreatedAt time.Time
+}
+
+type OutboxConfig struct {
+	MaxEvents int           // events kept per user, the oldest are dropped first
+	Retention time.Duration // how long events are kept
+}
+
+// DefaultOutboxConfig returns the outbox settings, overridable through the environment
+func DefaultOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		MaxEvents: env.GetInt("OUTBOX_MAX_EVENTS", 100),
+		Retention: time.Duration(env.GetInt("OUTBOX_RETENTION_MINUTES", 60)) * time.Minute,
+	}
+}
+
+// Outbox keeps the recent messages of every user, so the ones they missed while
+// disconnected can be replayed when they reconnect
+type Outbox interface {
+	// Append numbers the message and stores it, returning its sequence number
+	Append(ctx context.Context, userID string, message contracts.WSMessage) (uint64, error)
+	// Delivered records the message was written to a connection of the user
+	Delivered(ctx context.Context, userID string, seq uint64) error
+	// Cursor returns the sequence number of the last message of the user and of the last one delivered
+	Cursor(ctx context.Context, userID string) (last uint64, delivered uint64, err error)
+	// After returns the stored events of the user numbered after seq, oldest first
+	After(ctx context.Context, userID string, seq uint64) ([]OutboxEvent, error)
+}
+
+type userOutbox struct {
+	events    []OutboxEvent // oldest first
+	last      uint64
+	delivered uint64
+	updatedAt time.Time
+}
+
+// inmemOutbox keeps the events in memory, which only suits a single gateway instance
+type inmemOutbox struct {
+	cfg       OutboxConfig
+	users     map[string]*userOutbox
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+func NewInmemOutbox(cfg OutboxConfig) *inmemOutbox {
+	return &inmemOutbox{
+		cfg:       cfg,
+		users:     make(map[string]*userOutbox),
+		lastSweep: time.Now(),
+	}
+}
+
+func (o *inmemOutbox) Append(ctx context.Context, userID string, message contracts.WSMessage) (uint64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	o.sweep(now)
+
+	user, ok := o.users[userID]
+	if !ok {
+		user = &userOutbox{}
+		o.users[userID] = user
+	}
+
+	user.last++
+	user.updatedAt = now
+	user.events = append(user.events, OutboxEvent{Seq: user.last, Message: message, CreatedAt: now})
+	if len(user.events) > o.cfg.MaxEvents {
+		user.events = user.events[len(user.events)-o.cfg.MaxEvents:]
+	}
+
+	return user.last, nil
+}
+
+func (o *inmemOutbox) Delivered(ctx context.Context, userID string, seq uint64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if user, ok := o.users[userID]; ok && seq > user.delivered {
+		user.delivered = seq
+	}
+
+	return nil
+}
+
+func (o *inmemOutbox) Cursor(ctx context.Context, userID string) (uint64, uint64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	user, ok := o.users[userID]
+	if !ok {
+		return 0, 0, nil
+	}
+
+	return user.last, user.delivered, nil
+}
+
+func (o *inmemOutbox) After(ctx context.Context, userID string, seq uint64) ([]OutboxEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	user, ok := o.users[userID]
+	if !ok {
+		return nil, nil
+	}
+
+	expiredBefore := time.Now().Add(-o.cfg.Retention)
+
+	var events []OutboxEvent
+	for _, event := range user.events {
+		if event.Seq > seq && event.CreatedAt.After(expiredBefore) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// sweep drops the expired events, and the users without any once they're idle for the retention
+func (o *inmemOutbox) sweep(now time.Time) {
+	if now.Sub(o.lastSweep) < o.cfg.Retention/10 {
+		return
+	}
+	o.lastSweep = now
+
+	expiredBefore := now.Add(-o.cfg.Retention)
+	for userID, user := range o.users {
+		i := 0
+		for i < len(user.events) && user.events[i].CreatedAt.Before(expiredBefore) {
+			i++
+		}
+		user.events = user.events[i:]
+
+		if len(user.events) == 0 && user.updatedAt.Before(expiredBefore) {
+			delete(o.users, userID)
+		}
+	}
+}
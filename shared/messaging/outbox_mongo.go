@@ -0,0 +1,165 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ride-sharing/shared/contracts"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	OutboxEventsCollection = "ws_outbox_events"
+	// OutboxUsersCollection holds the sequence numbers of every user, kept after their events
+	// expire so the numbers keep increasing
+	OutboxUsersCollection = "ws_outbox_users"
+)
+
+type outboxEventDocument struct {
+	UserID    string    `bson:"userID"`
+	Seq       uint64    `bson:"seq"`
+	Type      string    `bson:"type"`
+	Data      string    `bson:"data"` // JSON, kept as is
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+type outboxUserDocument struct {
+	UserID    string    `bson:"_id"`
+	Last      uint64    `bson:"last"`
+	Delivered uint64    `bson:"delivered"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+// mongoOutbox keeps the events in MongoDB, shared by all the gateway instances
+type mongoOutbox struct {
+	db  *mongo.Database
+	cfg OutboxConfig
+}
+
+func NewMongoOutbox(ctx context.Context, db *mongo.Database, cfg OutboxConfig) (*mongoOutbox, error) {
+	o := &mongoOutbox{
+		db:  db,
+		cfg: cfg,
+	}
+
+	if _, err := db.Collection(OutboxEventsCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userID", Value: 1}, {Key: "seq", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(cfg.Retention.Seconds())),
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create %s indexes: %v", OutboxEventsCollection, err)
+	}
+
+	return o, nil
+}
+
+func (o *mongoOutbox) Append(ctx context.Context, userID string, message contracts.WSMessage) (uint64, error) {
+	data, err := json.Marshal(message.Data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	// numbering through the user document keeps the sequence consistent across the gateway instances
+	var user outboxUserDocument
+	if err := o.db.Collection(OutboxUsersCollection).FindOneAndUpdate(ctx,
+		bson.M{"_id": userID},
+		bson.M{
+			"$inc":         bson.M{"last": 1},
+			"$set":         bson.M{"updatedAt": time.Now()},
+			"$setOnInsert": bson.M{"delivered": 0},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&user); err != nil {
+		return 0, fmt.Errorf("failed to number message of user %s: %v", userID, err)
+	}
+
+	if _, err := o.db.Collection(OutboxEventsCollection).InsertOne(ctx, outboxEventDocument{
+		UserID:    userID,
+		Seq:       user.Last,
+		Type:      message.Type,
+		Data:      string(data),
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return 0, fmt.Errorf("failed to store message of user %s: %v", userID, err)
+	}
+
+	if user.Last > uint64(o.cfg.MaxEvents) {
+		if _, err := o.db.Collection(OutboxEventsCollection).DeleteMany(ctx, bson.M{
+			"userID": userID,
+			"seq":    bson.M{"$lte": user.Last - uint64(o.cfg.MaxEvents)},
+		}); err != nil {
+			return 0, fmt.Errorf("failed to drop old messages of user %s: %v", userID, err)
+		}
+	}
+
+	return user.Last, nil
+}
+
+func (o *mongoOutbox) Delivered(ctx context.Context, userID string, seq uint64) error {
+	if _, err := o.db.Collection(OutboxUsersCollection).UpdateOne(ctx,
+		bson.M{"_id": userID, "delivered": bson.M{"$lt": seq}},
+		bson.M{"$set": bson.M{"delivered": seq}},
+	); err != nil {
+		return fmt.Errorf("failed to record delivery to user %s: %v", userID, err)
+	}
+
+	return nil
+}
+
+func (o *mongoOutbox) Cursor(ctx context.Context, userID string) (uint64, uint64, error) {
+	var user outboxUserDocument
+	err := o.db.Collection(OutboxUsersCollection).FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get the outbox of user %s: %v", userID, err)
+	}
+
+	return user.Last, user.Delivered, nil
+}
+
+func (o *mongoOutbox) After(ctx context.Context, userID string, seq uint64) ([]OutboxEvent, error) {
+	cursor, err := o.db.Collection(OutboxEventsCollection).Find(ctx,
+		bson.M{
+			"userID": userID,
+			"seq":    bson.M{"$gt": seq},
+			// the TTL monitor only runs every minute
+			"createdAt": bson.M{"$gt": time.Now().Add(-o.cfg.Retention)},
+		},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the messages of user %s: %v", userID, err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []outboxEventDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode the messages of user %s: %v", userID, err)
+	}
+
+	events := make([]OutboxEvent, 0, len(docs))
+	for _, doc := range docs {
+		events = append(events, OutboxEvent{
+			Seq: doc.Seq,
+			Message: contracts.WSMessage{
+				Type: doc.Type,
+				Data: json.RawMessage(doc.Data),
+			},
+			CreatedAt: doc.CreatedAt,
+		})
+	}
+
+	return events, nil
+}
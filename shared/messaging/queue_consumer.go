@@ -1,30 +1,36 @@
 package messaging
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 
 	"ride-sharing/shared/contracts"
 )
 
+// QueueConsumer forwards the messages of a queue to the websockets of their owners,
+// through the gateway instance each owner is connected to
 type QueueConsumer struct {
 	rb        *RabbitMQ
-	connMgr   *ConnectionManager
+	router    *WSRouter
 	queueName string
 }
 
-func NewQueueConsumer(rb *RabbitMQ, connMgr *ConnectionManager, queueName string) *QueueConsumer {
+func NewQueueConsumer(rb *RabbitMQ, router *WSRouter, queueName string) *QueueConsumer {
 	return &QueueConsumer{
 		rb:        rb,
-		connMgr:   connMgr,
+		router:    router,
 		queueName: queueName,
 	}
 }
 
+// Start forwards the messages of the queue until Stop is called. A single consumer per queue
+// and gateway instance is enough, whichever instance gets a message routes it to its owner.
 func (qc *QueueConsumer) Start() error {
 	msgs, err := qc.rb.Channel.Consume(
 		qc.queueName,
-		"",
+		qc.consumerTag(),
 		true,
 		false,
 		false,
@@ -44,6 +50,10 @@ func (qc *QueueConsumer) Start() error {
 			}
 
 			userID := msgBody.OwnerID
+			if userID == "" {
+				log.Printf("Dropping %s message without owner", msg.RoutingKey)
+				continue
+			}
 
 			var payload any
 			if msgBody.Data != nil {
@@ -58,11 +68,20 @@ func (qc *QueueConsumer) Start() error {
 				Data: payload,
 			}
 
-			if err := qc.connMgr.SendMessage(userID, clientMsg); err != nil {
-				log.Printf("Failed to send message to user %s: %v", userID, err)
+			if err := qc.router.Send(context.Background(), userID, clientMsg); err != nil {
+				log.Printf("Failed to route message to user %s: %v", userID, err)
 			}
 		}
 	}()
 
 	return nil
 }
+
+// Stop cancels the consumer, the messages left in the queue are forwarded by the other instances
+func (qc *QueueConsumer) Stop() error {
+	return qc.rb.Channel.Cancel(qc.consumerTag(), false)
+}
+
+func (qc *QueueConsumer) consumerTag() string {
+	return fmt.Sprintf("%s.%s", qc.router.instanceID, qc.queueName)
+}
@@ -12,6 +12,8 @@ import (
 
 const (
 	TripExchange = "trip"
+	// GatewayExchange routes the websocket messages to the gateway instance of their user, by user ID
+	GatewayExchange = "gateway"
 )
 
 type RabbitMQ struct {
@@ -141,6 +143,18 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 		return fmt.Errorf("failed to declare an exchange: %v", err)
 	}
 
+	if err := r.Channel.ExchangeDeclare(
+		GatewayExchange, // name
+		"direct",        // type
+		true,            // durable
+		false,           // delete when unused
+		false,           // internal
+		false,           // no-wait
+		nil,             // arguments
+	); err != nil {
+		return fmt.Errorf("failed to declare an exchange: %v", err)
+	}
+
 	if err := r.declareAndBindQueue(
 		FindAvailableDriverQueue,
 		[]string{
@@ -156,6 +170,7 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 		DriverCmdTripRequestQueue,
 		[]string{
 			contracts.DriverCmdTripRequest,
+			contracts.DriverCmdTripCancel,
 		},
 		TripExchange,
 	); err != nil {
@@ -166,6 +181,7 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 		DriverTripResponseQueue,
 		[]string{
 			contracts.DriverCmdTripAccept, contracts.DriverCmdTripDecline,
+			contracts.DriverCmdTripStart, contracts.DriverCmdTripComplete,
 		},
 		TripExchange,
 	); err != nil {
@@ -192,6 +208,112 @@ func (r *RabbitMQ) setupExchangesAndQueues() error {
 		return err
 	}
 
+	if err := r.declareAndBindQueue(
+		NotifyTripCancelledQueue,
+		[]string{
+			contracts.TripEventCancelled,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		NotifyDriverArrivingQueue,
+		[]string{
+			contracts.TripEventDriverArriving,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		NotifyTripStartedQueue,
+		[]string{
+			contracts.TripEventStarted,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		NotifyTripCompletedQueue,
+		[]string{
+			contracts.TripEventCompleted,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		NotifyTripPaymentFailedQueue,
+		[]string{
+			contracts.TripEventPaymentFailed,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		NotifyDriverLocationQueue,
+		[]string{
+			contracts.DriverEventLocation,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		PaymentTripResponseQueue,
+		[]string{
+			contracts.PaymentCmdCreateSession,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		NotifyPaymentSessionCreatedQueue,
+		[]string{
+			contracts.PaymentEventSessionCreated,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		TripPaymentEventsQueue,
+		[]string{
+			contracts.PaymentEventSuccess,
+			contracts.PaymentEventFailed,
+			contracts.PaymentEventCancelled,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
+	if err := r.declareAndBindQueue(
+		DriverTripEventsQueue,
+		[]string{
+			contracts.TripEventDriverAssigned,
+			contracts.TripEventStarted,
+			contracts.TripEventCompleted,
+			contracts.TripEventCancelled,
+			contracts.TripEventPaymentFailed,
+		},
+		TripExchange,
+	); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -0,0 +1,429 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/env"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// WSRouter delivers websocket messages to the users whichever gateway instance they are connected to.
+// Every instance consumes its own queue, bound to the gateway exchange with the IDs of the users
+// connected to it, so RabbitMQ routes a message for a user to the instance holding their connection.
+//
+// The messages are numbered and kept in the outbox of the user first, so the ones sent
+// while the user was disconnected are replayed when they reconnect.
+//
+// The instance queue is also bound with the presence key of the users for as long as they
+// are connected, so the instances can tell whether a user is connected to any of them and
+// kick the connections of the user the others hold.
+type WSRouter struct {
+	rb         *RabbitMQ
+	connMgr    *ConnectionManager
+	outbox     Outbox
+	instanceID string
+	queue      string
+	bindings   map[string]int // userID -> local connections bound
+	presence   map[string]int // userID -> local connections joined
+	mu         sync.Mutex
+
+	probes       *amqp.Channel      // in confirm mode, so the probes are returned before they are confirmed
+	probeReturns <-chan amqp.Return // the probes no instance was bound for
+	lastProbeID  uint64
+	probeMu      sync.Mutex
+}
+
+const (
+	// presencePrefix prefixes the user IDs in the routing keys of the presence messages
+	presencePrefix = "presence."
+	// presenceProbe messages are dropped by the instances, they are only published to find out
+	// whether RabbitMQ routes them anywhere
+	presenceProbe = "gateway.presence_probe"
+	// presenceKick messages close the connections of the user held by the other instances
+	presenceKick = "gateway.presence_kick"
+
+	presenceTimeout = 2 * time.Second
+)
+
+// ephemeralMessages aren't worth replaying, a newer one soon replaces them
+var ephemeralMessages = map[string]bool{
+	contracts.DriverEventLocation: true,
+}
+
+func NewWSRouter(rb *RabbitMQ, connMgr *ConnectionManager, outbox Outbox) (*WSRouter, error) {
+	r := &WSRouter{
+		rb:         rb,
+		connMgr:    connMgr,
+		outbox:     outbox,
+		instanceID: gatewayInstanceID(),
+		bindings:   make(map[string]int),
+		presence:   make(map[string]int),
+	}
+	r.queue = fmt.Sprintf("gateway.%s", r.instanceID)
+
+	// the queue goes away with the instance, and with it the bindings of its users
+	if _, err := rb.Channel.QueueDeclare(
+		r.queue, // name
+		false,   // durable
+		true,    // delete when unused
+		true,    // exclusive
+		false,   // no-wait
+		nil,     // arguments
+	); err != nil {
+		return nil, fmt.Errorf("failed to declare the queue of gateway instance %s: %v", r.instanceID, err)
+	}
+
+	msgs, err := rb.Channel.Consume(r.queue, "", true, true, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume the queue of gateway instance %s: %v", r.instanceID, err)
+	}
+
+	// messages no instance is bound for come back, as the user isn't connected anywhere
+	returns := rb.Channel.NotifyReturn(make(chan amqp.Return, 16))
+
+	probes, err := rb.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the presence channel of gateway instance %s: %v", r.instanceID, err)
+	}
+	if err := probes.Confirm(false); err != nil {
+		return nil, fmt.Errorf("failed to confirm the presence probes of gateway instance %s: %v", r.instanceID, err)
+	}
+	r.probes = probes
+	r.probeReturns = probes.NotifyReturn(make(chan amqp.Return, 16))
+
+	go r.deliver(msgs)
+	go r.handleReturned(returns)
+
+	log.Printf("Gateway instance %s routing websocket messages through queue %s", r.instanceID, r.queue)
+
+	return r, nil
+}
+
+// gatewayInstanceID names this gateway instance, the pod name being unique within the cluster
+func gatewayInstanceID() string {
+	id := env.GetString("GATEWAY_INSTANCE_ID", "")
+	if id == "" {
+		id, _ = os.Hostname()
+	}
+
+	// a restarted instance may come back with the same name before its old queue is gone
+	return fmt.Sprintf("%s-%04x", id, rand.IntN(1<<16))
+}
+
+// Bind routes the messages of the user to this instance, until as many Unbind calls are made
+func (r *WSRouter) Bind(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.bindings[userID] == 0 {
+		if err := r.rb.Channel.QueueBind(r.queue, userID, GatewayExchange, false, nil); err != nil {
+			return fmt.Errorf("failed to route the messages of user %s to gateway instance %s: %v", userID, r.instanceID, err)
+		}
+	}
+	r.bindings[userID]++
+
+	return nil
+}
+
+// Unbind stops routing the messages of the user to this instance once its last connection is gone
+func (r *WSRouter) Unbind(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.bindings[userID]--; r.bindings[userID] > 0 {
+		return
+	}
+	delete(r.bindings, userID)
+
+	if err := r.rb.Channel.QueueUnbind(r.queue, userID, GatewayExchange, nil); err != nil {
+		log.Printf("Failed to stop routing the messages of user %s to gateway instance %s: %v", userID, r.instanceID, err)
+	}
+}
+
+// Join records the user is connected to this instance, until as many Leave calls are made,
+// and tells whether they were already connected to any instance, considering they weren't
+// when that can't be told. When the connection policy kicks the old connections, the ones
+// the other instances hold are closed.
+func (r *WSRouter) Join(ctx context.Context, userID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.presence[userID] > 0 {
+		r.presence[userID]++
+		return true, nil
+	}
+
+	// probed before binding, this instance would answer for itself
+	present, err := r.Present(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to tell whether user %s is connected to another gateway instance: %v", userID, err)
+	}
+
+	if err := r.rb.Channel.QueueBind(r.queue, presencePrefix+userID, GatewayExchange, false, nil); err != nil {
+		return false, fmt.Errorf("failed to record user %s is connected to gateway instance %s: %v", userID, r.instanceID, err)
+	}
+	r.presence[userID]++
+
+	if present && r.connMgr.Policy() == KickOldConnection {
+		if err := r.publishPresence(ctx, userID, presenceKick); err != nil {
+			log.Printf("Failed to close the connections of user %s on the other gateway instances: %v", userID, err)
+		}
+	}
+
+	return present, nil
+}
+
+// Leave records a connection of the user to this instance is gone, and tells whether they
+// are still connected to any instance. When that can't be told, they are considered gone.
+func (r *WSRouter) Leave(ctx context.Context, userID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.presence[userID]--; r.presence[userID] > 0 {
+		return true
+	}
+	delete(r.presence, userID)
+
+	if err := r.rb.Channel.QueueUnbind(r.queue, presencePrefix+userID, GatewayExchange, nil); err != nil {
+		log.Printf("Failed to record user %s left gateway instance %s: %v", userID, r.instanceID, err)
+	}
+
+	present, err := r.Present(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to tell whether user %s is connected to another gateway instance: %v", userID, err)
+		return false
+	}
+
+	return present
+}
+
+// Present tells whether the user is connected to any gateway instance, this one included.
+// A probe published for the user comes back when no instance is bound for them.
+func (r *WSRouter) Present(ctx context.Context, userID string) (bool, error) {
+	r.probeMu.Lock()
+	defer r.probeMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, presenceTimeout)
+	defer cancel()
+
+	r.lastProbeID++
+	probeID := strconv.FormatUint(r.lastProbeID, 10)
+
+	confirmation, err := r.probes.PublishWithDeferredConfirmWithContext(ctx,
+		GatewayExchange,       // exchange
+		presencePrefix+userID, // routing key
+		true,                  // mandatory: return the probe if no instance is bound for the user
+		false,                 // immediate
+		amqp.Publishing{
+			Type:      presenceProbe,
+			AppId:     r.instanceID,
+			MessageId: probeID,
+		},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to probe the presence of user %s: %v", userID, err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to probe the presence of user %s: %v", userID, err)
+	}
+	if !acked {
+		return false, fmt.Errorf("the presence probe of user %s was rejected", userID)
+	}
+
+	// RabbitMQ returns an unroutable message before confirming it, the returns of the
+	// probes that timed out before are skipped
+	for {
+		select {
+		case ret := <-r.probeReturns:
+			if ret.MessageId == probeID {
+				return false, nil
+			}
+		default:
+			return true, nil
+		}
+	}
+}
+
+func (r *WSRouter) publishPresence(ctx context.Context, userID, messageType string) error {
+	return r.rb.Channel.PublishWithContext(ctx,
+		GatewayExchange,       // exchange
+		presencePrefix+userID, // routing key
+		false,                 // mandatory
+		false,                 // immediate
+		amqp.Publishing{
+			Type:  messageType,
+			AppId: r.instanceID,
+		},
+	)
+}
+
+// Send keeps the message in the outbox of the user and routes it to the gateway instance they are connected to
+func (r *WSRouter) Send(ctx context.Context, userID string, message contracts.WSMessage) error {
+	if !ephemeralMessages[message.Type] {
+		seq, err := r.outbox.Append(ctx, userID, message)
+		if err != nil {
+			// better delivered without a number than not at all
+			log.Printf("Failed to keep %s message of user %s in the outbox: %v", message.Type, userID, err)
+		}
+		message.ID = seq
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	body, err := json.Marshal(contracts.AmqpMessage{
+		OwnerID: userID,
+		Data:    data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	return r.rb.Channel.PublishWithContext(ctx,
+		GatewayExchange, // exchange
+		userID,          // routing key
+		true,            // mandatory: return the message if the user isn't connected
+		false,           // immediate
+		amqp.Publishing{
+			ContentType: "application/json",
+			Type:        message.Type,
+			Body:        body,
+		},
+	)
+}
+
+// deliver writes the messages routed to this instance to the connections of their users
+func (r *WSRouter) deliver(msgs <-chan amqp.Delivery) {
+	for msg := range msgs {
+		switch msg.Type {
+		case presenceProbe:
+			continue
+		case presenceKick:
+			// the instance kicking the old connections keeps the new one
+			if msg.AppId != r.instanceID {
+				r.connMgr.KickAll(strings.TrimPrefix(msg.RoutingKey, presencePrefix))
+			}
+			continue
+		}
+
+		var routed contracts.AmqpMessage
+		if err := json.Unmarshal(msg.Body, &routed); err != nil {
+			log.Printf("Failed to unmarshal routed message: %v", err)
+			continue
+		}
+
+		var message contracts.WSMessage
+		if err := json.Unmarshal(routed.Data, &message); err != nil {
+			log.Printf("Failed to unmarshal routed message: %v", err)
+			continue
+		}
+
+		r.sendLocal(context.Background(), routed.OwnerID, message)
+	}
+}
+
+// sendLocal writes the message to the connection of the user on this instance,
+// recording the delivery so the message isn't replayed
+func (r *WSRouter) sendLocal(ctx context.Context, userID string, message contracts.WSMessage) error {
+	if err := r.connMgr.SendMessage(userID, message); err != nil {
+		log.Printf("Failed to send message to user %s: %v", userID, err)
+		return err
+	}
+
+	if message.ID != 0 {
+		if err := r.outbox.Delivered(ctx, userID, message.ID); err != nil {
+			log.Printf("Failed to record the delivery of message %d to user %s: %v", message.ID, userID, err)
+		}
+	}
+
+	return nil
+}
+
+// Connect routes the messages of the user to this instance and replays the ones of their outbox
+// they missed: the ones after lastEventID when the client tells which one it saw last, else the
+// ones that weren't delivered. Messages sent meanwhile may arrive twice or before the replayed
+// ones, clients order and deduplicate them by ID.
+func (r *WSRouter) Connect(ctx context.Context, userID string, lastEventID *uint64) error {
+	// where to replay from is read before binding, live deliveries would move the cursor
+	after, replayErr := r.replayStart(ctx, userID, lastEventID)
+	if replayErr != nil {
+		log.Printf("Failed to get the outbox of user %s, not replaying it: %v", userID, replayErr)
+	}
+
+	if err := r.Bind(userID); err != nil {
+		return err
+	}
+
+	if replayErr == nil {
+		r.replay(ctx, userID, after)
+	}
+
+	return nil
+}
+
+func (r *WSRouter) replayStart(ctx context.Context, userID string, lastEventID *uint64) (uint64, error) {
+	last, delivered, err := r.outbox.Cursor(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	after := delivered
+	if lastEventID != nil {
+		after = *lastEventID
+	}
+
+	// the outbox was reset since the client saw that message, e.g. after the user went idle for long
+	if after > last {
+		after = 0
+	}
+
+	return after, nil
+}
+
+func (r *WSRouter) replay(ctx context.Context, userID string, after uint64) {
+	events, err := r.outbox.After(ctx, userID, after)
+	if err != nil {
+		log.Printf("Failed to replay the outbox of user %s: %v", userID, err)
+		return
+	}
+
+	for _, event := range events {
+		message := event.Message
+		message.ID = event.Seq
+
+		if err := r.sendLocal(ctx, userID, message); err != nil {
+			return
+		}
+	}
+
+	if len(events) > 0 {
+		log.Printf("Replayed %d messages to user %s", len(events), userID)
+	}
+}
+
+func (r *WSRouter) handleReturned(returns <-chan amqp.Return) {
+	for ret := range returns {
+		if ret.Exchange != GatewayExchange {
+			continue
+		}
+
+		if ephemeralMessages[ret.Type] {
+			continue
+		}
+		log.Printf("User %s is not connected to any gateway instance, %s message kept for when they reconnect", ret.RoutingKey, ret.Type)
+	}
+}
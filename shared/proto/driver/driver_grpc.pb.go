@@ -19,8 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	DriverService_RegisterDriver_FullMethodName   = "/driver.DriverService/RegisterDriver"
-	DriverService_UnRegisterDriver_FullMethodName = "/driver.DriverService/UnRegisterDriver"
+	DriverService_RegisterDriver_FullMethodName        = "/driver.DriverService/RegisterDriver"
+	DriverService_UnRegisterDriver_FullMethodName      = "/driver.DriverService/UnRegisterDriver"
+	DriverService_UpdateDriverLocation_FullMethodName  = "/driver.DriverService/UpdateDriverLocation"
+	DriverService_CountAvailableDrivers_FullMethodName = "/driver.DriverService/CountAvailableDrivers"
+	DriverService_StreamDriverLocations_FullMethodName = "/driver.DriverService/StreamDriverLocations"
+	DriverService_GetTripOffer_FullMethodName          = "/driver.DriverService/GetTripOffer"
 )
 
 // DriverServiceClient is the client API for DriverService service.
@@ -29,6 +33,10 @@ const (
 type DriverServiceClient interface {
 	RegisterDriver(ctx context.Context, in *RegisterDriverRequest, opts ...grpc.CallOption) (*RegisterDriverResponse, error)
 	UnRegisterDriver(ctx context.Context, in *RegisterDriverRequest, opts ...grpc.CallOption) (*RegisterDriverResponse, error)
+	UpdateDriverLocation(ctx context.Context, in *UpdateDriverLocationRequest, opts ...grpc.CallOption) (*UpdateDriverLocationResponse, error)
+	CountAvailableDrivers(ctx context.Context, in *CountAvailableDriversRequest, opts ...grpc.CallOption) (*CountAvailableDriversResponse, error)
+	StreamDriverLocations(ctx context.Context, in *StreamDriverLocationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DriverLocationUpdate], error)
+	GetTripOffer(ctx context.Context, in *GetTripOfferRequest, opts ...grpc.CallOption) (*GetTripOfferResponse, error)
 }
 
 type driverServiceClient struct {
@@ -59,12 +67,65 @@ func (c *driverServiceClient) UnRegisterDriver(ctx context.Context, in *Register
 	return out, nil
 }
 
+func (c *driverServiceClient) UpdateDriverLocation(ctx context.Context, in *UpdateDriverLocationRequest, opts ...grpc.CallOption) (*UpdateDriverLocationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateDriverLocationResponse)
+	err := c.cc.Invoke(ctx, DriverService_UpdateDriverLocation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverServiceClient) CountAvailableDrivers(ctx context.Context, in *CountAvailableDriversRequest, opts ...grpc.CallOption) (*CountAvailableDriversResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountAvailableDriversResponse)
+	err := c.cc.Invoke(ctx, DriverService_CountAvailableDrivers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverServiceClient) StreamDriverLocations(ctx context.Context, in *StreamDriverLocationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DriverLocationUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DriverService_ServiceDesc.Streams[0], DriverService_StreamDriverLocations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamDriverLocationsRequest, DriverLocationUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DriverService_StreamDriverLocationsClient = grpc.ServerStreamingClient[DriverLocationUpdate]
+
+func (c *driverServiceClient) GetTripOffer(ctx context.Context, in *GetTripOfferRequest, opts ...grpc.CallOption) (*GetTripOfferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTripOfferResponse)
+	err := c.cc.Invoke(ctx, DriverService_GetTripOffer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DriverServiceServer is the server API for DriverService service.
 // All implementations must embed UnimplementedDriverServiceServer
 // for forward compatibility.
 type DriverServiceServer interface {
 	RegisterDriver(context.Context, *RegisterDriverRequest) (*RegisterDriverResponse, error)
 	UnRegisterDriver(context.Context, *RegisterDriverRequest) (*RegisterDriverResponse, error)
+	UpdateDriverLocation(context.Context, *UpdateDriverLocationRequest) (*UpdateDriverLocationResponse, error)
+	CountAvailableDrivers(context.Context, *CountAvailableDriversRequest) (*CountAvailableDriversResponse, error)
+	StreamDriverLocations(*StreamDriverLocationsRequest, grpc.ServerStreamingServer[DriverLocationUpdate]) error
+	GetTripOffer(context.Context, *GetTripOfferRequest) (*GetTripOfferResponse, error)
 	mustEmbedUnimplementedDriverServiceServer()
 }
 
@@ -81,6 +142,18 @@ func (UnimplementedDriverServiceServer) RegisterDriver(context.Context, *Registe
 func (UnimplementedDriverServiceServer) UnRegisterDriver(context.Context, *RegisterDriverRequest) (*RegisterDriverResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UnRegisterDriver not implemented")
 }
+func (UnimplementedDriverServiceServer) UpdateDriverLocation(context.Context, *UpdateDriverLocationRequest) (*UpdateDriverLocationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDriverLocation not implemented")
+}
+func (UnimplementedDriverServiceServer) CountAvailableDrivers(context.Context, *CountAvailableDriversRequest) (*CountAvailableDriversResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountAvailableDrivers not implemented")
+}
+func (UnimplementedDriverServiceServer) StreamDriverLocations(*StreamDriverLocationsRequest, grpc.ServerStreamingServer[DriverLocationUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDriverLocations not implemented")
+}
+func (UnimplementedDriverServiceServer) GetTripOffer(context.Context, *GetTripOfferRequest) (*GetTripOfferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTripOffer not implemented")
+}
 func (UnimplementedDriverServiceServer) mustEmbedUnimplementedDriverServiceServer() {}
 func (UnimplementedDriverServiceServer) testEmbeddedByValue()                       {}
 
@@ -138,6 +211,71 @@ func _DriverService_UnRegisterDriver_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DriverService_UpdateDriverLocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateDriverLocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServiceServer).UpdateDriverLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DriverService_UpdateDriverLocation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServiceServer).UpdateDriverLocation(ctx, req.(*UpdateDriverLocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DriverService_CountAvailableDrivers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountAvailableDriversRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServiceServer).CountAvailableDrivers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DriverService_CountAvailableDrivers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServiceServer).CountAvailableDrivers(ctx, req.(*CountAvailableDriversRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DriverService_StreamDriverLocations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamDriverLocationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DriverServiceServer).StreamDriverLocations(m, &grpc.GenericServerStream[StreamDriverLocationsRequest, DriverLocationUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DriverService_StreamDriverLocationsServer = grpc.ServerStreamingServer[DriverLocationUpdate]
+
+func _DriverService_GetTripOffer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTripOfferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServiceServer).GetTripOffer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DriverService_GetTripOffer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServiceServer).GetTripOffer(ctx, req.(*GetTripOfferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DriverService_ServiceDesc is the grpc.ServiceDesc for DriverService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,7 +291,25 @@ var DriverService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UnRegisterDriver",
 			Handler:    _DriverService_UnRegisterDriver_Handler,
 		},
+		{
+			MethodName: "UpdateDriverLocation",
+			Handler:    _DriverService_UpdateDriverLocation_Handler,
+		},
+		{
+			MethodName: "CountAvailableDrivers",
+			Handler:    _DriverService_CountAvailableDrivers_Handler,
+		},
+		{
+			MethodName: "GetTripOffer",
+			Handler:    _DriverService_GetTripOffer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamDriverLocations",
+			Handler:       _DriverService_StreamDriverLocations_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "driver.proto",
 }
@@ -133,6 +133,454 @@ func (x *CreateTripResponse) GetTrip() *Trip {
 	return nil
 }
 
+type CancelTripRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	UserID        string                 `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTripRequest) Reset() {
+	*x = CancelTripRequest{}
+	mi := &file_trip_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTripRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTripRequest) ProtoMessage() {}
+
+func (x *CancelTripRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTripRequest.ProtoReflect.Descriptor instead.
+func (*CancelTripRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CancelTripRequest) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *CancelTripRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+func (x *CancelTripRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type CancelTripResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trip          *Trip                  `protobuf:"bytes,1,opt,name=trip,proto3" json:"trip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTripResponse) Reset() {
+	*x = CancelTripResponse{}
+	mi := &file_trip_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTripResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTripResponse) ProtoMessage() {}
+
+func (x *CancelTripResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTripResponse.ProtoReflect.Descriptor instead.
+func (*CancelTripResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CancelTripResponse) GetTrip() *Trip {
+	if x != nil {
+		return x.Trip
+	}
+	return nil
+}
+
+type GetTripRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TripID        string                 `protobuf:"bytes,1,opt,name=tripID,proto3" json:"tripID,omitempty"`
+	UserID        string                 `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"` // the rider or the driver of the trip
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTripRequest) Reset() {
+	*x = GetTripRequest{}
+	mi := &file_trip_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTripRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTripRequest) ProtoMessage() {}
+
+func (x *GetTripRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTripRequest.ProtoReflect.Descriptor instead.
+func (*GetTripRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetTripRequest) GetTripID() string {
+	if x != nil {
+		return x.TripID
+	}
+	return ""
+}
+
+func (x *GetTripRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+type GetTripResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trip          *Trip                  `protobuf:"bytes,1,opt,name=trip,proto3" json:"trip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTripResponse) Reset() {
+	*x = GetTripResponse{}
+	mi := &file_trip_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTripResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTripResponse) ProtoMessage() {}
+
+func (x *GetTripResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTripResponse.ProtoReflect.Descriptor instead.
+func (*GetTripResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetTripResponse) GetTrip() *Trip {
+	if x != nil {
+		return x.Trip
+	}
+	return nil
+}
+
+type ListTripsByUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserID        string                 `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	Filter        *TripFilter            `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	PageToken     string                 `protobuf:"bytes,4,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTripsByUserRequest) Reset() {
+	*x = ListTripsByUserRequest{}
+	mi := &file_trip_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTripsByUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTripsByUserRequest) ProtoMessage() {}
+
+func (x *ListTripsByUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTripsByUserRequest.ProtoReflect.Descriptor instead.
+func (*ListTripsByUserRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListTripsByUserRequest) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+func (x *ListTripsByUserRequest) GetFilter() *TripFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListTripsByUserRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListTripsByUserRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListTripsByDriverRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DriverID      string                 `protobuf:"bytes,1,opt,name=driverID,proto3" json:"driverID,omitempty"`
+	Filter        *TripFilter            `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	PageToken     string                 `protobuf:"bytes,4,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTripsByDriverRequest) Reset() {
+	*x = ListTripsByDriverRequest{}
+	mi := &file_trip_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTripsByDriverRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTripsByDriverRequest) ProtoMessage() {}
+
+func (x *ListTripsByDriverRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTripsByDriverRequest.ProtoReflect.Descriptor instead.
+func (*ListTripsByDriverRequest) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListTripsByDriverRequest) GetDriverID() string {
+	if x != nil {
+		return x.DriverID
+	}
+	return ""
+}
+
+func (x *ListTripsByDriverRequest) GetFilter() *TripFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListTripsByDriverRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListTripsByDriverRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type TripFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Statuses      []string               `protobuf:"bytes,1,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	CreatedAfter  string                 `protobuf:"bytes,2,opt,name=createdAfter,proto3" json:"createdAfter,omitempty"`   // RFC 3339
+	CreatedBefore string                 `protobuf:"bytes,3,opt,name=createdBefore,proto3" json:"createdBefore,omitempty"` // RFC 3339
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TripFilter) Reset() {
+	*x = TripFilter{}
+	mi := &file_trip_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TripFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TripFilter) ProtoMessage() {}
+
+func (x *TripFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TripFilter.ProtoReflect.Descriptor instead.
+func (*TripFilter) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TripFilter) GetStatuses() []string {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *TripFilter) GetCreatedAfter() string {
+	if x != nil {
+		return x.CreatedAfter
+	}
+	return ""
+}
+
+func (x *TripFilter) GetCreatedBefore() string {
+	if x != nil {
+		return x.CreatedBefore
+	}
+	return ""
+}
+
+type ListTripsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trips         []*Trip                `protobuf:"bytes,1,rep,name=trips,proto3" json:"trips,omitempty"`                 // newest first
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"` // empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTripsResponse) Reset() {
+	*x = ListTripsResponse{}
+	mi := &file_trip_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTripsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTripsResponse) ProtoMessage() {}
+
+func (x *ListTripsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTripsResponse.ProtoReflect.Descriptor instead.
+func (*ListTripsResponse) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListTripsResponse) GetTrips() []*Trip {
+	if x != nil {
+		return x.Trips
+	}
+	return nil
+}
+
+func (x *ListTripsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
 type Trip struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -141,13 +589,15 @@ type Trip struct {
 	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
 	UserID        string                 `protobuf:"bytes,5,opt,name=userID,proto3" json:"userID,omitempty"`
 	Driver        *TripDriver            `protobuf:"bytes,6,opt,name=driver,proto3" json:"driver,omitempty"`
+	Cancellation  *TripCancellation      `protobuf:"bytes,7,opt,name=cancellation,proto3" json:"cancellation,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,8,opt,name=createdAt,proto3" json:"createdAt,omitempty"` // RFC 3339
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Trip) Reset() {
 	*x = Trip{}
-	mi := &file_trip_proto_msgTypes[2]
+	mi := &file_trip_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -159,7 +609,7 @@ func (x *Trip) String() string {
 func (*Trip) ProtoMessage() {}
 
 func (x *Trip) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[2]
+	mi := &file_trip_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -172,7 +622,7 @@ func (x *Trip) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Trip.ProtoReflect.Descriptor instead.
 func (*Trip) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{2}
+	return file_trip_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *Trip) GetId() string {
@@ -217,6 +667,80 @@ func (x *Trip) GetDriver() *TripDriver {
 	return nil
 }
 
+func (x *Trip) GetCancellation() *TripCancellation {
+	if x != nil {
+		return x.Cancellation
+	}
+	return nil
+}
+
+func (x *Trip) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+type TripCancellation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CancelledBy   string                 `protobuf:"bytes,1,opt,name=cancelledBy,proto3" json:"cancelledBy,omitempty"` // rider or driver
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	FeeInCents    float64                `protobuf:"fixed64,3,opt,name=feeInCents,proto3" json:"feeInCents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TripCancellation) Reset() {
+	*x = TripCancellation{}
+	mi := &file_trip_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TripCancellation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TripCancellation) ProtoMessage() {}
+
+func (x *TripCancellation) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TripCancellation.ProtoReflect.Descriptor instead.
+func (*TripCancellation) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TripCancellation) GetCancelledBy() string {
+	if x != nil {
+		return x.CancelledBy
+	}
+	return ""
+}
+
+func (x *TripCancellation) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *TripCancellation) GetFeeInCents() float64 {
+	if x != nil {
+		return x.FeeInCents
+	}
+	return 0
+}
+
 // Static driver object that is used to store driver info
 type TripDriver struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
@@ -230,7 +754,7 @@ type TripDriver struct {
 
 func (x *TripDriver) Reset() {
 	*x = TripDriver{}
-	mi := &file_trip_proto_msgTypes[3]
+	mi := &file_trip_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -242,7 +766,7 @@ func (x *TripDriver) String() string {
 func (*TripDriver) ProtoMessage() {}
 
 func (x *TripDriver) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[3]
+	mi := &file_trip_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -255,7 +779,7 @@ func (x *TripDriver) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TripDriver.ProtoReflect.Descriptor instead.
 func (*TripDriver) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{3}
+	return file_trip_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *TripDriver) GetId() string {
@@ -297,7 +821,7 @@ type PreviewTripRequest struct {
 
 func (x *PreviewTripRequest) Reset() {
 	*x = PreviewTripRequest{}
-	mi := &file_trip_proto_msgTypes[4]
+	mi := &file_trip_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -309,7 +833,7 @@ func (x *PreviewTripRequest) String() string {
 func (*PreviewTripRequest) ProtoMessage() {}
 
 func (x *PreviewTripRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[4]
+	mi := &file_trip_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -322,7 +846,7 @@ func (x *PreviewTripRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PreviewTripRequest.ProtoReflect.Descriptor instead.
 func (*PreviewTripRequest) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{4}
+	return file_trip_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *PreviewTripRequest) GetUserId() string {
@@ -356,7 +880,7 @@ type Coordinate struct {
 
 func (x *Coordinate) Reset() {
 	*x = Coordinate{}
-	mi := &file_trip_proto_msgTypes[5]
+	mi := &file_trip_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -368,7 +892,7 @@ func (x *Coordinate) String() string {
 func (*Coordinate) ProtoMessage() {}
 
 func (x *Coordinate) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[5]
+	mi := &file_trip_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -381,7 +905,7 @@ func (x *Coordinate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Coordinate.ProtoReflect.Descriptor instead.
 func (*Coordinate) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{5}
+	return file_trip_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *Coordinate) GetLatitude() float64 {
@@ -403,13 +927,14 @@ type PreviewTripResponse struct {
 	TripId        string                 `protobuf:"bytes,1,opt,name=trip_id,json=tripId,proto3" json:"trip_id,omitempty"`
 	Route         *Route                 `protobuf:"bytes,2,opt,name=route,proto3" json:"route,omitempty"`
 	RideFares     []*RideFare            `protobuf:"bytes,3,rep,name=rideFares,proto3" json:"rideFares,omitempty"`
+	Surge         *Surge                 `protobuf:"bytes,4,opt,name=surge,proto3" json:"surge,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PreviewTripResponse) Reset() {
 	*x = PreviewTripResponse{}
-	mi := &file_trip_proto_msgTypes[6]
+	mi := &file_trip_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -421,7 +946,7 @@ func (x *PreviewTripResponse) String() string {
 func (*PreviewTripResponse) ProtoMessage() {}
 
 func (x *PreviewTripResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[6]
+	mi := &file_trip_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -434,7 +959,7 @@ func (x *PreviewTripResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PreviewTripResponse.ProtoReflect.Descriptor instead.
 func (*PreviewTripResponse) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{6}
+	return file_trip_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *PreviewTripResponse) GetTripId() string {
@@ -458,6 +983,89 @@ func (x *PreviewTripResponse) GetRideFares() []*RideFare {
 	return nil
 }
 
+func (x *PreviewTripResponse) GetSurge() *Surge {
+	if x != nil {
+		return x.Surge
+	}
+	return nil
+}
+
+type Surge struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Multiplier       float64                `protobuf:"fixed64,1,opt,name=multiplier,proto3" json:"multiplier,omitempty"`
+	Geohash          string                 `protobuf:"bytes,2,opt,name=geohash,proto3" json:"geohash,omitempty"`
+	Reason           string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	AvailableDrivers int32                  `protobuf:"varint,4,opt,name=availableDrivers,proto3" json:"availableDrivers,omitempty"`
+	RecentTrips      int32                  `protobuf:"varint,5,opt,name=recentTrips,proto3" json:"recentTrips,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Surge) Reset() {
+	*x = Surge{}
+	mi := &file_trip_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Surge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Surge) ProtoMessage() {}
+
+func (x *Surge) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Surge.ProtoReflect.Descriptor instead.
+func (*Surge) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Surge) GetMultiplier() float64 {
+	if x != nil {
+		return x.Multiplier
+	}
+	return 0
+}
+
+func (x *Surge) GetGeohash() string {
+	if x != nil {
+		return x.Geohash
+	}
+	return ""
+}
+
+func (x *Surge) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Surge) GetAvailableDrivers() int32 {
+	if x != nil {
+		return x.AvailableDrivers
+	}
+	return 0
+}
+
+func (x *Surge) GetRecentTrips() int32 {
+	if x != nil {
+		return x.RecentTrips
+	}
+	return 0
+}
+
 type Route struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Geometry      []*Geometry            `protobuf:"bytes,1,rep,name=geometry,proto3" json:"geometry,omitempty"`
@@ -469,7 +1077,7 @@ type Route struct {
 
 func (x *Route) Reset() {
 	*x = Route{}
-	mi := &file_trip_proto_msgTypes[7]
+	mi := &file_trip_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -481,7 +1089,7 @@ func (x *Route) String() string {
 func (*Route) ProtoMessage() {}
 
 func (x *Route) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[7]
+	mi := &file_trip_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -494,7 +1102,7 @@ func (x *Route) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Route.ProtoReflect.Descriptor instead.
 func (*Route) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{7}
+	return file_trip_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *Route) GetGeometry() []*Geometry {
@@ -524,13 +1132,17 @@ type RideFare struct {
 	UserID            string                 `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
 	PackageSlug       string                 `protobuf:"bytes,3,opt,name=packageSlug,proto3" json:"packageSlug,omitempty"`
 	TotalPriceInCents float64                `protobuf:"fixed64,4,opt,name=totalPriceInCents,proto3" json:"totalPriceInCents,omitempty"`
+	Breakdown         *FareBreakdown         `protobuf:"bytes,5,opt,name=breakdown,proto3" json:"breakdown,omitempty"`
+	Currency          string                 `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"`
+	PricingVersion    string                 `protobuf:"bytes,7,opt,name=pricingVersion,proto3" json:"pricingVersion,omitempty"`
+	ExpiresAt         string                 `protobuf:"bytes,8,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"` // RFC 3339, trips can't be booked with the fare afterwards
 	unknownFields     protoimpl.UnknownFields
 	sizeCache         protoimpl.SizeCache
 }
 
 func (x *RideFare) Reset() {
 	*x = RideFare{}
-	mi := &file_trip_proto_msgTypes[8]
+	mi := &file_trip_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -542,7 +1154,7 @@ func (x *RideFare) String() string {
 func (*RideFare) ProtoMessage() {}
 
 func (x *RideFare) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[8]
+	mi := &file_trip_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -555,7 +1167,7 @@ func (x *RideFare) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RideFare.ProtoReflect.Descriptor instead.
 func (*RideFare) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{8}
+	return file_trip_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *RideFare) GetId() string {
@@ -586,6 +1198,150 @@ func (x *RideFare) GetTotalPriceInCents() float64 {
 	return 0
 }
 
+func (x *RideFare) GetBreakdown() *FareBreakdown {
+	if x != nil {
+		return x.Breakdown
+	}
+	return nil
+}
+
+func (x *RideFare) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *RideFare) GetPricingVersion() string {
+	if x != nil {
+		return x.PricingVersion
+	}
+	return ""
+}
+
+func (x *RideFare) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+type FareBreakdown struct {
+	state                        protoimpl.MessageState `protogen:"open.v1"`
+	BaseFareInCents              float64                `protobuf:"fixed64,1,opt,name=baseFareInCents,proto3" json:"baseFareInCents,omitempty"`
+	DistanceFareInCents          float64                `protobuf:"fixed64,2,opt,name=distanceFareInCents,proto3" json:"distanceFareInCents,omitempty"`
+	TimeFareInCents              float64                `protobuf:"fixed64,3,opt,name=timeFareInCents,proto3" json:"timeFareInCents,omitempty"`
+	MinimumFareAdjustmentInCents float64                `protobuf:"fixed64,4,opt,name=minimumFareAdjustmentInCents,proto3" json:"minimumFareAdjustmentInCents,omitempty"`
+	BookingFeeInCents            float64                `protobuf:"fixed64,5,opt,name=bookingFeeInCents,proto3" json:"bookingFeeInCents,omitempty"`
+	RoundingAdjustmentInCents    float64                `protobuf:"fixed64,6,opt,name=roundingAdjustmentInCents,proto3" json:"roundingAdjustmentInCents,omitempty"`
+	DistanceKm                   float64                `protobuf:"fixed64,7,opt,name=distanceKm,proto3" json:"distanceKm,omitempty"`
+	DurationMinutes              float64                `protobuf:"fixed64,8,opt,name=durationMinutes,proto3" json:"durationMinutes,omitempty"`
+	SurgeMultiplier              float64                `protobuf:"fixed64,9,opt,name=surgeMultiplier,proto3" json:"surgeMultiplier,omitempty"`
+	SurgeFareInCents             float64                `protobuf:"fixed64,10,opt,name=surgeFareInCents,proto3" json:"surgeFareInCents,omitempty"`
+	unknownFields                protoimpl.UnknownFields
+	sizeCache                    protoimpl.SizeCache
+}
+
+func (x *FareBreakdown) Reset() {
+	*x = FareBreakdown{}
+	mi := &file_trip_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FareBreakdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FareBreakdown) ProtoMessage() {}
+
+func (x *FareBreakdown) ProtoReflect() protoreflect.Message {
+	mi := &file_trip_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FareBreakdown.ProtoReflect.Descriptor instead.
+func (*FareBreakdown) Descriptor() ([]byte, []int) {
+	return file_trip_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *FareBreakdown) GetBaseFareInCents() float64 {
+	if x != nil {
+		return x.BaseFareInCents
+	}
+	return 0
+}
+
+func (x *FareBreakdown) GetDistanceFareInCents() float64 {
+	if x != nil {
+		return x.DistanceFareInCents
+	}
+	return 0
+}
+
+func (x *FareBreakdown) GetTimeFareInCents() float64 {
+	if x != nil {
+		return x.TimeFareInCents
+	}
+	return 0
+}
+
+func (x *FareBreakdown) GetMinimumFareAdjustmentInCents() float64 {
+	if x != nil {
+		return x.MinimumFareAdjustmentInCents
+	}
+	return 0
+}
+
+func (x *FareBreakdown) GetBookingFeeInCents() float64 {
+	if x != nil {
+		return x.BookingFeeInCents
+	}
+	return 0
+}
+
+func (x *FareBreakdown) GetRoundingAdjustmentInCents() float64 {
+	if x != nil {
+		return x.RoundingAdjustmentInCents
+	}
+	return 0
+}
+
+func (x *FareBreakdown) GetDistanceKm() float64 {
+	if x != nil {
+		return x.DistanceKm
+	}
+	return 0
+}
+
+func (x *FareBreakdown) GetDurationMinutes() float64 {
+	if x != nil {
+		return x.DurationMinutes
+	}
+	return 0
+}
+
+func (x *FareBreakdown) GetSurgeMultiplier() float64 {
+	if x != nil {
+		return x.SurgeMultiplier
+	}
+	return 0
+}
+
+func (x *FareBreakdown) GetSurgeFareInCents() float64 {
+	if x != nil {
+		return x.SurgeFareInCents
+	}
+	return 0
+}
+
 type Geometry struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Coordinates   []*Coordinate          `protobuf:"bytes,1,rep,name=coordinates,proto3" json:"coordinates,omitempty"`
@@ -595,7 +1351,7 @@ type Geometry struct {
 
 func (x *Geometry) Reset() {
 	*x = Geometry{}
-	mi := &file_trip_proto_msgTypes[9]
+	mi := &file_trip_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -607,7 +1363,7 @@ func (x *Geometry) String() string {
 func (*Geometry) ProtoMessage() {}
 
 func (x *Geometry) ProtoReflect() protoreflect.Message {
-	mi := &file_trip_proto_msgTypes[9]
+	mi := &file_trip_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -620,7 +1376,7 @@ func (x *Geometry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Geometry.ProtoReflect.Descriptor instead.
 func (*Geometry) Descriptor() ([]byte, []int) {
-	return file_trip_proto_rawDescGZIP(), []int{9}
+	return file_trip_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *Geometry) GetCoordinates() []*Coordinate {
@@ -645,14 +1401,54 @@ const file_trip_proto_rawDesc = "" +
 	"\x12CreateTripResponse\x12\x16\n" +
 	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x1e\n" +
 	"\x04trip\x18\x02 \x01(\v2\n" +
-	".trip.TripR\x04trip\"\xc7\x01\n" +
+	".trip.TripR\x04trip\"[\n" +
+	"\x11CancelTripRequest\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x16\n" +
+	"\x06userID\x18\x02 \x01(\tR\x06userID\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"4\n" +
+	"\x12CancelTripResponse\x12\x1e\n" +
+	"\x04trip\x18\x01 \x01(\v2\n" +
+	".trip.TripR\x04trip\"@\n" +
+	"\x0eGetTripRequest\x12\x16\n" +
+	"\x06tripID\x18\x01 \x01(\tR\x06tripID\x12\x16\n" +
+	"\x06userID\x18\x02 \x01(\tR\x06userID\"1\n" +
+	"\x0fGetTripResponse\x12\x1e\n" +
+	"\x04trip\x18\x01 \x01(\v2\n" +
+	".trip.TripR\x04trip\"\x94\x01\n" +
+	"\x16ListTripsByUserRequest\x12\x16\n" +
+	"\x06userID\x18\x01 \x01(\tR\x06userID\x12(\n" +
+	"\x06filter\x18\x02 \x01(\v2\x10.trip.TripFilterR\x06filter\x12\x1a\n" +
+	"\bpageSize\x18\x03 \x01(\x05R\bpageSize\x12\x1c\n" +
+	"\tpageToken\x18\x04 \x01(\tR\tpageToken\"\x9a\x01\n" +
+	"\x18ListTripsByDriverRequest\x12\x1a\n" +
+	"\bdriverID\x18\x01 \x01(\tR\bdriverID\x12(\n" +
+	"\x06filter\x18\x02 \x01(\v2\x10.trip.TripFilterR\x06filter\x12\x1a\n" +
+	"\bpageSize\x18\x03 \x01(\x05R\bpageSize\x12\x1c\n" +
+	"\tpageToken\x18\x04 \x01(\tR\tpageToken\"r\n" +
+	"\n" +
+	"TripFilter\x12\x1a\n" +
+	"\bstatuses\x18\x01 \x03(\tR\bstatuses\x12\"\n" +
+	"\fcreatedAfter\x18\x02 \x01(\tR\fcreatedAfter\x12$\n" +
+	"\rcreatedBefore\x18\x03 \x01(\tR\rcreatedBefore\"[\n" +
+	"\x11ListTripsResponse\x12 \n" +
+	"\x05trips\x18\x01 \x03(\v2\n" +
+	".trip.TripR\x05trips\x12$\n" +
+	"\rnextPageToken\x18\x02 \x01(\tR\rnextPageToken\"\xa1\x02\n" +
 	"\x04Trip\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x122\n" +
 	"\fselectedFare\x18\x02 \x01(\v2\x0e.trip.RideFareR\fselectedFare\x12!\n" +
 	"\x05route\x18\x03 \x01(\v2\v.trip.RouteR\x05route\x12\x16\n" +
 	"\x06status\x18\x04 \x01(\tR\x06status\x12\x16\n" +
 	"\x06userID\x18\x05 \x01(\tR\x06userID\x12(\n" +
-	"\x06driver\x18\x06 \x01(\v2\x10.trip.TripDriverR\x06driver\"t\n" +
+	"\x06driver\x18\x06 \x01(\v2\x10.trip.TripDriverR\x06driver\x12:\n" +
+	"\fcancellation\x18\a \x01(\v2\x16.trip.TripCancellationR\fcancellation\x12\x1c\n" +
+	"\tcreatedAt\x18\b \x01(\tR\tcreatedAt\"l\n" +
+	"\x10TripCancellation\x12 \n" +
+	"\vcancelledBy\x18\x01 \x01(\tR\vcancelledBy\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x1e\n" +
+	"\n" +
+	"feeInCents\x18\x03 \x01(\x01R\n" +
+	"feeInCents\"t\n" +
 	"\n" +
 	"TripDriver\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
@@ -666,26 +1462,58 @@ const file_trip_proto_rawDesc = "" +
 	"\n" +
 	"Coordinate\x12\x1a\n" +
 	"\blatitude\x18\x01 \x01(\x01R\blatitude\x12\x1c\n" +
-	"\tlongitude\x18\x02 \x01(\x01R\tlongitude\"\x7f\n" +
+	"\tlongitude\x18\x02 \x01(\x01R\tlongitude\"\xa2\x01\n" +
 	"\x13PreviewTripResponse\x12\x17\n" +
 	"\atrip_id\x18\x01 \x01(\tR\x06tripId\x12!\n" +
 	"\x05route\x18\x02 \x01(\v2\v.trip.RouteR\x05route\x12,\n" +
-	"\trideFares\x18\x03 \x03(\v2\x0e.trip.RideFareR\trideFares\"k\n" +
+	"\trideFares\x18\x03 \x03(\v2\x0e.trip.RideFareR\trideFares\x12!\n" +
+	"\x05surge\x18\x04 \x01(\v2\v.trip.SurgeR\x05surge\"\xa7\x01\n" +
+	"\x05Surge\x12\x1e\n" +
+	"\n" +
+	"multiplier\x18\x01 \x01(\x01R\n" +
+	"multiplier\x12\x18\n" +
+	"\ageohash\x18\x02 \x01(\tR\ageohash\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12*\n" +
+	"\x10availableDrivers\x18\x04 \x01(\x05R\x10availableDrivers\x12 \n" +
+	"\vrecentTrips\x18\x05 \x01(\x05R\vrecentTrips\"k\n" +
 	"\x05Route\x12*\n" +
 	"\bgeometry\x18\x01 \x03(\v2\x0e.trip.GeometryR\bgeometry\x12\x1a\n" +
 	"\bdistance\x18\x02 \x01(\x01R\bdistance\x12\x1a\n" +
-	"\bduration\x18\x03 \x01(\x01R\bduration\"\x82\x01\n" +
+	"\bduration\x18\x03 \x01(\x01R\bduration\"\x97\x02\n" +
 	"\bRideFare\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
 	"\x06userID\x18\x02 \x01(\tR\x06userID\x12 \n" +
 	"\vpackageSlug\x18\x03 \x01(\tR\vpackageSlug\x12,\n" +
-	"\x11totalPriceInCents\x18\x04 \x01(\x01R\x11totalPriceInCents\">\n" +
+	"\x11totalPriceInCents\x18\x04 \x01(\x01R\x11totalPriceInCents\x121\n" +
+	"\tbreakdown\x18\x05 \x01(\v2\x13.trip.FareBreakdownR\tbreakdown\x12\x1a\n" +
+	"\bcurrency\x18\x06 \x01(\tR\bcurrency\x12&\n" +
+	"\x0epricingVersion\x18\a \x01(\tR\x0epricingVersion\x12\x1c\n" +
+	"\texpiresAt\x18\b \x01(\tR\texpiresAt\"\xe5\x03\n" +
+	"\rFareBreakdown\x12(\n" +
+	"\x0fbaseFareInCents\x18\x01 \x01(\x01R\x0fbaseFareInCents\x120\n" +
+	"\x13distanceFareInCents\x18\x02 \x01(\x01R\x13distanceFareInCents\x12(\n" +
+	"\x0ftimeFareInCents\x18\x03 \x01(\x01R\x0ftimeFareInCents\x12B\n" +
+	"\x1cminimumFareAdjustmentInCents\x18\x04 \x01(\x01R\x1cminimumFareAdjustmentInCents\x12,\n" +
+	"\x11bookingFeeInCents\x18\x05 \x01(\x01R\x11bookingFeeInCents\x12<\n" +
+	"\x19roundingAdjustmentInCents\x18\x06 \x01(\x01R\x19roundingAdjustmentInCents\x12\x1e\n" +
+	"\n" +
+	"distanceKm\x18\a \x01(\x01R\n" +
+	"distanceKm\x12(\n" +
+	"\x0fdurationMinutes\x18\b \x01(\x01R\x0fdurationMinutes\x12(\n" +
+	"\x0fsurgeMultiplier\x18\t \x01(\x01R\x0fsurgeMultiplier\x12*\n" +
+	"\x10surgeFareInCents\x18\n" +
+	" \x01(\x01R\x10surgeFareInCents\">\n" +
 	"\bGeometry\x122\n" +
-	"\vcoordinates\x18\x01 \x03(\v2\x10.trip.CoordinateR\vcoordinates2\x92\x01\n" +
+	"\vcoordinates\x18\x01 \x03(\v2\x10.trip.CoordinateR\vcoordinates2\xa3\x03\n" +
 	"\vTripService\x12B\n" +
 	"\vPreviewTrip\x12\x18.trip.PreviewTripRequest\x1a\x19.trip.PreviewTripResponse\x12?\n" +
 	"\n" +
-	"CreateTrip\x12\x17.trip.CreateTripRequest\x1a\x18.trip.CreateTripResponseB\x18Z\x16shared/proto/trip;tripb\x06proto3"
+	"CreateTrip\x12\x17.trip.CreateTripRequest\x1a\x18.trip.CreateTripResponse\x12?\n" +
+	"\n" +
+	"CancelTrip\x12\x17.trip.CancelTripRequest\x1a\x18.trip.CancelTripResponse\x126\n" +
+	"\aGetTrip\x12\x14.trip.GetTripRequest\x1a\x15.trip.GetTripResponse\x12H\n" +
+	"\x0fListTripsByUser\x12\x1c.trip.ListTripsByUserRequest\x1a\x17.trip.ListTripsResponse\x12L\n" +
+	"\x11ListTripsByDriver\x12\x1e.trip.ListTripsByDriverRequest\x1a\x17.trip.ListTripsResponseB\x18Z\x16shared/proto/trip;tripb\x06proto3"
 
 var (
 	file_trip_proto_rawDescOnce sync.Once
@@ -699,40 +1527,67 @@ func file_trip_proto_rawDescGZIP() []byte {
 	return file_trip_proto_rawDescData
 }
 
-var file_trip_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_trip_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
 var file_trip_proto_goTypes = []any{
-	(*CreateTripRequest)(nil),   // 0: trip.CreateTripRequest
-	(*CreateTripResponse)(nil),  // 1: trip.CreateTripResponse
-	(*Trip)(nil),                // 2: trip.Trip
-	(*TripDriver)(nil),          // 3: trip.TripDriver
-	(*PreviewTripRequest)(nil),  // 4: trip.PreviewTripRequest
-	(*Coordinate)(nil),          // 5: trip.Coordinate
-	(*PreviewTripResponse)(nil), // 6: trip.PreviewTripResponse
-	(*Route)(nil),               // 7: trip.Route
-	(*RideFare)(nil),            // 8: trip.RideFare
-	(*Geometry)(nil),            // 9: trip.Geometry
+	(*CreateTripRequest)(nil),        // 0: trip.CreateTripRequest
+	(*CreateTripResponse)(nil),       // 1: trip.CreateTripResponse
+	(*CancelTripRequest)(nil),        // 2: trip.CancelTripRequest
+	(*CancelTripResponse)(nil),       // 3: trip.CancelTripResponse
+	(*GetTripRequest)(nil),           // 4: trip.GetTripRequest
+	(*GetTripResponse)(nil),          // 5: trip.GetTripResponse
+	(*ListTripsByUserRequest)(nil),   // 6: trip.ListTripsByUserRequest
+	(*ListTripsByDriverRequest)(nil), // 7: trip.ListTripsByDriverRequest
+	(*TripFilter)(nil),               // 8: trip.TripFilter
+	(*ListTripsResponse)(nil),        // 9: trip.ListTripsResponse
+	(*Trip)(nil),                     // 10: trip.Trip
+	(*TripCancellation)(nil),         // 11: trip.TripCancellation
+	(*TripDriver)(nil),               // 12: trip.TripDriver
+	(*PreviewTripRequest)(nil),       // 13: trip.PreviewTripRequest
+	(*Coordinate)(nil),               // 14: trip.Coordinate
+	(*PreviewTripResponse)(nil),      // 15: trip.PreviewTripResponse
+	(*Surge)(nil),                    // 16: trip.Surge
+	(*Route)(nil),                    // 17: trip.Route
+	(*RideFare)(nil),                 // 18: trip.RideFare
+	(*FareBreakdown)(nil),            // 19: trip.FareBreakdown
+	(*Geometry)(nil),                 // 20: trip.Geometry
 }
 var file_trip_proto_depIdxs = []int32{
-	8,  // 0: trip.CreateTripRequest.rideFares:type_name -> trip.RideFare
-	2,  // 1: trip.CreateTripResponse.trip:type_name -> trip.Trip
-	8,  // 2: trip.Trip.selectedFare:type_name -> trip.RideFare
-	7,  // 3: trip.Trip.route:type_name -> trip.Route
-	3,  // 4: trip.Trip.driver:type_name -> trip.TripDriver
-	5,  // 5: trip.PreviewTripRequest.startLocation:type_name -> trip.Coordinate
-	5,  // 6: trip.PreviewTripRequest.endLocation:type_name -> trip.Coordinate
-	7,  // 7: trip.PreviewTripResponse.route:type_name -> trip.Route
-	8,  // 8: trip.PreviewTripResponse.rideFares:type_name -> trip.RideFare
-	9,  // 9: trip.Route.geometry:type_name -> trip.Geometry
-	5,  // 10: trip.Geometry.coordinates:type_name -> trip.Coordinate
-	4,  // 11: trip.TripService.PreviewTrip:input_type -> trip.PreviewTripRequest
-	0,  // 12: trip.TripService.CreateTrip:input_type -> trip.CreateTripRequest
-	6,  // 13: trip.TripService.PreviewTrip:output_type -> trip.PreviewTripResponse
-	1,  // 14: trip.TripService.CreateTrip:output_type -> trip.CreateTripResponse
-	13, // [13:15] is the sub-list for method output_type
-	11, // [11:13] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	18, // 0: trip.CreateTripRequest.rideFares:type_name -> trip.RideFare
+	10, // 1: trip.CreateTripResponse.trip:type_name -> trip.Trip
+	10, // 2: trip.CancelTripResponse.trip:type_name -> trip.Trip
+	10, // 3: trip.GetTripResponse.trip:type_name -> trip.Trip
+	8,  // 4: trip.ListTripsByUserRequest.filter:type_name -> trip.TripFilter
+	8,  // 5: trip.ListTripsByDriverRequest.filter:type_name -> trip.TripFilter
+	10, // 6: trip.ListTripsResponse.trips:type_name -> trip.Trip
+	18, // 7: trip.Trip.selectedFare:type_name -> trip.RideFare
+	17, // 8: trip.Trip.route:type_name -> trip.Route
+	12, // 9: trip.Trip.driver:type_name -> trip.TripDriver
+	11, // 10: trip.Trip.cancellation:type_name -> trip.TripCancellation
+	14, // 11: trip.PreviewTripRequest.startLocation:type_name -> trip.Coordinate
+	14, // 12: trip.PreviewTripRequest.endLocation:type_name -> trip.Coordinate
+	17, // 13: trip.PreviewTripResponse.route:type_name -> trip.Route
+	18, // 14: trip.PreviewTripResponse.rideFares:type_name -> trip.RideFare
+	16, // 15: trip.PreviewTripResponse.surge:type_name -> trip.Surge
+	20, // 16: trip.Route.geometry:type_name -> trip.Geometry
+	19, // 17: trip.RideFare.breakdown:type_name -> trip.FareBreakdown
+	14, // 18: trip.Geometry.coordinates:type_name -> trip.Coordinate
+	13, // 19: trip.TripService.PreviewTrip:input_type -> trip.PreviewTripRequest
+	0,  // 20: trip.TripService.CreateTrip:input_type -> trip.CreateTripRequest
+	2,  // 21: trip.TripService.CancelTrip:input_type -> trip.CancelTripRequest
+	4,  // 22: trip.TripService.GetTrip:input_type -> trip.GetTripRequest
+	6,  // 23: trip.TripService.ListTripsByUser:input_type -> trip.ListTripsByUserRequest
+	7,  // 24: trip.TripService.ListTripsByDriver:input_type -> trip.ListTripsByDriverRequest
+	15, // 25: trip.TripService.PreviewTrip:output_type -> trip.PreviewTripResponse
+	1,  // 26: trip.TripService.CreateTrip:output_type -> trip.CreateTripResponse
+	3,  // 27: trip.TripService.CancelTrip:output_type -> trip.CancelTripResponse
+	5,  // 28: trip.TripService.GetTrip:output_type -> trip.GetTripResponse
+	9,  // 29: trip.TripService.ListTripsByUser:output_type -> trip.ListTripsResponse
+	9,  // 30: trip.TripService.ListTripsByDriver:output_type -> trip.ListTripsResponse
+	25, // [25:31] is the sub-list for method output_type
+	19, // [19:25] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_trip_proto_init() }
@@ -746,7 +1601,7 @@ func file_trip_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_trip_proto_rawDesc), len(file_trip_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   10,
+			NumMessages:   21,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
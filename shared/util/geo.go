@@ -0,0 +1,20 @@
+package util
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+// HaversineDistanceKm returns the great-circle distance in kilometers between two coordinates
+func HaversineDistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := degreesToRadians(lat2 - lat1)
+	dLon := degreesToRadians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(degreesToRadians(lat1))*math.Cos(degreesToRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+func degreesToRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
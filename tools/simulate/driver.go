@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"ride-sharing/shared/auth"
+	"ride-sharing/shared/contracts"
+	"ride-sharing/shared/messaging"
+	pbd "ride-sharing/shared/proto/driver"
+	"ride-sharing/shared/types"
+	"time"
+)
+
+// fakeDriver is a driver app connected to /ws/drivers, answering the trips it is offered
+type fakeDriver struct {
+	id          string
+	packageSlug string
+	cfg         config
+	stats       *stats
+
+	conn     *wsConn
+	driver   *pbd.Driver
+	location types.Coordinate
+}
+
+func (d *fakeDriver) Run(ctx context.Context) {
+	token, err := d.cfg.token(d.id, auth.RoleDriver)
+	if err != nil {
+		d.stats.failed("driver token", err)
+		return
+	}
+
+	query := url.Values{"userID": {d.id}, "packageSlug": {d.packageSlug}, "token": {token}}
+
+	conn, err := dialWS(ctx, d.cfg.wsURL("/ws/drivers", query))
+	if err != nil {
+		d.stats.failed("driver connect", err)
+		return
+	}
+	defer conn.Close()
+	d.conn = conn
+	d.stats.driverConnected()
+
+	// the connection is dropped when the run is over, which ends the read loop
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	d.location = randomPoint(d.cfg.center, d.cfg.radiusKm)
+	go d.drive(ctx)
+
+	for {
+		var msg struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			if ctx.Err() == nil {
+				d.stats.failed("driver read", err)
+			}
+			return
+		}
+
+		switch msg.Type {
+		case contracts.DriverCmdRegister:
+			var driver pbd.Driver
+			if err := json.Unmarshal(msg.Data, &driver); err != nil {
+				d.stats.failed("driver register", err)
+				continue
+			}
+			d.driver = &driver
+		case contracts.DriverCmdTripRequest:
+			var payload messaging.TripEventData
+			if err := json.Unmarshal(msg.Data, &payload); err != nil {
+				d.stats.failed("driver trip request", err)
+				continue
+			}
+			go d.answer(ctx, payload)
+		}
+	}
+}
+
+// drive reports a slightly different location every few seconds, as the driver app does
+func (d *fakeDriver) drive(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.locationInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.conn.WriteJSON(contracts.WSMessage{
+			Type: contracts.DriverCmdLocation,
+			Data: map[string]any{"location": d.location},
+		}); err != nil {
+			if ctx.Err() == nil {
+				d.stats.failed("driver location", err)
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// roughly 100m per update, far below what the gateway rejects as a jump
+			d.location = randomPoint(d.location, 0.1)
+		}
+	}
+}
+
+// answer accepts or declines the trip after a short thought, and drives accepted trips to completion
+func (d *fakeDriver) answer(ctx context.Context, payload messaging.TripEventData) {
+	accept := rand.Float64() < d.cfg.acceptProbability
+	d.stats.tripOffered(accept)
+
+	if !sleep(ctx, d.cfg.answerDelay) {
+		return
+	}
+
+	response := messaging.DriverTripResponseData{
+		Driver:  d.driver,
+		TripID:  payload.Trip.GetId(),
+		RiderID: payload.Trip.GetUserID(),
+	}
+
+	if !accept {
+		d.send(contracts.DriverCmdTripDecline, response)
+		return
+	}
+
+	if !d.send(contracts.DriverCmdTripAccept, response) || d.cfg.tripDuration <= 0 {
+		return
+	}
+
+	// pick the rider up and drop them off, so the driver becomes available again
+	if !sleep(ctx, d.cfg.tripDuration/2) || !d.send(contracts.DriverCmdTripStart, response) {
+		return
+	}
+	if sleep(ctx, d.cfg.tripDuration/2) {
+		d.send(contracts.DriverCmdTripComplete, response)
+	}
+}
+
+func (d *fakeDriver) send(msgType string, data any) bool {
+	if err := d.conn.WriteJSON(contracts.WSMessage{Type: msgType, Data: data}); err != nil {
+		d.stats.failed(fmt.Sprintf("driver %s", msgType), err)
+		return false
+	}
+
+	return true
+}
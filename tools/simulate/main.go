@@ -0,0 +1,273 @@
+// Command simulate puts load on the API gateway: fake drivers connect to /ws/drivers and answer
+// the trips they are offered, while fake riders preview and book trips and wait on /ws/riders
+// for a driver. It reports the match latency, the share of trips without drivers and the errors.
+//
+//	go run ./tools/simulate -drivers 50 -riders 200 -trip-rate 5 -duration 5m
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"ride-sharing/shared/auth"
+	"ride-sharing/shared/types"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+type config struct {
+	gateway           string
+	drivers           int
+	riders            int
+	packages          []string
+	driverRate        float64 // drivers connecting per second
+	tripRate          float64 // trips booked per second, over all the riders
+	acceptProbability float64
+	answerDelay       time.Duration
+	tripDuration      time.Duration
+	matchTimeout      time.Duration
+	locationInterval  time.Duration
+	center            types.Coordinate
+	radiusKm          float64
+	tripLengthKm      float64
+	duration          time.Duration
+	reportInterval    time.Duration
+	authSecret        string // signs the access tokens of the fake users, if the gateway checks them
+}
+
+// token returns an access token for the user, or nothing when the gateway doesn't authenticate
+func (c config) token(userID string, role auth.Role) (string, error) {
+	if c.authSecret == "" {
+		return "", nil
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  userID,
+		"role": role,
+		"exp":  time.Now().Add(c.duration + time.Hour).Unix(),
+	}).SignedString([]byte(c.authSecret))
+}
+
+func (c config) wsURL(path string, query url.Values) string {
+	u := strings.Replace(strings.Replace(c.gateway, "https://", "wss://", 1), "http://", "ws://", 1)
+	return u + path + "?" + query.Encode()
+}
+
+func main() {
+	cfg, err := parseFlags()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.duration)
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		<-sigCh
+		cancel()
+	}()
+
+	stats := newStats()
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	var wg sync.WaitGroup
+
+	// ramp the drivers up
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < cfg.drivers; i++ {
+			driver := &fakeDriver{
+				id:          fmt.Sprintf("loadtest-driver-%d", i+1),
+				packageSlug: cfg.packages[i%len(cfg.packages)],
+				cfg:         cfg,
+				stats:       stats,
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				driver.Run(ctx)
+			}()
+
+			if !sleep(ctx, interval(cfg.driverRate)) {
+				return
+			}
+		}
+	}()
+
+	riders := make([]*fakeRider, cfg.riders)
+	for i := range riders {
+		riders[i] = newFakeRider(fmt.Sprintf("loadtest-rider-%d", i+1), cfg, stats, client)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			riders[i].Listen(ctx)
+		}()
+	}
+
+	// trips arrive as a Poisson process, booked by any rider not already waiting for a driver
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if len(riders) == 0 {
+			return
+		}
+
+		for sleep(ctx, time.Duration(rand.ExpFloat64()*float64(interval(cfg.tripRate)))) {
+			requested := false
+			for _, i := range rand.Perm(len(riders)) {
+				if riders[i].TryRequestTrip(ctx) {
+					requested = true
+					break
+				}
+			}
+
+			if !requested {
+				stats.failed("trip arrival", fmt.Errorf("all %d riders are waiting for a driver", len(riders)))
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(cfg.reportInterval)
+	defer ticker.Stop()
+
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-ticker.C:
+			fmt.Print(stats.Report())
+		}
+	}
+
+	wg.Wait()
+	fmt.Print(stats.Report())
+}
+
+func parseFlags() (config, error) {
+	var cfg config
+	var packages, center string
+
+	flag.StringVar(&cfg.gateway, "gateway", "http://localhost:8081", "URL of the API gateway")
+	flag.IntVar(&cfg.drivers, "drivers", 20, "number of fake drivers")
+	flag.IntVar(&cfg.riders, "riders", 50, "number of fake riders")
+	flag.StringVar(&packages, "packages", "sedan,suv,van,luxury", "comma separated packages the drivers drive and the riders book")
+	flag.Float64Var(&cfg.driverRate, "driver-rate", 5, "drivers connecting per second while ramping up")
+	flag.Float64Var(&cfg.tripRate, "trip-rate", 1, "trips booked per second, on average")
+	flag.Float64Var(&cfg.acceptProbability, "accept", 0.8, "probability a driver accepts the trip it is offered")
+	flag.DurationVar(&cfg.answerDelay, "answer-delay", 2*time.Second, "how long drivers take to answer a trip")
+	flag.DurationVar(&cfg.tripDuration, "trip-duration", 30*time.Second, "how long an accepted trip takes until the driver is available again, 0 to never complete trips")
+	flag.DurationVar(&cfg.matchTimeout, "match-timeout", 3*time.Minute, "how long a rider waits for a driver")
+	flag.DurationVar(&cfg.locationInterval, "location-interval", 5*time.Second, "how often drivers report their location")
+	flag.StringVar(&center, "center", "37.7749,-122.4194", "latitude,longitude the drivers and pickups are spread around")
+	flag.Float64Var(&cfg.radiusKm, "radius", 3, "radius in km the drivers and pickups are spread over")
+	flag.Float64Var(&cfg.tripLengthKm, "trip-length", 3, "maximum distance in km between the pickup and the destination")
+	flag.DurationVar(&cfg.duration, "duration", 2*time.Minute, "how long the simulation runs")
+	flag.DurationVar(&cfg.reportInterval, "report-interval", 10*time.Second, "how often the stats are printed")
+	flag.StringVar(&cfg.authSecret, "auth-secret", "", "HMAC secret of the gateway, to sign the access tokens of the fake users")
+	flag.Parse()
+
+	cfg.gateway = strings.TrimSuffix(cfg.gateway, "/")
+
+	for _, slug := range strings.Split(packages, ",") {
+		if slug = strings.TrimSpace(slug); slug != "" {
+			cfg.packages = append(cfg.packages, slug)
+		}
+	}
+	if len(cfg.packages) == 0 {
+		return cfg, fmt.Errorf("-packages must list at least one package")
+	}
+
+	lat, lon, ok := strings.Cut(center, ",")
+	latitude, latErr := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	longitude, lonErr := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	if !ok || latErr != nil || lonErr != nil {
+		return cfg, fmt.Errorf("-center must be latitude,longitude, got %q", center)
+	}
+	cfg.center = types.Coordinate{Latitude: latitude, Longitude: longitude}
+
+	switch {
+	case cfg.drivers < 0 || cfg.riders < 0:
+		return cfg, fmt.Errorf("-drivers and -riders can't be negative")
+	case cfg.riders > 0 && cfg.tripRate <= 0:
+		return cfg, fmt.Errorf("-trip-rate must be positive")
+	case cfg.driverRate <= 0:
+		return cfg, fmt.Errorf("-driver-rate must be positive")
+	case cfg.acceptProbability < 0 || cfg.acceptProbability > 1:
+		return cfg, fmt.Errorf("-accept must be between 0 and 1")
+	case cfg.reportInterval <= 0 || cfg.locationInterval <= 0:
+		return cfg, fmt.Errorf("-report-interval and -location-interval must be positive")
+	}
+
+	return cfg, nil
+}
+
+// wsConn is a websocket connection safe for concurrent writes
+type wsConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+func dialWS(ctx context.Context, u string) (*wsConn, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wsConn{Conn: conn}, nil
+}
+
+func (c *wsConn) WriteJSON(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.Conn.WriteJSON(v)
+}
+
+// randomPoint returns a point uniformly spread within radiusKm of the center
+func randomPoint(center types.Coordinate, radiusKm float64) types.Coordinate {
+	const kmPerDegree = 111.32
+
+	distance := radiusKm * math.Sqrt(rand.Float64())
+	bearing := rand.Float64() * 2 * math.Pi
+
+	return types.Coordinate{
+		Latitude:  center.Latitude + distance*math.Cos(bearing)/kmPerDegree,
+		Longitude: center.Longitude + distance*math.Sin(bearing)/(kmPerDegree*math.Cos(center.Latitude*math.Pi/180)),
+	}
+}
+
+// interval returns the time between two events happening rate times per second
+func interval(rate float64) time.Duration {
+	return time.Duration(float64(time.Second) / rate)
+}
+
+// sleep waits for d, returning false if ctx was cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"ride-sharing/shared/auth"
+	"ride-sharing/shared/contracts"
+	pb "ride-sharing/shared/proto/trip"
+	"sync/atomic"
+	"time"
+)
+
+// fakeRider is a rider app listening on /ws/riders, booking a trip when asked to
+type fakeRider struct {
+	id     string
+	token  string
+	cfg    config
+	stats  *stats
+	client *http.Client
+
+	busy   atomic.Bool
+	events chan string // types of the trip events received on the websocket
+}
+
+func newFakeRider(id string, cfg config, stats *stats, client *http.Client) *fakeRider {
+	token, err := cfg.token(id, auth.RoleRider)
+	if err != nil {
+		stats.failed("rider token", err)
+	}
+
+	return &fakeRider{
+		id:     id,
+		token:  token,
+		cfg:    cfg,
+		stats:  stats,
+		client: client,
+		events: make(chan string, 16),
+	}
+}
+
+func (r *fakeRider) Listen(ctx context.Context) {
+	conn, err := dialWS(ctx, r.cfg.wsURL("/ws/riders", url.Values{"userID": {r.id}, "token": {r.token}}))
+	if err != nil {
+		r.stats.failed("rider connect", err)
+		return
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	for {
+		var msg struct {
+			Type string `json:"type"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			if ctx.Err() == nil {
+				r.stats.failed("rider read", err)
+			}
+			return
+		}
+
+		switch msg.Type {
+		case contracts.TripEventDriverAssigned, contracts.TripEventNoDriversFound:
+			select {
+			case r.events <- msg.Type:
+			default:
+			}
+		}
+	}
+}
+
+// TryRequestTrip books a trip unless the rider already waits for one, returning false if busy
+func (r *fakeRider) TryRequestTrip(ctx context.Context) bool {
+	if !r.busy.CompareAndSwap(false, true) {
+		return false
+	}
+
+	go func() {
+		defer r.busy.Store(false)
+		r.requestTrip(ctx)
+	}()
+
+	return true
+}
+
+func (r *fakeRider) requestTrip(ctx context.Context) {
+	// forget the events of a previous trip
+	for len(r.events) > 0 {
+		<-r.events
+	}
+
+	pickup := randomPoint(r.cfg.center, r.cfg.radiusKm)
+	destination := randomPoint(pickup, r.cfg.tripLengthKm)
+
+	var preview pb.PreviewTripResponse
+	if err := r.post(ctx, "/trip/preview", map[string]any{
+		"userID":      r.id,
+		"pickup":      pickup,
+		"destination": destination,
+	}, &preview); err != nil {
+		r.stats.failed("trip preview", err)
+		return
+	}
+
+	fare := r.pickFare(preview.GetRideFares())
+	if fare == nil {
+		r.stats.failed("trip preview", errors.New("no fare offered"))
+		return
+	}
+
+	requestedAt := time.Now()
+
+	var created pb.CreateTripResponse
+	if err := r.post(ctx, "/trip/start", map[string]any{
+		"userID":     r.id,
+		"rideFareID": fare.GetId(),
+	}, &created); err != nil {
+		r.stats.failed("trip start", err)
+		return
+	}
+	r.stats.tripRequested()
+
+	timeout := time.NewTimer(r.cfg.matchTimeout)
+	defer timeout.Stop()
+
+	select {
+	case <-ctx.Done():
+	case event := <-r.events:
+		if event == contracts.TripEventDriverAssigned {
+			r.stats.tripMatched(time.Since(requestedAt))
+		} else {
+			r.stats.tripNotMatched(false)
+		}
+	case <-timeout.C:
+		r.stats.tripNotMatched(true)
+	}
+}
+
+// pickFare books one of the packages the fake drivers drive
+func (r *fakeRider) pickFare(fares []*pb.RideFare) *pb.RideFare {
+	var candidates []*pb.RideFare
+	for _, fare := range fares {
+		for _, slug := range r.cfg.packages {
+			if fare.GetPackageSlug() == slug {
+				candidates = append(candidates, fare)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return candidates[rand.IntN(len(candidates))]
+}
+
+// post sends the body to the gateway and decodes the data of the response into out
+func (r *fakeRider) post(ctx context.Context, path string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.gateway+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Data  json.RawMessage     `json:"data"`
+		Error *contracts.APIError `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("status %d: %v", resp.StatusCode, err)
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("status %d: %s: %s", resp.StatusCode, response.Error.Code, response.Error.Message)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return json.Unmarshal(response.Data, out)
+}
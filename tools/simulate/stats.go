@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stats collects what the simulated riders and drivers went through
+type stats struct {
+	started time.Time
+
+	tripsRequested int
+	tripsMatched   int
+	noDrivers      int
+	matchTimeouts  int
+	matchLatencies []time.Duration
+
+	driversConnected int
+	offers           int
+	accepted         int
+	declined         int
+
+	errors map[string]int // what failed -> count
+
+	mu sync.Mutex
+}
+
+func newStats() *stats {
+	return &stats{
+		started: time.Now(),
+		errors:  make(map[string]int),
+	}
+}
+
+func (s *stats) tripRequested() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tripsRequested++
+}
+
+func (s *stats) tripMatched(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tripsMatched++
+	s.matchLatencies = append(s.matchLatencies, latency)
+}
+
+func (s *stats) tripNotMatched(timedOut bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if timedOut {
+		s.matchTimeouts++
+	} else {
+		s.noDrivers++
+	}
+}
+
+func (s *stats) driverConnected() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.driversConnected++
+}
+
+func (s *stats) tripOffered(accepted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offers++
+	if accepted {
+		s.accepted++
+	} else {
+		s.declined++
+	}
+}
+
+func (s *stats) failed(what string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[what]++
+
+	// only the first occurrence is logged, the counts are in the report
+	if s.errors[what] == 1 {
+		fmt.Printf("[%s] %s failed: %v\n", time.Since(s.started).Round(time.Second), what, err)
+	}
+}
+
+// Report describes the run so far
+func (s *stats) Report() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+	elapsed := time.Since(s.started).Round(time.Second)
+
+	fmt.Fprintf(&b, "--- after %s ---\n", elapsed)
+	fmt.Fprintf(&b, "trips:   %d requested, %d matched, %d no drivers (%s), %d timed out, %d pending\n",
+		s.tripsRequested, s.tripsMatched, s.noDrivers, percent(s.noDrivers, s.tripsRequested), s.matchTimeouts,
+		s.tripsRequested-s.tripsMatched-s.noDrivers-s.matchTimeouts,
+	)
+	fmt.Fprintf(&b, "drivers: %d connected, %d offers, %d accepted, %d declined\n",
+		s.driversConnected, s.offers, s.accepted, s.declined,
+	)
+
+	if len(s.matchLatencies) > 0 {
+		latencies := append([]time.Duration(nil), s.matchLatencies...)
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		fmt.Fprintf(&b, "match latency: p50 %s, p95 %s, p99 %s, max %s\n",
+			quantile(latencies, 0.50), quantile(latencies, 0.95), quantile(latencies, 0.99), latencies[len(latencies)-1],
+		)
+	}
+
+	if len(s.errors) > 0 {
+		whats := make([]string, 0, len(s.errors))
+		for what := range s.errors {
+			whats = append(whats, what)
+		}
+		sort.Strings(whats)
+
+		fmt.Fprintf(&b, "errors:")
+		for _, what := range whats {
+			fmt.Fprintf(&b, " %s=%d", what, s.errors[what])
+		}
+		fmt.Fprintln(&b)
+	}
+
+	return b.String()
+}
+
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	index := int(q * float64(len(sorted)-1))
+	return sorted[index].Round(time.Millisecond)
+}
+
+func percent(part, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
@@ -1,4 +1,4 @@
-import { Coordinate, Driver, Route, RouteFare, Trip } from "./types";
+import { Coordinate, Driver, Route, RouteFare, Surge, Trip } from "./types";
 
 // These are the endpoints the API Gateway must have for the frontend to work correctly
 export enum BackendEndpoints {
@@ -11,26 +11,36 @@ export enum BackendEndpoints {
 export enum TripEvents {
   NoDriversFound = "trip.event.no_drivers_found",
   DriverAssigned = "trip.event.driver_assigned",
+  DriverArriving = "trip.event.driver_arriving",
+  Started = "trip.event.started",
   Completed = "trip.event.completed",
   Cancelled = "trip.event.cancelled",
+  PaymentFailed = "trip.event.payment_failed",
   Created = "trip.event.created",
   DriverLocation = "driver.cmd.location",
+  DriverLocationUpdated = "driver.event.location",
   DriverTripRequest = "driver.cmd.trip_request",
   DriverTripAccept = "driver.cmd.trip_accept",
   DriverTripDecline = "driver.cmd.trip_decline",
+  DriverTripCancel = "driver.cmd.trip_cancel",
+  DriverTripStart = "driver.cmd.trip_start",
+  DriverTripComplete = "driver.cmd.trip_complete",
   DriverRegister = "driver.cmd.register",
   PaymentSessionCreated = "payment.event.session_created",
 }
 
-// Messages sent from the server to the client via the websocket
-export type ServerWsMessage =
+// Messages sent from the server to the client via the websocket.
+// Numbered messages are replayed after the one passed as `lastEventID` when reconnecting.
+export type ServerWsMessage = { id?: number } & (
   | PaymentSessionCreatedRequest
   | DriverAssignedRequest
   | DriverLocationRequest
+  | DriverLocationUpdatedRequest
   | DriverTripRequest
   | DriverRegisterRequest
   | TripCreatedRequest
-  | NoDriversFoundRequest;
+  | NoDriversFoundRequest
+);
 
 // Messages sent from the client to the server via the websocket
 export type ClientWsMessage = DriverResponseToTripResponse;
@@ -75,6 +85,14 @@ interface DriverLocationRequest {
   data: Driver[];
 }
 
+interface DriverLocationUpdatedRequest {
+  type: TripEvents.DriverLocationUpdated;
+  data: {
+    tripID: string;
+    driver: Driver;
+  };
+}
+
 interface DriverResponseToTripResponse {
   type: TripEvents.DriverTripAccept | TripEvents.DriverTripDecline;
   data: {
@@ -87,6 +105,7 @@ interface DriverResponseToTripResponse {
 export interface HTTPTripPreviewResponse {
   route: Route;
   rideFares: RouteFare[];
+  surge?: Surge;
 }
 
 export interface HTTPTripStartRequestPayload {
@@ -40,12 +40,15 @@ export function useRiderStreamConnection(location: Coordinate, userID: string) {
         case TripEvents.DriverLocation:
           setDrivers(message.data);
           break;
+        case TripEvents.DriverLocationUpdated:
+          setAssignedDriver(message.data.driver);
+          break;
         case TripEvents.PaymentSessionCreated:
           setPaymentSession(message.data);
           setTripStatus(message.type);
           break;
         case TripEvents.DriverAssigned:
-          setAssignedDriver(message.data.driver);
+          setAssignedDriver(message.data.trip.driver);
           setTripStatus(message.type);
           break;
         case TripEvents.Created:
@@ -6,6 +6,12 @@ export interface Trip {
   route: Route;
   driver?: Driver;
   trip: Trip;
+  createdAt?: string;
+}
+
+export interface TripsPage {
+  trips?: Trip[];
+  nextPageToken?: string;
 }
 
 export interface RequestRideProps {
@@ -38,10 +44,35 @@ export interface RouteFare {
   packageSlug: CarPackageSlug;
   basePrice: number;
   totalPriceInCents?: number;
+  breakdown?: FareBreakdown;
+  currency?: string;
+  pricingVersion?: string;
   expiresAt: Date;
   route: Route;
 }
 
+export interface Surge {
+  multiplier: number;
+  geohash: string;
+  // Explains riders why fares are higher, empty without surge
+  reason?: string;
+  availableDrivers?: number;
+  recentTrips?: number;
+}
+
+export interface FareBreakdown {
+  baseFareInCents?: number;
+  distanceFareInCents?: number;
+  timeFareInCents?: number;
+  minimumFareAdjustmentInCents?: number;
+  bookingFeeInCents?: number;
+  roundingAdjustmentInCents?: number;
+  distanceKm?: number;
+  durationMinutes?: number;
+  surgeMultiplier?: number;
+  surgeFareInCents?: number;
+}
+
 export interface HTTPTripStartResponse {
   tripID: string;
 }